@@ -0,0 +1,155 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Code identifies the kind of an error for API clients; unlike messages, codes never change
+type Code string
+
+// Codes shared by all resources. Services define more specific codes next to their errors.
+const (
+	CodeInvalidRequest   Code = "invalid_request"
+	CodeValidationFailed Code = "validation_failed"
+	CodeNotFound         Code = "not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodePayloadTooLarge  Code = "payload_too_large"
+	CodeInternal         Code = "internal_error"
+)
+
+// FieldError describes why a single request field was rejected. Rule and Param name the
+// validation rule that failed, e.g. "max" and "200"; when Message is empty it is filled in
+// from the rule in the language of the request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+
+	kind reflect.Kind
+}
+
+// Error is an application error carrying everything needed to report it to API clients.
+// Message, Fields and Details are shown to clients; the wrapped Err is only logged.
+type Error struct {
+	Code    Code
+	Message string
+	Status  int
+	Fields  []FieldError
+	Details map[string]interface{}
+	Err     error
+}
+
+// New creates an application error
+func New(status int, code Code, message string) *Error {
+	return &Error{Code: code, Message: message, Status: status}
+}
+
+// InvalidRequest creates an error for a malformed request
+func InvalidRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidRequest, message)
+}
+
+// Internal wraps an unexpected error without exposing it to clients
+func Internal(err error) *Error {
+	return &Error{
+		Code:    CodeInternal,
+		Message: "Internal server error",
+		Status:  http.StatusInternalServerError,
+		Err:     err,
+	}
+}
+
+// Validation converts a request validation failure into an error listing the rejected
+// fields. Fields are named by their path in the JSON request, e.g. "photos[1]".
+func Validation(err error) *Error {
+	e := New(http.StatusUnprocessableEntity, CodeValidationFailed, "request validation failed")
+	e.Err = err
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		e.Message = err.Error()
+		return e
+	}
+
+	for _, fe := range validationErrs {
+		// The namespace starts with the name of the validated struct
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		e.Fields = append(e.Fields, FieldError{
+			Field: field,
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+			kind:  fe.Kind(),
+		})
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an application error with the same code, so copies
+// made with the With methods still match the sentinel they were made from
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with a different message
+func (e *Error) WithMessage(message string) *Error {
+	c := e.clone()
+	c.Message = message
+	return c
+}
+
+// WithDetail returns a copy of the error with an extra detail for clients
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	c := e.clone()
+	c.Details = make(map[string]interface{}, len(e.Details)+1)
+	for k, v := range e.Details {
+		c.Details[k] = v
+	}
+	c.Details[key] = value
+	return c
+}
+
+// WithFields returns a copy of the error listing rejected request fields
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	c := e.clone()
+	c.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return c
+}
+
+// Wrap returns a copy of the error recording the underlying cause
+func (e *Error) Wrap(err error) *Error {
+	c := e.clone()
+	c.Err = err
+	return c
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	return &c
+}
+
+// From returns the application error in err's chain, or an internal error wrapping err
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
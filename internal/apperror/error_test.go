@@ -0,0 +1,139 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var errWidgetNotFound = New(http.StatusNotFound, "widget_not_found", "widget not found")
+
+func TestError_IsMatchesCopies(t *testing.T) {
+	err := fmt.Errorf("loading: %w", errWidgetNotFound.WithDetail("id", 7).Wrap(errors.New("no rows")))
+
+	if !errors.Is(err, errWidgetNotFound) {
+		t.Errorf("errors.Is(copy, sentinel) = false, want true")
+	}
+	if errors.Is(err, New(http.StatusNotFound, CodeNotFound, "widget not found")) {
+		t.Errorf("errors.Is() with a different code = true, want false")
+	}
+	if errWidgetNotFound.Details != nil {
+		t.Errorf("WithDetail() modified the sentinel: %v", errWidgetNotFound.Details)
+	}
+}
+
+func TestFrom(t *testing.T) {
+	if got := From(fmt.Errorf("wrapped: %w", errWidgetNotFound)); got.Code != "widget_not_found" {
+		t.Errorf("From() code = %q, want widget_not_found", got.Code)
+	}
+
+	got := From(errors.New("connection refused"))
+	if got.Status != http.StatusInternalServerError || got.Code != CodeInternal {
+		t.Errorf("From() = %d %q, want 500 %q", got.Status, got.Code, CodeInternal)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	type request struct {
+		Title  string   `validate:"required"`
+		Photos []string `validate:"min=1,dive,url"`
+	}
+
+	err := Validation(validator.New().Struct(request{Photos: []string{"not a url"}}))
+	if err.Status != http.StatusUnprocessableEntity || err.Code != CodeValidationFailed {
+		t.Errorf("Validation() = %d %q, want 422 %q", err.Status, err.Code, CodeValidationFailed)
+	}
+	want := []FieldError{
+		{Field: "Title", Rule: "required", kind: reflect.String},
+		{Field: "Photos[0]", Rule: "url", kind: reflect.String},
+	}
+	if !reflect.DeepEqual(err.Fields, want) {
+		t.Errorf("Validation() fields = %+v, want %+v", err.Fields, want)
+	}
+
+	if err := Validation(errors.New("duplicate attribute key")); err.Message != "duplicate attribute key" {
+		t.Errorf("Validation() message = %q, want the error text", err.Message)
+	}
+}
+
+func TestFieldError_Localize(t *testing.T) {
+	tests := []struct {
+		field FieldError
+		lang  string
+		want  string
+	}{
+		{FieldError{Rule: "required"}, "en", "is required"},
+		{FieldError{Rule: "required"}, "ru", "обязательное поле"},
+		{FieldError{Rule: "required"}, "kk", "обязательное поле"},
+		{FieldError{Rule: "max", Param: "200", kind: reflect.String}, "en", "must be at most 200 characters long"},
+		{FieldError{Rule: "min", Param: "1", kind: reflect.Slice}, "ru", "должно содержать не меньше 1 элементов"},
+		{FieldError{Rule: "min", Param: "0", kind: reflect.Int}, "en", "must be at least 0"},
+		{FieldError{Rule: "oneof", Param: "new used"}, "en", "must be one of: new, used"},
+		{FieldError{Rule: "no_such_rule"}, "en", "is invalid"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.field.Localize(tt.lang); got != tt.want {
+			t.Errorf("%+v.Localize(%q) = %q, want %q", tt.field, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	req := httptest.NewRequest("GET", "/widgets/7", nil)
+
+	rec := httptest.NewRecorder()
+	Write(rec, req, errWidgetNotFound.WithDetail("id", 7))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Write() status = %d, want 404", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Write() Content-Type = %q, want application/json", ct)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Error.Code != "widget_not_found" || resp.Error.Message != "widget not found" || resp.Error.Details["id"] != float64(7) {
+		t.Errorf("Write() body = %+v", resp.Error)
+	}
+
+	rec = httptest.NewRecorder()
+	Write(rec, req, errors.New("pq: password authentication failed"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Write() internal status = %d, want 500", rec.Code)
+	}
+	resp = Response{}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Error.Message != "Internal server error" {
+		t.Errorf("Write() internal message = %q, want it hidden", resp.Error.Message)
+	}
+}
+
+func TestWrite_LocalizesFields(t *testing.T) {
+	err := New(http.StatusUnprocessableEntity, CodeValidationFailed, "request validation failed").
+		WithFields(FieldError{Field: "title", Rule: "required"}, FieldError{Field: "price", Rule: "custom", Message: "kept as is"})
+
+	req := httptest.NewRequest("POST", "/widgets?lang=en", nil)
+	rec := httptest.NewRecorder()
+	Write(rec, req, err)
+
+	var resp Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(resp.Error.Fields) != 2 || resp.Error.Fields[0].Message != "is required" || resp.Error.Fields[1].Message != "kept as is" {
+		t.Errorf("Write() fields = %+v", resp.Error.Fields)
+	}
+	if err.Fields[0].Message != "" {
+		t.Errorf("Write() modified the error fields: %+v", err.Fields)
+	}
+}
@@ -0,0 +1,60 @@
+package apperror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"shary_be/internal/i18n"
+)
+
+// Response is the JSON envelope every error response is sent in
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Body describes an error to API clients
+type Body struct {
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	Fields  []FieldError           `json:"fields,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Write sends err as a JSON error response. Errors that are not application errors
+// are reported as internal errors without revealing their message. Field errors are
+// described in the language of the request.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := From(err)
+
+	var fields []FieldError
+	if len(appErr.Fields) > 0 {
+		lang := i18n.FromRequest(r)
+		fields = make([]FieldError, len(appErr.Fields))
+		for i, field := range appErr.Fields {
+			if field.Message == "" {
+				field.Message = field.Localize(lang)
+			}
+			fields[i] = field
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(appErr.Status)
+	json.NewEncoder(w).Encode(Response{Error: Body{
+		Code:    appErr.Code,
+		Message: appErr.Message,
+		Fields:  fields,
+		Details: appErr.Details,
+	}})
+}
+
+// NotFoundHandler responds to requests for unknown routes
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, New(http.StatusNotFound, CodeNotFound, "Route not found"))
+}
+
+// MethodNotAllowedHandler responds to requests with a method the route does not support
+func MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed"))
+}
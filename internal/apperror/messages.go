@@ -0,0 +1,84 @@
+package apperror
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"shary_be/internal/i18n"
+)
+
+// ruleMessage holds the description of a broken validation rule in each language.
+// %s stands for the rule parameter.
+type ruleMessage struct {
+	ru string
+	en string
+}
+
+// ruleMessages describes validation rules; min and max are described by length
+// for strings and by count for lists, see sizeRuleMessages
+var ruleMessages = map[string]ruleMessage{
+	"required":          {ru: "обязательное поле", en: "is required"},
+	"min":               {ru: "должно быть не меньше %s", en: "must be at least %s"},
+	"max":               {ru: "должно быть не больше %s", en: "must be at most %s"},
+	"gt":                {ru: "должно быть больше %s", en: "must be greater than %s"},
+	"gte":               {ru: "должно быть не меньше %s", en: "must be at least %s"},
+	"lt":                {ru: "должно быть меньше %s", en: "must be less than %s"},
+	"lte":               {ru: "должно быть не больше %s", en: "must be at most %s"},
+	"len":               {ru: "должно быть равно %s", en: "must be exactly %s"},
+	"oneof":             {ru: "должно быть одним из: %s", en: "must be one of: %s"},
+	"url":               {ru: "должно быть URL-адресом", en: "must be a valid URL"},
+	"email":             {ru: "должно быть адресом электронной почты", en: "must be a valid email address"},
+	"unique":            {ru: "не должно содержать повторов", en: "must not contain duplicates"},
+	"unknown":           {ru: "не предусмотрено для этой категории", en: "is not defined for this category"},
+	"type":              {ru: "должно иметь тип %s", en: "must be a %s"},
+	"category_required": {ru: "требуют указать категорию", en: "require a category"},
+}
+
+var sizeRuleMessages = map[string]map[reflect.Kind]ruleMessage{
+	"min": {
+		reflect.String: {ru: "должно содержать не меньше %s символов", en: "must be at least %s characters long"},
+		reflect.Slice:  {ru: "должно содержать не меньше %s элементов", en: "must contain at least %s items"},
+	},
+	"max": {
+		reflect.String: {ru: "должно содержать не больше %s символов", en: "must be at most %s characters long"},
+		reflect.Slice:  {ru: "должно содержать не больше %s элементов", en: "must contain at most %s items"},
+	},
+	"len": {
+		reflect.String: {ru: "должно содержать ровно %s символов", en: "must be exactly %s characters long"},
+		reflect.Slice:  {ru: "должно содержать ровно %s элементов", en: "must contain exactly %s items"},
+	},
+}
+
+var invalidMessage = ruleMessage{ru: "недопустимое значение", en: "is invalid"}
+
+// Localize returns the description of the broken rule in the given language.
+// Languages without their own messages get Russian, the default language.
+func (f FieldError) Localize(lang string) string {
+	msg, ok := ruleMessages[f.Rule]
+	if !ok {
+		msg = invalidMessage
+	}
+
+	kind := f.kind
+	if kind == reflect.Array || kind == reflect.Map {
+		kind = reflect.Slice
+	}
+	if sized, ok := sizeRuleMessages[f.Rule][kind]; ok {
+		msg = sized
+	}
+
+	format := msg.ru
+	if lang == i18n.English {
+		format = msg.en
+	}
+	if !strings.Contains(format, "%s") {
+		return format
+	}
+
+	param := f.Param
+	if f.Rule == "oneof" {
+		param = strings.ReplaceAll(param, " ", ", ")
+	}
+	return fmt.Sprintf(format, param)
+}
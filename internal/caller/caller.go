@@ -0,0 +1,81 @@
+// Package caller identifies the user a request is made by. The API does not
+// authenticate users itself: the gateway in front of it authenticates them and names
+// the user in the X-User-ID header. The gateway proves the header is its own with the
+// shared secret in X-Gateway-Secret, so clients cannot name another user.
+package caller
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	"shary_be/internal/apperror"
+)
+
+const (
+	// Header is the request header naming the user
+	Header = "X-User-ID"
+	// GatewaySecretHeader is the request header carrying the gateway's shared secret
+	GatewaySecretHeader = "X-Gateway-Secret"
+)
+
+var (
+	// ErrInvalidUserID is returned when the header does not hold a user ID
+	ErrInvalidUserID = apperror.New(http.StatusBadRequest, "invalid_user_id", "X-User-ID must be a positive integer")
+	// ErrUserRequired is returned when a request that acts for a user names none
+	ErrUserRequired = apperror.New(http.StatusUnauthorized, "user_required", "the request must be made by a user")
+	// ErrUntrustedUserID is returned when the header was not set by the gateway
+	ErrUntrustedUserID = apperror.New(http.StatusUnauthorized, "untrusted_user_id", "X-User-ID is only accepted from the gateway")
+)
+
+// contextKey is the context key of the user ID
+type contextKey struct{}
+
+// Middleware reads the user ID from the header into the request context. Requests
+// without the header are passed through; those with an invalid one, or without the
+// gateway's secret, are rejected. With no secret configured the header is never
+// trusted.
+func Middleware(gatewaySecret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := r.Header.Get(Header)
+			if value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !fromGateway(r, gatewaySecret) {
+				apperror.Write(w, r, ErrUntrustedUserID)
+				return
+			}
+
+			userID, err := strconv.Atoi(value)
+			if err != nil || userID < 1 {
+				apperror.Write(w, r, ErrInvalidUserID)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), userID)))
+		})
+	}
+}
+
+// fromGateway reports whether the request carries the configured gateway secret
+func fromGateway(r *http.Request, gatewaySecret string) bool {
+	if gatewaySecret == "" {
+		return false
+	}
+	got := r.Header.Get(GatewaySecretHeader)
+	return subtle.ConstantTimeCompare([]byte(got), []byte(gatewaySecret)) == 1
+}
+
+// WithUserID returns a context carrying the user ID
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserID returns the user ID ctx carries, if any
+func UserID(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(contextKey{}).(int)
+	return userID, ok
+}
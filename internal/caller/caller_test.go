@@ -0,0 +1,69 @@
+package caller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// echoUser writes the user ID the request context carries, or "none"
+var echoUser = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserID(r.Context())
+	if !ok {
+		w.Write([]byte("none"))
+		return
+	}
+	w.Write([]byte(strconv.Itoa(userID)))
+})
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware("gateway-secret")(echoUser)
+
+	tests := []struct {
+		header   string
+		secret   string
+		wantCode int
+		wantBody string
+	}{
+		{header: "", wantCode: http.StatusOK, wantBody: "none"},
+		{header: "42", secret: "gateway-secret", wantCode: http.StatusOK, wantBody: "42"},
+		{header: "0", secret: "gateway-secret", wantCode: http.StatusBadRequest},
+		{header: "admin", secret: "gateway-secret", wantCode: http.StatusBadRequest},
+		{header: "42", wantCode: http.StatusUnauthorized},
+		{header: "42", secret: "guessed", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set(Header, tt.header)
+		}
+		if tt.secret != "" {
+			req.Header.Set(GatewaySecretHeader, tt.secret)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s %q, secret %q: status = %d, want %d", Header, tt.header, tt.secret, rec.Code, tt.wantCode)
+			continue
+		}
+		if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+			t.Errorf("%s %q: user = %q, want %q", Header, tt.header, rec.Body.String(), tt.wantBody)
+		}
+	}
+}
+
+func TestMiddleware_NoGatewaySecret(t *testing.T) {
+	handler := Middleware("")(echoUser)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(Header, "42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d when no gateway secret is configured", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +16,46 @@ type Config struct {
 	DatabaseURL string
 	Environment string
 	LogLevel    string
+
+	// Whether pending migrations are applied at startup; without it the server refuses
+	// to start until they have been applied
+	MigrateOnStart bool
+
+	// Photo storage
+	StorageBackend   string
+	StorageLocalDir  string
+	StoragePublicURL string
+	UploadMaxBytes   int64
+	UploadMaxFiles   int
+	PhotoWorkers     int
+	PhotoQueueSize   int
+	MaxPhotosPerItem int
+
+	// Importing photos from remote URLs
+	PhotoImportTimeout      time.Duration
+	PhotoImportMaxRedirects int
+
+	// Shared secret the gateway sends with X-User-ID; without it the header is rejected
+	GatewaySecret string
+
+	// Signed URLs for private photos
+	SignedURLSecret string
+	SignedURLTTL    time.Duration
+
+	// Orphaned photo garbage collection; a zero interval disables the periodic job
+	PhotoGCInterval    time.Duration
+	PhotoGCGracePeriod time.Duration
+	PhotoGCDryRun      bool
+
+	// Detection of photos copied from other listings
+	PhotoDuplicatePolicy      string
+	PhotoDuplicateMaxDistance int
+
+	// When the unversioned /api aliases of /api/v1 stop being served
+	LegacyAPISunset time.Time
+
+	// How long responses to requests with an Idempotency-Key are replayed
+	IdempotencyKeyTTL time.Duration
 }
 
 // Load loads configuration from environment variables and .env file
@@ -57,11 +98,156 @@ func Load() *Config {
 		logLevel = "info"
 	}
 
+	storageBackend := os.Getenv("STORAGE_BACKEND")
+	if storageBackend == "" {
+		storageBackend = "local"
+	}
+
+	storageLocalDir := os.Getenv("STORAGE_LOCAL_DIR")
+	if storageLocalDir == "" {
+		storageLocalDir = "./uploads"
+	}
+
+	storagePublicURL := os.Getenv("STORAGE_PUBLIC_URL")
+	if storagePublicURL == "" {
+		storagePublicURL = "/uploads"
+	}
+
+	uploadMaxBytes := int64(10 << 20) // 10 MB per photo by default
+	if sizeStr := os.Getenv("UPLOAD_MAX_SIZE_MB"); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil && size > 0 {
+			uploadMaxBytes = int64(size) << 20
+		}
+	}
+
+	uploadMaxFiles := 10
+	if filesStr := os.Getenv("UPLOAD_MAX_FILES"); filesStr != "" {
+		if files, err := strconv.Atoi(filesStr); err == nil && files > 0 {
+			uploadMaxFiles = files
+		}
+	}
+
+	photoWorkers := 2
+	if workersStr := os.Getenv("PHOTO_WORKERS"); workersStr != "" {
+		if workers, err := strconv.Atoi(workersStr); err == nil && workers > 0 {
+			photoWorkers = workers
+		}
+	}
+
+	photoQueueSize := 100
+	if queueStr := os.Getenv("PHOTO_QUEUE_SIZE"); queueStr != "" {
+		if queue, err := strconv.Atoi(queueStr); err == nil && queue > 0 {
+			photoQueueSize = queue
+		}
+	}
+
+	maxPhotosPerItem := 10
+	if maxStr := os.Getenv("MAX_PHOTOS_PER_ITEM"); maxStr != "" {
+		if max, err := strconv.Atoi(maxStr); err == nil && max > 0 {
+			maxPhotosPerItem = max
+		}
+	}
+
+	photoImportTimeout := 10 * time.Second
+	if timeoutStr := os.Getenv("PHOTO_IMPORT_TIMEOUT_SECONDS"); timeoutStr != "" {
+		if timeout, err := strconv.Atoi(timeoutStr); err == nil && timeout > 0 {
+			photoImportTimeout = time.Duration(timeout) * time.Second
+		}
+	}
+
+	photoImportMaxRedirects := 3
+	if redirectsStr := os.Getenv("PHOTO_IMPORT_MAX_REDIRECTS"); redirectsStr != "" {
+		if redirects, err := strconv.Atoi(redirectsStr); err == nil && redirects >= 0 {
+			photoImportMaxRedirects = redirects
+		}
+	}
+
+	signedURLTTL := 15 * time.Minute
+	if ttlStr := os.Getenv("SIGNED_URL_TTL_MINUTES"); ttlStr != "" {
+		if ttl, err := strconv.Atoi(ttlStr); err == nil && ttl > 0 {
+			signedURLTTL = time.Duration(ttl) * time.Minute
+		}
+	}
+
+	photoGCInterval := time.Hour
+	if intervalStr := os.Getenv("PHOTO_GC_INTERVAL_MINUTES"); intervalStr != "" {
+		if interval, err := strconv.Atoi(intervalStr); err == nil && interval >= 0 {
+			photoGCInterval = time.Duration(interval) * time.Minute
+		}
+	}
+
+	photoGCGracePeriod := 24 * time.Hour
+	if graceStr := os.Getenv("PHOTO_GC_GRACE_HOURS"); graceStr != "" {
+		if grace, err := strconv.Atoi(graceStr); err == nil && grace >= 0 {
+			photoGCGracePeriod = time.Duration(grace) * time.Hour
+		}
+	}
+
+	photoGCDryRun := os.Getenv("PHOTO_GC_DRY_RUN") == "true"
+
+	migrateOnStart := os.Getenv("MIGRATE_ON_START") == "true"
+
+	photoDuplicatePolicy := os.Getenv("PHOTO_DUPLICATE_POLICY")
+	if photoDuplicatePolicy == "" {
+		photoDuplicatePolicy = "flag"
+	}
+
+	photoDuplicateMaxDistance := 5 // differing bits out of 64
+	if distanceStr := os.Getenv("PHOTO_DUPLICATE_MAX_DISTANCE"); distanceStr != "" {
+		if distance, err := strconv.Atoi(distanceStr); err == nil && distance >= 0 {
+			photoDuplicateMaxDistance = distance
+		}
+	}
+
+	legacyAPISunset := time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
+	if sunsetStr := os.Getenv("LEGACY_API_SUNSET"); sunsetStr != "" {
+		if sunset, err := time.Parse(time.DateOnly, sunsetStr); err == nil {
+			legacyAPISunset = sunset
+		}
+	}
+
+	idempotencyKeyTTL := 24 * time.Hour
+	if ttlStr := os.Getenv("IDEMPOTENCY_KEY_TTL_HOURS"); ttlStr != "" {
+		if ttl, err := strconv.Atoi(ttlStr); err == nil && ttl > 0 {
+			idempotencyKeyTTL = time.Duration(ttl) * time.Hour
+		}
+	}
+
 	return &Config{
 		Port:        port,
 		DatabaseURL: databaseURL,
 		Environment: environment,
 		LogLevel:    logLevel,
+
+		MigrateOnStart: migrateOnStart,
+
+		StorageBackend:   storageBackend,
+		StorageLocalDir:  storageLocalDir,
+		StoragePublicURL: storagePublicURL,
+		UploadMaxBytes:   uploadMaxBytes,
+		UploadMaxFiles:   uploadMaxFiles,
+		PhotoWorkers:     photoWorkers,
+		PhotoQueueSize:   photoQueueSize,
+		MaxPhotosPerItem: maxPhotosPerItem,
+
+		PhotoImportTimeout:      photoImportTimeout,
+		PhotoImportMaxRedirects: photoImportMaxRedirects,
+
+		GatewaySecret: os.Getenv("GATEWAY_SECRET"),
+
+		SignedURLSecret: os.Getenv("SIGNED_URL_SECRET"),
+		SignedURLTTL:    signedURLTTL,
+
+		PhotoGCInterval:    photoGCInterval,
+		PhotoGCGracePeriod: photoGCGracePeriod,
+		PhotoGCDryRun:      photoGCDryRun,
+
+		PhotoDuplicatePolicy:      photoDuplicatePolicy,
+		PhotoDuplicateMaxDistance: photoDuplicateMaxDistance,
+
+		LegacyAPISunset: legacyAPISunset,
+
+		IdempotencyKeyTTL: idempotencyKeyTTL,
 	}
 }
 
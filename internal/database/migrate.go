@@ -1,67 +1,187 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+
+	"shary_be/migrations"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
 )
 
-// RunMigrations runs database migrations using golang-migrate
-func RunMigrations(db *sqlx.DB, migrationsPath string) error {
-	// Get the underlying sql.DB from sqlx
-	sqlDB := db.DB
+var (
+	// ErrSchemaDirty is returned when a migration failed halfway and the schema has to be
+	// repaired by hand before it can be migrated again
+	ErrSchemaDirty = errors.New("database schema is dirty")
+	// ErrSchemaBehind is returned when migrations embedded in the binary have not been applied
+	ErrSchemaBehind = errors.New("database schema is behind the migrations")
+)
+
+// MigrationStatus describes the schema version of a database
+type MigrationStatus struct {
+	// Version is the last applied migration, 0 if none was applied
+	Version uint
+	// Dirty is set when migration Version failed halfway
+	Dirty bool
+	// Latest is the newest migration embedded in the binary
+	Latest uint
+}
+
+// Behind reports whether embedded migrations remain to be applied
+func (s MigrationStatus) Behind() bool {
+	return s.Version < s.Latest
+}
+
+// Check returns ErrSchemaDirty or ErrSchemaBehind unless the schema is up to date.
+// A schema ahead of the binary, migrated by a newer release, is accepted.
+func (s MigrationStatus) Check() error {
+	if s.Dirty {
+		return fmt.Errorf("%w at version %d", ErrSchemaDirty, s.Version)
+	}
+	if s.Behind() {
+		return fmt.Errorf("%w: at version %d, latest is %d", ErrSchemaBehind, s.Version, s.Latest)
+	}
+	return nil
+}
 
-	// Create postgres driver instance
-	driver, err := postgres.WithInstance(sqlDB, &postgres.Config{})
+// Migrator applies the embedded migrations to a database. It holds a connection of its
+// own, so Close must be called when done.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+// NewMigrator creates a migrator for the embedded migrations. Changes take a PostgreSQL
+// advisory lock, so replicas starting together apply each migration only once.
+func NewMigrator(ctx context.Context, db *sqlx.DB) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	// Migrate closes the database it was given, so give it a connection instead of the pool
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create postgres driver: %w", err)
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
 	}
 
-	// Create migrate instance
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsPath),
-		"postgres", driver)
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		conn.Close()
+		return nil, fmt.Errorf("failed to create postgres driver: %w", err)
 	}
 
-	// Run migrations
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+
+	return &Migrator{m: m, source: src}, nil
+}
+
+// Close releases the migrator's database connection
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// Status returns the schema version of the database and the newest embedded migration
+func (m *Migrator) Status() (MigrationStatus, error) {
+	var status MigrationStatus
+
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, fmt.Errorf("failed to get migration version: %w", err)
+	}
+	status.Version = version
+	status.Dirty = dirty
+
+	status.Latest, err = m.latest()
+	if err != nil {
+		return status, err
+	}
+
+	return status, nil
+}
+
+// Up applies all embedded migrations that have not been applied yet
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
+	return nil
+}
 
-	log.Println("Database migrations completed successfully")
+// Down rolls back the given number of applied migrations
+func (m *Migrator) Down(steps int) error {
+	if err := m.m.Steps(-steps); err != nil {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
 	return nil
 }
 
-// GetMigrationVersion returns the current migration version
-func GetMigrationVersion(db *sqlx.DB, migrationsPath string) (uint, error) {
-	sqlDB := db.DB
+// Force records version as applied and clears the dirty flag without running any
+// migration, after a failed migration was repaired by hand. -1 records that no
+// migration was applied.
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("failed to force migration version: %w", err)
+	}
+	return nil
+}
 
-	driver, err := postgres.WithInstance(sqlDB, &postgres.Config{})
+// latest returns the version of the newest embedded migration, 0 if there is none
+func (m *Migrator) latest() (uint, error) {
+	version, err := m.source.First()
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to create postgres driver: %w", err)
+		return 0, fmt.Errorf("failed to read embedded migrations: %w", err)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsPath),
-		"postgres", driver)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create migrate instance: %w", err)
+	for {
+		next, err := m.source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read embedded migrations: %w", err)
+		}
+		version = next
 	}
+}
 
-	version, dirty, err := m.Version()
+// RunMigrations applies all embedded migrations that have not been applied yet
+func RunMigrations(ctx context.Context, db *sqlx.DB) error {
+	migrator, err := NewMigrator(ctx, db)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get migration version: %w", err)
+		return err
 	}
+	defer migrator.Close()
 
-	if dirty {
-		return 0, fmt.Errorf("database is in dirty state at version %d", version)
+	return migrator.Up()
+}
+
+// CheckSchema returns the schema version of the database, with an error wrapping
+// ErrSchemaDirty or ErrSchemaBehind unless the schema is up to date
+func CheckSchema(ctx context.Context, db *sqlx.DB) (MigrationStatus, error) {
+	migrator, err := NewMigrator(ctx, db)
+	if err != nil {
+		return MigrationStatus{}, err
 	}
+	defer migrator.Close()
 
-	return version, nil
+	status, err := migrator.Status()
+	if err != nil {
+		return status, err
+	}
+	return status, status.Check()
 }
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"errors"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"shary_be/migrations"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	names, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+
+	pattern := regexp.MustCompile(`^(\d+)_\w+\.(up|down)\.sql$`)
+	directions := map[uint64]map[string]bool{}
+	var newest uint64
+	for _, name := range names {
+		match := pattern.FindStringSubmatch(name)
+		if match == nil {
+			t.Errorf("migration %q is not named <version>_<name>.(up|down).sql", name)
+			continue
+		}
+		version, _ := strconv.ParseUint(match[1], 10, 64)
+		if directions[version] == nil {
+			directions[version] = map[string]bool{}
+		}
+		directions[version][match[2]] = true
+		if version > newest {
+			newest = version
+		}
+	}
+	for version, dirs := range directions {
+		if !dirs["up"] || !dirs["down"] {
+			t.Errorf("migration %d lacks an up or down file", version)
+		}
+	}
+
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		t.Fatalf("iofs.New: %v", err)
+	}
+	latest, err := (&Migrator{source: src}).latest()
+	if err != nil {
+		t.Fatalf("latest: %v", err)
+	}
+	if uint64(latest) != newest {
+		t.Errorf("latest = %d, want %d", latest, newest)
+	}
+}
+
+func TestMigrationStatus_Check(t *testing.T) {
+	tests := []struct {
+		name   string
+		status MigrationStatus
+		want   error
+	}{
+		{"up to date", MigrationStatus{Version: 5, Latest: 5}, nil},
+		{"ahead", MigrationStatus{Version: 6, Latest: 5}, nil},
+		{"behind", MigrationStatus{Version: 4, Latest: 5}, ErrSchemaBehind},
+		{"never migrated", MigrationStatus{Latest: 5}, ErrSchemaBehind},
+		{"dirty", MigrationStatus{Version: 5, Dirty: true, Latest: 5}, ErrSchemaDirty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.status.Check()
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Check() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Reindex rebuilds the indexes of every table in the current schema, calling done
+// after each table. Concurrently rebuilds them without blocking writes, which needs
+// PostgreSQL 12 or later and takes longer.
+func Reindex(ctx context.Context, db *sqlx.DB, concurrently bool, done func(table string)) error {
+	var tables []string
+	query := `SELECT tablename FROM pg_tables WHERE schemaname = current_schema() ORDER BY tablename`
+	if err := db.SelectContext(ctx, &tables, query); err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	reindex := "REINDEX TABLE "
+	if concurrently {
+		reindex = "REINDEX TABLE CONCURRENTLY "
+	}
+
+	// Each table is reindexed on its own: a concurrent reindex cannot run in a
+	// transaction, and a failure leaves the tables already done rebuilt
+	for _, table := range tables {
+		if _, err := db.ExecContext(ctx, reindex+pq.QuoteIdentifier(table)); err != nil {
+			return fmt.Errorf("failed to reindex %s: %w", table, err)
+		}
+		done(table)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNotEmpty is returned when sample data would be loaded into a database that
+// already has users or categories
+var ErrNotEmpty = errors.New("database already has data")
+
+//go:embed seed.sql
+var seedSQL string
+
+//go:embed unseed.sql
+var unseedSQL string
+
+// Seed loads the sample users, categories, items, photos, rents and favorites into an
+// empty database, all in one transaction. With replace it first removes the sample data
+// already there, e.g. the rows the early sample data migrations inserted, and loads into
+// a database that has other data too.
+func Seed(ctx context.Context, db *sqlx.DB, replace bool) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.ExecContext(ctx, unseedSQL); err != nil {
+			return fmt.Errorf("failed to remove sample data: %w", err)
+		}
+	} else {
+		var hasData bool
+		query := `SELECT EXISTS(SELECT 1 FROM users) OR EXISTS(SELECT 1 FROM categories)`
+		if err := tx.GetContext(ctx, &hasData, query); err != nil {
+			return fmt.Errorf("failed to check for existing data: %w", err)
+		}
+		if hasData {
+			return ErrNotEmpty
+		}
+	}
+
+	// Without arguments the statements are sent together as one simple query
+	if _, err := tx.ExecContext(ctx, seedSQL); err != nil {
+		return fmt.Errorf("failed to load sample data: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+-- Sample data for development, loaded with `shary_be seed` into an empty database.
+-- Rows refer to each other by natural keys, so the data loads whatever IDs it gets.
+
+-- Sample users with Kazakhstan IIN
+INSERT INTO users (first_name, last_name, identity, phone, avatar_url, verified) VALUES
+('Александр', 'Иванов', '123456789012', '+7 701 123 4567', 'https://example.com/avatars/alex.jpg', true),
+('Мария', 'Петрова', '234567890123', '+7 702 234 5678', 'https://example.com/avatars/maria.jpg', true),
+('Дмитрий', 'Сидоров', '345678901234', '+7 703 345 6789', 'https://example.com/avatars/dmitry.jpg', false),
+('Анна', 'Козлова', '456789012345', '+7 704 456 7890', 'https://example.com/avatars/anna.jpg', true),
+('Сергей', 'Новиков', '567890123456', '+7 705 567 8901', 'https://example.com/avatars/sergey.jpg', false);
+
+-- Sample categories with Russian default names and typed attributes
+INSERT INTO categories (name, slug, attribute_schema) VALUES
+('Электроника', 'elektronika', DEFAULT),
+('Одежда и обувь', 'odezhda-i-obuv', '[
+    {"key": "size", "label": "Размер", "type": "enum", "required": true, "options": ["XS", "S", "M", "L", "XL", "XXL"]}
+]'),
+('Спорт и отдых', 'sport-i-otdyh', DEFAULT),
+('Книги и образование', 'knigi-i-obrazovanie', DEFAULT),
+('Дом и сад', 'dom-i-sad', DEFAULT),
+('Транспорт', 'transport', '[
+    {"key": "year", "label": "Год выпуска", "type": "number", "required": true},
+    {"key": "transmission", "label": "Коробка передач", "type": "enum", "required": false, "options": ["manual", "automatic"]}
+]'),
+('Красота и здоровье', 'krasota-i-zdorove', DEFAULT),
+('Игрушки и хобби', 'igrushki-i-hobbi', DEFAULT),
+('Другое', 'drugoe', DEFAULT);
+
+-- Kazakh and English category names
+INSERT INTO category_translations (category_id, lang, name)
+SELECT c.id, t.lang, t.name
+FROM (VALUES
+    ('elektronika', 'kk', 'Электроника'),
+    ('elektronika', 'en', 'Electronics'),
+    ('odezhda-i-obuv', 'kk', 'Киім және аяқ киім'),
+    ('odezhda-i-obuv', 'en', 'Clothing and shoes'),
+    ('sport-i-otdyh', 'kk', 'Спорт және демалыс'),
+    ('sport-i-otdyh', 'en', 'Sports and leisure'),
+    ('knigi-i-obrazovanie', 'kk', 'Кітаптар және білім'),
+    ('knigi-i-obrazovanie', 'en', 'Books and education'),
+    ('dom-i-sad', 'kk', 'Үй және бақ'),
+    ('dom-i-sad', 'en', 'Home and garden'),
+    ('transport', 'kk', 'Көлік'),
+    ('transport', 'en', 'Transport'),
+    ('krasota-i-zdorove', 'kk', 'Сұлулық және денсаулық'),
+    ('krasota-i-zdorove', 'en', 'Beauty and health'),
+    ('igrushki-i-hobbi', 'kk', 'Ойыншықтар және хобби'),
+    ('igrushki-i-hobbi', 'en', 'Toys and hobbies'),
+    ('drugoe', 'kk', 'Басқа'),
+    ('drugoe', 'en', 'Other')
+) AS t(slug, lang, name)
+INNER JOIN categories c ON c.slug = t.slug;
+
+-- Sample items with Russian titles and tenge prices
+INSERT INTO items (title, description, price, author_id, location, category_id, tags, attributes, has_photos)
+SELECT t.title, t.description, t.price, u.id, t.location, c.id, t.tags, t.attributes::jsonb, t.has_photos
+FROM (VALUES
+    ('iPhone 14 Pro', 'Современный смартфон Apple в отличном состоянии. Идеально подходит для работы и развлечений.', 50000, '123456789012', 'Алматы, ул. Достык 123', 'elektronika', ARRAY['смартфон', 'apple', 'техника'], '{}', true),
+    ('Велосипед горный', 'Качественный горный велосипед для активного отдыха. Подходит для катания по городу и за городом.', 15000, '234567890123', 'Астана, пр. Республики 45', 'sport-i-otdyh', ARRAY['велосипед', 'спорт', 'отдых'], '{}', true),
+    ('Книга "Война и мир"', 'Классическое произведение Льва Толстого в твердом переплете. Отличное состояние.', 2000, '345678901234', 'Алматы, ул. Абая 67', 'knigi-i-obrazovanie', ARRAY['книга', 'классика', 'литература'], '{}', false),
+    ('Платье вечернее', 'Элегантное вечернее платье черного цвета. Размер M, идеально для торжественных мероприятий.', 8000, '456789012345', 'Астана, ул. Бейбитшилик 89', 'odezhda-i-obuv', ARRAY['платье', 'вечернее', 'элегантное'], '{"size": "M"}', true),
+    ('Ноутбук Dell', 'Мощный ноутбук для работы и учебы. Intel i7, 16GB RAM, SSD 512GB.', 120000, '123456789012', 'Алматы, ул. Толе би 234', 'elektronika', ARRAY['ноутбук', 'dell', 'компьютер'], '{}', true),
+    ('Футбольный мяч', 'Профессиональный футбольный мяч для тренировок и игр. Размер 5.', 3000, '567890123456', 'Астана, ул. Кенесары 12', 'sport-i-otdyh', ARRAY['футбол', 'мяч', 'спорт'], '{}', false),
+    ('Кофемашина', 'Автоматическая кофемашина для приготовления эспрессо и капучино. В отличном состоянии.', 25000, '234567890123', 'Алматы, ул. Фурманова 56', 'dom-i-sad', ARRAY['кофе', 'машина', 'кухня'], '{}', true),
+    ('Гитара акустическая', 'Красивая акустическая гитара для начинающих музыкантов. Включает чехол и медиатор.', 18000, '345678901234', 'Астана, ул. Сарыарка 78', 'igrushki-i-hobbi', ARRAY['гитара', 'музыка', 'инструмент'], '{}', true)
+) AS t(title, description, price, author_identity, location, category_slug, tags, attributes, has_photos)
+INNER JOIN users u ON u.identity = t.author_identity
+INNER JOIN categories c ON c.slug = t.category_slug;
+
+-- Sample item photos; the first photo of each item is its cover
+INSERT INTO item_photos (item_id, url, position, is_cover)
+SELECT i.id, t.url, t.position, t.position = 0
+FROM (VALUES
+    ('iPhone 14 Pro', 'https://example.com/photos/iphone1.jpg', 0),
+    ('iPhone 14 Pro', 'https://example.com/photos/iphone2.jpg', 1),
+    ('Велосипед горный', 'https://example.com/photos/bike1.jpg', 0),
+    ('Велосипед горный', 'https://example.com/photos/bike2.jpg', 1),
+    ('Платье вечернее', 'https://example.com/photos/dress1.jpg', 0),
+    ('Ноутбук Dell', 'https://example.com/photos/laptop1.jpg', 0),
+    ('Ноутбук Dell', 'https://example.com/photos/laptop2.jpg', 1),
+    ('Кофемашина', 'https://example.com/photos/coffee1.jpg', 0),
+    ('Гитара акустическая', 'https://example.com/photos/guitar1.jpg', 0),
+    ('Гитара акустическая', 'https://example.com/photos/guitar2.jpg', 1)
+) AS t(item_title, url, position)
+INNER JOIN items i ON i.title = t.item_title;
+
+-- Sample rents
+INSERT INTO rents (item_id, renter_id, date_start, date_end, price, status_id)
+SELECT i.id, u.id, t.date_start::date, t.date_end::date, t.price, s.id
+FROM (VALUES
+    ('iPhone 14 Pro', '234567890123', '2024-07-01', '2024-07-03', 15000, 'approved'),
+    ('Велосипед горный', '123456789012', '2024-07-05', '2024-07-07', 4500, 'requested'),
+    ('Ноутбук Dell', '456789012345', '2024-07-10', '2024-07-15', 36000, 'requested'),
+    ('Кофемашина', '345678901234', '2024-07-12', '2024-07-14', 7500, 'recieved')
+) AS t(item_title, renter_identity, date_start, date_end, price, status)
+INNER JOIN items i ON i.title = t.item_title
+INNER JOIN users u ON u.identity = t.renter_identity
+INNER JOIN statuses s ON s.name = t.status;
+
+-- Sample favorite items
+INSERT INTO favorite_items (item_id, user_id)
+SELECT i.id, u.id
+FROM (VALUES
+    ('iPhone 14 Pro', '234567890123'),
+    ('iPhone 14 Pro', '456789012345'),
+    ('Велосипед горный', '123456789012'),
+    ('Велосипед горный', '567890123456'),
+    ('Ноутбук Dell', '345678901234'),
+    ('Кофемашина', '123456789012'),
+    ('Гитара акустическая', '234567890123')
+) AS t(item_title, user_identity)
+INNER JOIN items i ON i.title = t.item_title
+INNER JOIN users u ON u.identity = t.user_identity;
@@ -0,0 +1,11 @@
+-- Removes the sample data, as loaded by seed.sql or by the early sample data migrations,
+-- so that `shary_be seed -replace` can load the current sample data in its place.
+
+-- Removing the sample users also removes their items, photos, rents and favorites
+DELETE FROM users WHERE identity IN ('123456789012', '234567890123', '345678901234', '456789012345', '567890123456');
+
+-- Sample categories still used by other items or categories are kept, and seeding then fails
+DELETE FROM categories c
+WHERE c.slug IN ('elektronika', 'odezhda-i-obuv', 'sport-i-otdyh', 'knigi-i-obrazovanie', 'dom-i-sad', 'transport', 'krasota-i-zdorove', 'igrushki-i-hobbi', 'drugoe')
+  AND NOT EXISTS (SELECT 1 FROM items i WHERE i.category_id = c.id)
+  AND NOT EXISTS (SELECT 1 FROM categories child WHERE child.parent_id = c.id);
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"shary_be/internal/apperror"
+	"shary_be/internal/i18n"
 	"shary_be/internal/models"
 	"shary_be/internal/service"
 
@@ -26,36 +28,51 @@ func NewCategoryHandler(categoryService *service.CategoryService, logger *zap.Lo
 
 func (h *CategoryHandler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	categories, err := h.categoryService.GetAllCategories()
+
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+
+	categories, err := h.categoryService.GetAllCategories(r.Context(), lang)
 	if err != nil {
 		h.logger.Error("Failed to get all categories", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(categories)
 }
 
-// CreateCategory handles POST /api/categories
+// GetCategoryTree handles GET /api/v1/categories/tree
+func (h *CategoryHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+
+	tree, err := h.categoryService.GetCategoryTree(r.Context(), lang)
+	if err != nil {
+		h.logger.Error("Failed to get category tree", zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(tree)
+}
+
+// CreateCategory handles POST /api/v1/categories
 func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	var req models.CreateCategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid request body"))
 		return
 	}
 
-	category, err := h.categoryService.CreateCategory(&req)
+	category, err := h.categoryService.CreateCategory(r.Context(), &req)
 	if err != nil {
 		h.logger.Error("Failed to create category", zap.Error(err))
-
-		if err.Error() == "category already exists" {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
@@ -63,87 +80,239 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(category)
 }
 
-// UpdateCategory handles PUT /api/categories/:id
-func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+// ReplaceCategory handles PUT /api/v1/categories/{id}, replacing the category's editable fields
+func (h *CategoryHandler) ReplaceCategory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	categoryIDStr := chi.URLParam(r, "id")
 	categoryID, err := strconv.Atoi(categoryIDStr)
 	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid category ID"))
 		return
 	}
 
-	var req models.UpdateCategoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req models.ReplaceCategoryRequest
+	if err := decodeReplacement(r, &req); err != nil {
+		apperror.Write(w, r, err)
 		return
 	}
 
-	category, err := h.categoryService.UpdateCategory(categoryID, &req)
+	category, err := h.categoryService.ReplaceCategory(r.Context(), categoryID, &req, ifMatch(r))
 	if err != nil {
-		h.logger.Error("Failed to update category", zap.Error(err))
+		h.logger.Error("Failed to replace category", zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
 
-		if err.Error() == "category not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
+	w.Header().Set("ETag", categoryETag(category, i18n.Default))
+	json.NewEncoder(w).Encode(category)
+}
+
+// PatchCategory handles PATCH /api/v1/categories/{id} with a JSON Merge Patch
+func (h *CategoryHandler) PatchCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	categoryIDStr := chi.URLParam(r, "id")
+	categoryID, err := strconv.Atoi(categoryIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid category ID"))
+		return
+	}
+
+	patch, err := readMergePatch(w, r)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
 
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	category, err := h.categoryService.PatchCategory(r.Context(), categoryID, patch, ifMatch(r))
+	if err != nil {
+		h.logger.Error("Failed to patch category", zap.Error(err))
+		apperror.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", categoryETag(category, i18n.Default))
 	json.NewEncoder(w).Encode(category)
 }
 
-// DeleteCategory handles DELETE /api/categories/{id}
+// DeleteCategory handles DELETE /api/v1/categories/{id}
 func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	categoryIDStr := chi.URLParam(r, "id")
 	categoryID, err := strconv.Atoi(categoryIDStr)
 	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid category ID"))
 		return
 	}
 
-	err = h.categoryService.DeleteCategory(categoryID)
-	if err != nil {
-		h.logger.Error("Failed to delete category", zap.Error(err))
-
-		if err.Error() == "category not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
+	var reassignTo *int
+	if reassignToStr := r.URL.Query().Get("reassign_to"); reassignToStr != "" {
+		targetID, err := strconv.Atoi(reassignToStr)
+		if err != nil || targetID <= 0 {
+			apperror.Write(w, r, apperror.InvalidRequest("Invalid reassign_to category ID"))
 			return
 		}
+		reassignTo = &targetID
+	}
 
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	err = h.categoryService.DeleteCategory(r.Context(), categoryID, reassignTo, ifMatch(r))
+	if err != nil {
+		h.logger.Error("Failed to delete category", zap.Error(err))
+		apperror.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetCategoryByID handles GET /api/categories/{id}
+// MergeCategory handles POST /api/v1/categories/{id}/merge
+func (h *CategoryHandler) MergeCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	categoryIDStr := chi.URLParam(r, "id")
+	categoryID, err := strconv.Atoi(categoryIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid category ID"))
+		return
+	}
+
+	var req models.MergeCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid request body"))
+		return
+	}
+
+	result, err := h.categoryService.MergeCategory(r.Context(), categoryID, &req)
+	if err != nil {
+		h.logger.Error("Failed to merge category", zap.Int("category_id", categoryID), zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetCategoryByID handles GET /api/v1/categories/{id}
 func (h *CategoryHandler) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	categoryIDStr := chi.URLParam(r, "id")
 	categoryID, err := strconv.Atoi(categoryIDStr)
 	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid category ID"))
 		return
 	}
 
-	category, err := h.categoryService.GetCategoryByID(categoryID)
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+
+	category, err := h.categoryService.GetCategoryByID(r.Context(), categoryID, lang)
 	if err != nil {
 		h.logger.Error("Failed to get category by ID", zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
 
-		if err.Error() == "category not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
+	if notModified(w, r, categoryETag(category, lang)) {
+		return
+	}
+
+	json.NewEncoder(w).Encode(category)
+}
+
+// GetCategoryBySlug handles GET /api/v1/categories/slug/{slug}
+func (h *CategoryHandler) GetCategoryBySlug(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	slug := chi.URLParam(r, "slug")
+	if slug == "" {
+		apperror.Write(w, r, apperror.InvalidRequest("Slug cannot be empty"))
+		return
+	}
 
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+
+	category, err := h.categoryService.GetCategoryBySlug(r.Context(), slug, lang)
+	if err != nil {
+		h.logger.Error("Failed to get category by slug", zap.String("slug", slug), zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	if notModified(w, r, categoryETag(category, lang)) {
 		return
 	}
 
 	json.NewEncoder(w).Encode(category)
 }
+
+// GetTranslations handles GET /api/v1/categories/{id}/translations
+func (h *CategoryHandler) GetTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	categoryIDStr := chi.URLParam(r, "id")
+	categoryID, err := strconv.Atoi(categoryIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid category ID"))
+		return
+	}
+
+	translations, err := h.categoryService.GetTranslations(r.Context(), categoryID)
+	if err != nil {
+		h.logger.Error("Failed to get category translations", zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"translations": translations,
+	})
+}
+
+// UpsertTranslation handles PUT /api/v1/categories/{id}/translations/{lang}
+func (h *CategoryHandler) UpsertTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	categoryIDStr := chi.URLParam(r, "id")
+	categoryID, err := strconv.Atoi(categoryIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid category ID"))
+		return
+	}
+
+	var req models.UpsertCategoryTranslationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid request body"))
+		return
+	}
+
+	lang := i18n.Normalize(chi.URLParam(r, "lang"))
+	translation, err := h.categoryService.UpsertTranslation(r.Context(), categoryID, lang, &req)
+	if err != nil {
+		h.logger.Error("Failed to save category translation", zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(translation)
+}
+
+// DeleteTranslation handles DELETE /api/v1/categories/{id}/translations/{lang}
+func (h *CategoryHandler) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	categoryIDStr := chi.URLParam(r, "id")
+	categoryID, err := strconv.Atoi(categoryIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid category ID"))
+		return
+	}
+
+	lang := i18n.Normalize(chi.URLParam(r, "lang"))
+	if err := h.categoryService.DeleteTranslation(r.Context(), categoryID, lang); err != nil {
+		h.logger.Error("Failed to delete category translation", zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
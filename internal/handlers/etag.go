@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shary_be/internal/models"
+	"shary_be/internal/service"
+)
+
+// Entity tags name the version of a resource, followed by whatever else its
+// representation depends on: the response language and, for items, the version of
+// their category, whose name is embedded. Only the leading version counts for If-Match,
+// so a tag read in any language can be used to update the resource.
+
+// itemETag returns the entity tag of an item representation in lang
+func itemETag(item *models.ItemResponse, lang string) string {
+	categoryVersion := 0
+	if item.Category.Version != nil {
+		categoryVersion = *item.Category.Version
+	}
+	return fmt.Sprintf(`"%d.%d-%s"`, item.Version, categoryVersion, lang)
+}
+
+// categoryETag returns the entity tag of a category representation in lang
+func categoryETag(category *models.Category, lang string) string {
+	return fmt.Sprintf(`"%d-%s"`, category.Version, lang)
+}
+
+// ifMatch reads the If-Match header of a request into a precondition. Without the
+// header, or with "*", any version matches. Weak and malformed tags never match, as
+// If-Match requires a strong comparison.
+func ifMatch(r *http.Request) service.Precondition {
+	header := r.Header.Get("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return nil
+	}
+
+	precondition := service.Precondition{}
+	for _, tag := range strings.Split(header, ",") {
+		if version, ok := tagVersion(strings.TrimSpace(tag)); ok {
+			precondition = append(precondition, version)
+		}
+	}
+	return precondition
+}
+
+// tagVersion returns the version an entity tag starts with
+func tagVersion(tag string) (int, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	opaque := tag[1 : len(tag)-1]
+	if i := strings.IndexAny(opaque, ".-"); i >= 0 {
+		opaque = opaque[:i]
+	}
+	version, err := strconv.Atoi(opaque)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// notModified sets the ETag of a response and reports whether the request's
+// If-None-Match header already names it, in which case 304 Not Modified is written
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
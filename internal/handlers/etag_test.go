@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"shary_be/internal/models"
+	"shary_be/internal/service"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := map[string]service.Precondition{
+		"":                     nil,
+		"*":                    nil,
+		`"3-en"`:               {3},
+		`"3.7-uk", "4.7-en"`:   {3, 4},
+		`"5"`:                  {5},
+		`W/"3-en"`:             {},
+		`"abc", 3, "0-en"`:     {},
+		`"12.0-en",W/"13-en" `: {12},
+	}
+
+	for header, want := range tests {
+		r := httptest.NewRequest("PUT", "/api/v1/items/1", nil)
+		if header != "" {
+			r.Header.Set("If-Match", header)
+		}
+		if got := ifMatch(r); !reflect.DeepEqual(got, want) {
+			t.Errorf("ifMatch(%q) = %#v, want %#v", header, got, want)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	categoryVersion := 2
+	item := &models.ItemResponse{Version: 3, Category: models.CategoryInfo{Version: &categoryVersion}}
+	etag := itemETag(item, "en")
+	if etag != `"3.2-en"` {
+		t.Fatalf("itemETag() = %s, want \"3.2-en\"", etag)
+	}
+
+	tests := map[string]bool{
+		"":                   false,
+		`"3.1-en"`:           false,
+		`"3.2-uk"`:           false,
+		`"3.2-en"`:           true,
+		`W/"3.2-en"`:         true,
+		`"1.1-en", "3.2-en"`: true,
+		"*":                  true,
+	}
+
+	for header, want := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/items/1", nil)
+		if header != "" {
+			r.Header.Set("If-None-Match", header)
+		}
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+
+		got := notModified(rec, r, etag)
+		if got != want {
+			t.Errorf("notModified(If-None-Match: %q) = %v, want %v", header, got, want)
+		}
+		if got && rec.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match: %q status = %d, want 304", header, rec.Code)
+		}
+		if rec.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match: %q ETag = %q, want %s", header, rec.Header().Get("ETag"), etag)
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 
+	"shary_be/internal/apperror"
+	"shary_be/internal/i18n"
 	"shary_be/internal/models"
 	"shary_be/internal/service"
 
@@ -29,7 +33,7 @@ func NewItemHandler(itemService *service.ItemService, logger *zap.Logger) *ItemH
 	}
 }
 
-// GetAllItems handles GET /api/items with optional query parameters
+// GetAllItems handles GET /api/v1/items with optional query parameters
 func (h *ItemHandler) GetAllItems(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -62,6 +66,16 @@ func (h *ItemHandler) GetAllItems(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	attributes, err := parseAttributeFilters(r.URL.Query())
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest(err.Error()))
+		return
+	}
+	filter.Attributes = attributes
+
+	filter.Lang = i18n.FromRequest(r)
+	w.Header().Set("Content-Language", filter.Lang)
+
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
 			filter.Limit = limit
@@ -74,10 +88,10 @@ func (h *ItemHandler) GetAllItems(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	items, err := h.itemService.GetAllItems(filter)
+	items, err := h.itemService.GetAllItems(r.Context(), filter)
 	if err != nil {
 		h.logger.Error("Failed to get all items", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
@@ -88,26 +102,20 @@ func (h *ItemHandler) GetAllItems(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// CreateItem handles POST /api/items
+// CreateItem handles POST /api/v1/items
 func (h *ItemHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	var req models.CreateItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid request body"))
 		return
 	}
 
-	item, err := h.itemService.CreateItem(&req)
+	item, err := h.itemService.CreateItem(r.Context(), &req)
 	if err != nil {
 		h.logger.Error("Failed to create item", zap.Error(err))
-
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Item not found", http.StatusNotFound)
-			return
-		}
-
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
@@ -115,7 +123,7 @@ func (h *ItemHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(item)
 }
 
-// GetItemByID handles GET /api/items/{id}
+// GetItemByID handles GET /api/v1/items/{id}
 func (h *ItemHandler) GetItemByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -123,107 +131,129 @@ func (h *ItemHandler) GetItemByID(w http.ResponseWriter, r *http.Request) {
 	itemIDStr := chi.URLParam(r, "id")
 	itemID, err := strconv.Atoi(itemIDStr)
 	if err != nil {
-		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
 		return
 	}
 
-	item, err := h.itemService.GetItemByID(itemID)
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+
+	item, err := h.itemService.GetItemByID(r.Context(), itemID, lang)
 	if err != nil {
 		h.logger.Error("Failed to get item by ID", zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
 
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Item not found", http.StatusNotFound)
-			return
-		}
-
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	if notModified(w, r, itemETag(item, lang)) {
 		return
 	}
 
 	json.NewEncoder(w).Encode(item)
 }
 
-// UpdateItem handles PUT /api/items/{id}
-func (h *ItemHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+// ReplaceItem handles PUT /api/v1/items/{id}, replacing the item's editable fields
+func (h *ItemHandler) ReplaceItem(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Extract item ID from Chi URL parameters
 	itemIDStr := chi.URLParam(r, "id")
 	itemID, err := strconv.Atoi(itemIDStr)
 	if err != nil {
-		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
 		return
 	}
 
-	var req models.UpdateItemRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req models.ReplaceItemRequest
+	if err := decodeReplacement(r, &req); err != nil {
+		apperror.Write(w, r, err)
 		return
 	}
 
-	item, err := h.itemService.UpdateItem(itemID, &req)
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+
+	item, err := h.itemService.ReplaceItem(r.Context(), itemID, &req, ifMatch(r), lang)
 	if err != nil {
-		h.logger.Error("Failed to update item", zap.Error(err))
+		h.logger.Error("Failed to replace item", zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
 
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Item not found", http.StatusNotFound)
-			return
-		}
+	w.Header().Set("ETag", itemETag(item, lang))
+	json.NewEncoder(w).Encode(item)
+}
+
+// PatchItem handles PATCH /api/v1/items/{id} with a JSON Merge Patch
+func (h *ItemHandler) PatchItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract item ID from Chi URL parameters
+	itemIDStr := chi.URLParam(r, "id")
+	itemID, err := strconv.Atoi(itemIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
+		return
+	}
+
+	patch, err := readMergePatch(w, r)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
 
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+
+	item, err := h.itemService.PatchItem(r.Context(), itemID, patch, ifMatch(r), lang)
+	if err != nil {
+		h.logger.Error("Failed to patch item", zap.Error(err))
+		apperror.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", itemETag(item, lang))
 	json.NewEncoder(w).Encode(item)
 }
 
-// DeleteItem handles DELETE /api/items/{id}
+// DeleteItem handles DELETE /api/v1/items/{id}
 func (h *ItemHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
 	// Extract item ID from Chi URL parameters
 	itemIDStr := chi.URLParam(r, "id")
 	itemID, err := strconv.Atoi(itemIDStr)
 	if err != nil {
-		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
 		return
 	}
 
-	err = h.itemService.DeleteItem(itemID)
+	err = h.itemService.DeleteItem(r.Context(), itemID, ifMatch(r))
 	if err != nil {
 		h.logger.Error("Failed to delete item", zap.Error(err))
-
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Item not found", http.StatusNotFound)
-			return
-		}
-
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetItemsByLocation handles GET /api/items/location/{location}
+// GetItemsByLocation handles GET /api/v1/items/location/{location}
 func (h *ItemHandler) GetItemsByLocation(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Extract location from Chi URL parameters
 	location := chi.URLParam(r, "location")
 	if location == "" {
-		http.Error(w, "Location cannot be empty", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Location cannot be empty"))
 		return
 	}
 
-	items, err := h.itemService.GetItemsByLocation(location)
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+
+	items, err := h.itemService.GetItemsByLocation(r.Context(), location, lang)
 	if err != nil {
 		h.logger.Error("Failed to get items by location", zap.Error(err))
-
-		if err.Error() == "location cannot be empty" {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
@@ -234,27 +264,24 @@ func (h *ItemHandler) GetItemsByLocation(w http.ResponseWriter, r *http.Request)
 	})
 }
 
-// GetItemsByCategory handles GET /api/items/category/{category_id}
+// GetItemsByCategory handles GET /api/v1/items/category/{category_id}
 func (h *ItemHandler) GetItemsByCategory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	categoryIDStr := chi.URLParam(r, "category_id")
 	categoryID, err := strconv.Atoi(categoryIDStr)
 	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid category ID"))
 		return
 	}
 
-	items, err := h.itemService.GetItemsByCategory(categoryID)
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+
+	items, err := h.itemService.GetItemsByCategory(r.Context(), categoryID, lang)
 	if err != nil {
 		h.logger.Error("Failed to get items by category", zap.Error(err))
-
-		if err.Error() == "category_id cannot be empty" {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
@@ -264,3 +291,46 @@ func (h *ItemHandler) GetItemsByCategory(w http.ResponseWriter, r *http.Request)
 		"category": categoryID,
 	})
 }
+
+// parseAttributeFilters reads attribute filters from query parameters of the form
+// attr.<key>=<value>, attr.<key>.min=<number> and attr.<key>.max=<number>
+func parseAttributeFilters(query url.Values) ([]models.AttributeFilter, error) {
+	var filters []models.AttributeFilter
+
+	for param, values := range query {
+		if !strings.HasPrefix(param, "attr.") || len(values) == 0 {
+			continue
+		}
+
+		key := strings.TrimPrefix(param, "attr.")
+		op := models.AttributeFilterEq
+		if strings.HasSuffix(key, ".min") {
+			key, op = strings.TrimSuffix(key, ".min"), models.AttributeFilterMin
+		} else if strings.HasSuffix(key, ".max") {
+			key, op = strings.TrimSuffix(key, ".max"), models.AttributeFilterMax
+		}
+
+		if !models.IsValidAttributeKey(key) {
+			return nil, fmt.Errorf("invalid attribute filter %q", param)
+		}
+
+		value := values[0]
+		if op != models.AttributeFilterEq {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("attribute filter %q must be a number", param)
+			}
+		}
+
+		filters = append(filters, models.AttributeFilter{Key: key, Op: op, Value: value})
+	}
+
+	// Keep the generated query stable regardless of map iteration order
+	sort.Slice(filters, func(i, j int) bool {
+		if filters[i].Key != filters[j].Key {
+			return filters[i].Key < filters[j].Key
+		}
+		return filters[i].Op < filters[j].Op
+	})
+
+	return filters, nil
+}
@@ -1,12 +1,12 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
 
+	"shary_be/internal/apperror"
 	"shary_be/internal/models"
 	"shary_be/internal/service"
 
@@ -15,6 +15,13 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// multipartMemory is how much of a multipart upload is kept in memory before spilling to disk
+	multipartMemory = 32 << 20
+	// multipartOverhead allows for form boundaries and headers on top of the file contents
+	multipartOverhead = 1 << 20
+)
+
 type ItemPhotoHandler struct {
 	itemPhotoService *service.ItemPhotoService
 	logger           *zap.Logger
@@ -27,7 +34,7 @@ func NewItemPhotoHandler(itemPhotoService *service.ItemPhotoService, logger *zap
 	}
 }
 
-// GetPhotosByItemID handles GET /api/item_photos/{item_id}
+// GetPhotosByItemID handles GET /api/v1/item_photos/{item_id}
 func (h *ItemPhotoHandler) GetPhotosByItemID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -35,20 +42,14 @@ func (h *ItemPhotoHandler) GetPhotosByItemID(w http.ResponseWriter, r *http.Requ
 	itemIDStr := chi.URLParam(r, "item_id")
 	itemID, err := strconv.Atoi(itemIDStr)
 	if err != nil {
-		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
 		return
 	}
 
-	photos, err := h.itemPhotoService.GetPhotosByItemID(itemID)
+	photos, err := h.itemPhotoService.GetPhotosByItemID(r.Context(), itemID)
 	if err != nil {
 		h.logger.Error("Failed to get photos by item ID", zap.Int("item_id", itemID), zap.Error(err))
-
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Item not found", http.StatusNotFound)
-			return
-		}
-
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
@@ -64,31 +65,94 @@ func (h *ItemPhotoHandler) AddPhotos(w http.ResponseWriter, r *http.Request) {
 	itemIDStr := chi.URLParam(r, "item_id")
 	itemID, err := strconv.Atoi(itemIDStr)
 	if err != nil {
-		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
 		return
 	}
 
 	var req models.CreateItemPhotoRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid request body"))
 		return
 	}
 
-	if err := h.itemPhotoService.AddPhotos(itemID, req.Photos); err != nil {
+	// The item comes from the URL; the body field is not required here
+	req.ItemID = itemID
+	if err := req.Validate(); err != nil {
+		apperror.Write(w, r, apperror.Validation(err))
+		return
+	}
+
+	photos := req.Photos
+	if req.Import {
+		photos, err = h.itemPhotoService.ImportPhotos(r.Context(), itemID, req.Photos)
+	} else {
+		err = h.itemPhotoService.AddPhotos(r.Context(), itemID, req.Photos)
+	}
+	if err != nil {
 		h.logger.Error("Failed to add photos", zap.Int("item_id", itemID), zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
 
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Item not found", http.StatusNotFound)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"item_id": itemID,
+		"photos":  photos,
+	})
+}
+
+// UploadPhotos handles POST /api/v1/item_photos/{item_id}/photos/upload with multipart/form-data.
+// Files are read from the "photos" form field.
+func (h *ItemPhotoHandler) UploadPhotos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract item ID from Chi URL parameters
+	itemIDStr := chi.URLParam(r, "item_id")
+	itemID, err := strconv.Atoi(itemIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
+		return
+	}
+
+	opts := h.itemPhotoService.UploadOptions()
+	r.Body = http.MaxBytesReader(w, r.Body, int64(opts.MaxFiles)*opts.MaxBytes+multipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apperror.Write(w, r, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodePayloadTooLarge, "Upload too large"))
+			return
+		}
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid multipart form"))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var uploads []service.PhotoUpload
+	for _, header := range r.MultipartForm.File["photos"] {
+		file, err := header.Open()
+		if err != nil {
+			apperror.Write(w, r, apperror.InvalidRequest("Invalid multipart form"))
 			return
 		}
+		defer file.Close()
+
+		uploads = append(uploads, service.PhotoUpload{
+			Filename: header.Filename,
+			Body:     file,
+		})
+	}
 
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	urls, err := h.itemPhotoService.UploadPhotos(r.Context(), itemID, uploads)
+	if err != nil {
+		h.logger.Error("Failed to upload photos", zap.Int("item_id", itemID), zap.Error(err))
+		apperror.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"item_id": itemID,
+		"photos":  urls,
 	})
 }
 
@@ -99,29 +163,111 @@ func (h *ItemPhotoHandler) DeletePhotos(w http.ResponseWriter, r *http.Request)
 	itemIDStr := chi.URLParam(r, "item_id")
 	itemID, err := strconv.Atoi(itemIDStr)
 	if err != nil {
-		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
 		return
 	}
 
 	var req models.DeleteItemPhotosRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid request body"))
 		return
 	}
 
-	if err := h.itemPhotoService.DeletePhotos(itemID, req.PhotoIDs); err != nil {
+	if err := h.itemPhotoService.DeletePhotos(r.Context(), itemID, req.PhotoIDs); err != nil {
 		h.logger.Error("Failed to delete photos", zap.Int("item_id", itemID), zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
 
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Item not found", http.StatusNotFound)
-			return
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderPhotos handles PUT /api/v1/item_photos/{item_id}/photos/order
+func (h *ItemPhotoHandler) ReorderPhotos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract item ID from Chi URL parameters
+	itemIDStr := chi.URLParam(r, "item_id")
+	itemID, err := strconv.Atoi(itemIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
+		return
+	}
+
+	var req models.ReorderItemPhotosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid request body"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		apperror.Write(w, r, apperror.Validation(err))
+		return
+	}
+
+	photos, err := h.itemPhotoService.ReorderPhotos(r.Context(), itemID, req.PhotoIDs)
+	if err != nil {
+		h.logger.Error("Failed to reorder photos", zap.Int("item_id", itemID), zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"item_id": itemID,
+		"photos":  photos,
+	})
+}
+
+// SetCoverPhoto handles PUT /api/v1/item_photos/{item_id}/photos/{photo_id}/cover
+func (h *ItemPhotoHandler) SetCoverPhoto(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract item and photo IDs from Chi URL parameters
+	itemIDStr := chi.URLParam(r, "item_id")
+	itemID, err := strconv.Atoi(itemIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
+		return
+	}
+
+	photoIDStr := chi.URLParam(r, "photo_id")
+	photoID, err := strconv.Atoi(photoIDStr)
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid photo ID"))
+		return
+	}
+
+	photos, err := h.itemPhotoService.SetCoverPhoto(r.Context(), itemID, photoID)
+	if err != nil {
+		if !errors.Is(err, service.ErrPhotoNotFound) {
+			h.logger.Error("Failed to set cover photo", zap.Int("item_id", itemID), zap.Int("photo_id", photoID), zap.Error(err))
 		}
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"item_id": itemID,
+		"photos":  photos,
+	})
+}
+
+// GetFlaggedPhotos handles GET /api/v1/item_photos/flagged, listing photos flagged for
+// moderators as near-duplicates of photos on other authors' items
+func (h *ItemPhotoHandler) GetFlaggedPhotos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	photos, err := h.itemPhotoService.GetFlaggedPhotos(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to get flagged photos", zap.Error(err))
+		apperror.Write(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"photos": photos,
+		"count":  len(photos),
+	})
 }
 
 func (h *ItemPhotoHandler) CountPhotosByItemID(w http.ResponseWriter, r *http.Request) {
@@ -131,20 +277,14 @@ func (h *ItemPhotoHandler) CountPhotosByItemID(w http.ResponseWriter, r *http.Re
 	itemIDStr := chi.URLParam(r, "item_id")
 	itemID, err := strconv.Atoi(itemIDStr)
 	if err != nil {
-		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid item ID"))
 		return
 	}
 
-	count, err := h.itemPhotoService.CountPhotosByItemID(itemID)
+	count, err := h.itemPhotoService.CountPhotosByItemID(r.Context(), itemID)
 	if err != nil {
 		h.logger.Error("Failed to count photos", zap.Int("item_id", itemID), zap.Error(err))
-
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Item not found", http.StatusNotFound)
-			return
-		}
-
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
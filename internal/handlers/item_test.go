@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"shary_be/internal/models"
+	"shary_be/internal/repository/memory"
+	"shary_be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// newItemRouter serves the item handler over in-memory repositories holding one item
+func newItemRouter(t *testing.T) (http.Handler, int) {
+	t.Helper()
+
+	db := memory.NewDB()
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	author := &models.User{FirstName: "Aigerim", LastName: "Sadykova", Identity: "900101300123"}
+	if err := memory.NewUserRepository(db).Create(ctx, author); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	itemService := service.NewItemService(memory.NewItemRepository(db), memory.NewCategoryRepository(db), nil, 10, logger, memory.NewTxManager(db))
+	item, err := itemService.CreateItem(ctx, &models.CreateItemRequest{
+		Title:       "City bike",
+		Description: "A city bike in good condition",
+		Price:       1000,
+		Location:    "Almaty",
+		AuthorID:    author.ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	h := NewItemHandler(itemService, logger)
+	r := chi.NewRouter()
+	r.Get("/items/{id}", h.GetItemByID)
+	r.Put("/items/{id}", h.ReplaceItem)
+	r.Patch("/items/{id}", h.PatchItem)
+	r.Delete("/items/{id}", h.DeleteItem)
+
+	return r, item.ID
+}
+
+// serve sends a request with the given headers to the router
+func serve(router http.Handler, method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+	var r *http.Request
+	if body != "" {
+		r = httptest.NewRequest(method, target, strings.NewReader(body))
+	} else {
+		r = httptest.NewRequest(method, target, nil)
+	}
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestItemHandler_ConditionalRequests(t *testing.T) {
+	router, itemID := newItemRouter(t)
+	target := "/items/" + strconv.Itoa(itemID)
+
+	w := serve(router, http.MethodGet, target, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d", w.Code, http.StatusOK)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET sent no ETag")
+	}
+
+	w = serve(router, http.MethodGet, target, "", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("GET with current If-None-Match status = %d, want %d", w.Code, http.StatusNotModified)
+	}
+
+	patch := map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": etag}
+	w = serve(router, http.MethodPatch, target, `{"price": 1500}`, patch)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH with current If-Match status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	newETag := w.Header().Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Fatalf("PATCH ETag = %q, want a tag other than %q", newETag, etag)
+	}
+
+	w = serve(router, http.MethodPatch, target, `{"price": 2000}`, patch)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PATCH with stale If-Match status = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+
+	w = serve(router, http.MethodDelete, target, "", map[string]string{"If-Match": etag})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with stale If-Match status = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+
+	w = serve(router, http.MethodDelete, target, "", map[string]string{"If-Match": newETag})
+	if w.Code != http.StatusNoContent {
+		t.Errorf("DELETE with current If-Match status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	w = serve(router, http.MethodGet, target, "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET after delete status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestItemHandler_ReplaceItem_UnknownFields(t *testing.T) {
+	router, itemID := newItemRouter(t)
+	target := "/items/" + strconv.Itoa(itemID)
+	item := `"title": "City bike", "description": "A city bike in good condition", "price": 1200, "location": "Almaty"`
+
+	w := serve(router, http.MethodPut, target, `{`+item+`, "photos_to_add": ["https://example.com/1.jpg"]}`, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("PUT with photos_to_add status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(w.Body.String(), "photos_to_add") {
+		t.Errorf("PUT with photos_to_add error = %s, want it to name the field", w.Body)
+	}
+
+	w = serve(router, http.MethodPut, target, `{`+item+`}`, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("PUT status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+}
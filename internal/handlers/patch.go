@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"shary_be/internal/apperror"
+)
+
+// MergePatchContentType is the media type of JSON Merge Patch documents (RFC 7396)
+const MergePatchContentType = "application/merge-patch+json"
+
+// maxPatchBytes bounds the size of merge patch documents
+const maxPatchBytes = 1 << 20
+
+var errUnsupportedPatchType = apperror.New(http.StatusUnsupportedMediaType, "unsupported_media_type",
+	"PATCH requests must be sent as "+MergePatchContentType)
+
+// readMergePatch reads the JSON Merge Patch sent with a PATCH request. Plain JSON is
+// accepted as well; other types, notably JSON Patch (RFC 6902), are rejected rather
+// than misread as a merge patch.
+func readMergePatch(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+		return nil, errUnsupportedPatchType
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		return nil, apperror.InvalidRequest("Invalid request body")
+	}
+	return patch, nil
+}
+
+// decodeReplacement decodes the representation sent with a PUT request. Like merge
+// patches, it may only hold the fields of the representation: a misspelt or retired
+// field, such as the photos_to_add items once took, is rejected rather than ignored.
+func decodeReplacement(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return apperror.InvalidRequest("Unknown field " + field)
+		}
+		return apperror.InvalidRequest("Invalid request body")
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/caller"
+	"shary_be/internal/service"
+	"shary_be/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+
+	"go.uber.org/zap"
+)
+
+type PrivatePhotoHandler struct {
+	privatePhotoService *service.PrivatePhotoService
+	maxBytes            int64
+	logger              *zap.Logger
+}
+
+func NewPrivatePhotoHandler(privatePhotoService *service.PrivatePhotoService, maxBytes int64, logger *zap.Logger) *PrivatePhotoHandler {
+	return &PrivatePhotoHandler{
+		privatePhotoService: privatePhotoService,
+		maxBytes:            maxBytes,
+		logger:              logger,
+	}
+}
+
+// UploadPhoto handles POST /api/v1/private_photos with multipart/form-data.
+// The file is read from the "photo" form field; the response holds a signed URL.
+// The photo belongs to the user named in the X-User-ID header.
+func (h *PrivatePhotoHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := caller.UserID(r.Context())
+	if !ok {
+		apperror.Write(w, r, caller.ErrUserRequired)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBytes+multipartOverhead)
+	file, header, err := r.FormFile("photo")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apperror.Write(w, r, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodePayloadTooLarge, "Upload too large"))
+			return
+		}
+		apperror.Write(w, r, apperror.InvalidRequest("Missing photo file"))
+		return
+	}
+	defer file.Close()
+	defer r.MultipartForm.RemoveAll()
+
+	photo, err := h.privatePhotoService.Store(r.Context(), userID, service.PhotoUpload{
+		Filename: header.Filename,
+		Body:     file,
+	})
+	if err != nil {
+		h.logger.Error("Failed to store private photo", zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(photo)
+}
+
+// RenewURL handles GET /api/v1/private_photos/url?key=...
+// It issues a new signed URL for a photo the user named in X-User-ID stored.
+func (h *PrivatePhotoHandler) RenewURL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := caller.UserID(r.Context())
+	if !ok {
+		apperror.Write(w, r, caller.ErrUserRequired)
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		apperror.Write(w, r, apperror.InvalidRequest("Missing photo key"))
+		return
+	}
+
+	photo, err := h.privatePhotoService.RenewURL(r.Context(), userID, key)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(photo)
+}
+
+// DownloadPhoto handles GET /api/v1/private_photos/*?expires=...&signature=...
+func (h *PrivatePhotoHandler) DownloadPhoto(w http.ResponseWriter, r *http.Request) {
+	key := storage.PrivatePrefix + chi.URLParam(r, "*")
+	query := r.URL.Query()
+
+	rc, err := h.privatePhotoService.Open(r.Context(), key, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+	defer rc.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Neither shared caches nor the browser should keep sensitive documents around
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	if _, err := io.Copy(w, rc); err != nil {
+		h.logger.Warn("Failed to send private photo", zap.String("key", key), zap.Error(err))
+	}
+}
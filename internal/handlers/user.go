@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/models"
+	"shary_be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// UserHandler handles HTTP requests for users
+type UserHandler struct {
+	userService *service.UserService
+	logger      *zap.Logger
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(userService *service.UserService, logger *zap.Logger) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+		logger:      logger,
+	}
+}
+
+// GetUser handles GET /api/v1/users/{id}
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid user ID"))
+		return
+	}
+
+	user, err := h.userService.GetUser(r.Context(), userID)
+	if err != nil {
+		if !errors.Is(err, service.ErrUserNotFound) {
+			h.logger.Error("Failed to get user", zap.Int("user_id", userID), zap.Error(err))
+		}
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(user)
+}
+
+// ReplaceUser handles PUT /api/v1/users/{id}, replacing the user's profile
+func (h *UserHandler) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid user ID"))
+		return
+	}
+
+	var req models.ReplaceUserRequest
+	if err := decodeReplacement(r, &req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	user, err := h.userService.ReplaceUser(r.Context(), userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to replace user", zap.Int("user_id", userID), zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(user)
+}
+
+// PatchUser handles PATCH /api/v1/users/{id} with a JSON Merge Patch
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, apperror.InvalidRequest("Invalid user ID"))
+		return
+	}
+
+	patch, err := readMergePatch(w, r)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	user, err := h.userService.PatchUser(r.Context(), userID, patch)
+	if err != nil {
+		h.logger.Error("Failed to patch user", zap.Int("user_id", userID), zap.Error(err))
+		apperror.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(user)
+}
@@ -0,0 +1,97 @@
+package i18n
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported languages
+const (
+	Russian = "ru"
+	Kazakh  = "kk"
+	English = "en"
+
+	// Default is the language stored in the base tables and used as a fallback
+	Default = Russian
+)
+
+// Supported lists all languages the API can respond in
+var Supported = []string{Russian, Kazakh, English}
+
+// IsSupported reports whether the language is one of the supported languages
+func IsSupported(lang string) bool {
+	for _, l := range Supported {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize reduces a language tag like "kk-KZ" to its primary subtag in lower case
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
+
+// FromRequest picks the response language for a request.
+// The "lang" query parameter takes precedence over the Accept-Language header.
+func FromRequest(r *http.Request) string {
+	if lang := Normalize(r.URL.Query().Get("lang")); IsSupported(lang) {
+		return lang
+	}
+	return Match(r.Header.Get("Accept-Language"))
+}
+
+// Match returns the best supported language for an Accept-Language header value,
+// falling back to Default when nothing matches
+func Match(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		lang := Normalize(fields[0])
+		if lang == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		candidates = append(candidates, candidate{lang: lang, q: q})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	for _, c := range candidates {
+		if c.lang == "*" {
+			return Default
+		}
+		if IsSupported(c.lang) {
+			return c.lang
+		}
+	}
+
+	return Default
+}
@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "empty header", acceptLanguage: "", want: Default},
+		{name: "exact match", acceptLanguage: "en", want: English},
+		{name: "region subtag", acceptLanguage: "kk-KZ", want: Kazakh},
+		{name: "quality ordering", acceptLanguage: "en;q=0.5, kk;q=0.9", want: Kazakh},
+		{name: "unsupported skipped", acceptLanguage: "de-DE, en;q=0.8", want: English},
+		{name: "zero quality excluded", acceptLanguage: "en;q=0, fr", want: Default},
+		{name: "wildcard", acceptLanguage: "fr, *;q=0.1", want: Default},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.acceptLanguage); got != tt.want {
+				t.Errorf("Match(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "query parameter wins", url: "/api/categories?lang=en", acceptLanguage: "kk", want: English},
+		{name: "unsupported query falls back to header", url: "/api/categories?lang=de", acceptLanguage: "kk", want: Kazakh},
+		{name: "no preference", url: "/api/categories", want: Default},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if got := FromRequest(r); got != tt.want {
+				t.Errorf("FromRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
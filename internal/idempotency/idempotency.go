@@ -0,0 +1,215 @@
+// Package idempotency makes POST requests safe to retry. A client sends a unique
+// Idempotency-Key header with a request; the first response to it is stored, and a
+// retry with the same key gets that response replayed instead of being processed again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/caller"
+	"shary_be/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// Header is the request header carrying the key
+const Header = "Idempotency-Key"
+
+// ReplayedHeader is set on replayed responses
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxKeyLength bounds the length of keys, which are meant to be UUIDs
+const maxKeyLength = 255
+
+// maxBodyBytes bounds the size of request bodies, which are kept in memory to be hashed
+const maxBodyBytes = 1 << 20
+
+var (
+	// ErrInvalidKey is returned when an Idempotency-Key header is too long
+	ErrInvalidKey = apperror.New(http.StatusBadRequest, "invalid_idempotency_key", "Idempotency-Key must be at most 255 characters")
+	// ErrKeyReused is returned when a key is sent again with a different request body
+	ErrKeyReused = apperror.New(http.StatusConflict, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+	// ErrRequestInProgress is returned when a key is sent again before the first request finished
+	ErrRequestInProgress = apperror.New(http.StatusConflict, "request_in_progress", "a request with this Idempotency-Key is still being processed")
+	// errBodyTooLarge is returned when a request body cannot be kept in memory
+	errBodyTooLarge = apperror.New(http.StatusRequestEntityTooLarge, apperror.CodePayloadTooLarge, "request body too large")
+)
+
+// Store keeps requests and their responses by key. Reserve returns nil when the key
+// is now held by the caller, or the record of the request holding it.
+type Store interface {
+	Reserve(ctx context.Context, scope, key, requestHash string, expiredBefore time.Time) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope, key string, statusCode int, headers models.ResponseHeader, body []byte) error
+	Release(ctx context.Context, scope, key string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// scopePathKey is the context key of the path keys are scoped by
+type scopePathKey struct{}
+
+// WithScopePath returns a context under which keys are scoped by path rather than by
+// the request path, e.g. by the canonical path of a request made to an alias of it
+func WithScopePath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, scopePathKey{}, path)
+}
+
+// scopeOf returns the scope of a key sent by userID with the request: a key belongs to
+// the user who sent it and to the method and path it was sent to, so users cannot see
+// each other's responses and the same key may be used for different endpoints
+func scopeOf(r *http.Request, userID int) string {
+	path, ok := r.Context().Value(scopePathKey{}).(string)
+	if !ok {
+		path = r.URL.Path
+	}
+	return "user " + strconv.Itoa(userID) + " " + r.Method + " " + path
+}
+
+// Middleware replays the stored response to requests that repeat an Idempotency-Key
+// used within ttl. Keys are scoped by user, method and path; requests with a key must
+// name their user in the X-User-ID header. Requests without a key are passed through.
+//
+// Server errors (5xx) are not stored: the key is released so a retry is processed again.
+func Middleware(store Store, ttl time.Duration, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				apperror.Write(w, r, ErrInvalidKey)
+				return
+			}
+			userID, ok := caller.UserID(r.Context())
+			if !ok {
+				apperror.Write(w, r, caller.ErrUserRequired)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					apperror.Write(w, r, errBodyTooLarge)
+					return
+				}
+				apperror.Write(w, r, apperror.InvalidRequest("Invalid request body"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := scopeOf(r, userID)
+			sum := sha256.Sum256(body)
+			requestHash := hex.EncodeToString(sum[:])
+
+			record, err := store.Reserve(r.Context(), scope, key, requestHash, time.Now().Add(-ttl))
+			if err != nil {
+				logger.Error("Failed to reserve idempotency key", zap.String("scope", scope), zap.Error(err))
+				apperror.Write(w, r, err)
+				return
+			}
+			if record != nil {
+				replay(w, r, record, requestHash)
+				return
+			}
+
+			// The outcome is recorded even if the client went away: it is the one to retry
+			ctx := context.WithoutCancel(r.Context())
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				// The handler panicked or failed; let the client retry
+				if err := store.Release(ctx, scope, key); err != nil {
+					logger.Error("Failed to release idempotency key", zap.String("scope", scope), zap.Error(err))
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+			headers := models.ResponseHeader(w.Header().Clone())
+			if err := store.Complete(ctx, scope, key, recorder.status, headers, recorder.body.Bytes()); err != nil {
+				logger.Error("Failed to store idempotent response", zap.String("scope", scope), zap.Error(err))
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// replay answers a repeated request with the response stored for its key
+func replay(w http.ResponseWriter, r *http.Request, record *models.IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		apperror.Write(w, r, ErrKeyReused)
+		return
+	}
+	if record.StatusCode == nil {
+		w.Header().Set("Retry-After", "1")
+		apperror.Write(w, r, ErrRequestInProgress)
+		return
+	}
+
+	for name, values := range record.Headers {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(*record.StatusCode)
+	w.Write(record.Body)
+}
+
+// Purge removes expired keys every interval until the context is cancelled
+func Purge(ctx context.Context, store Store, ttl time.Duration, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := store.DeleteExpired(ctx, time.Now().Add(-ttl))
+			if err != nil {
+				logger.Error("Failed to purge expired idempotency keys", zap.Error(err))
+				continue
+			}
+			logger.Info("Purged expired idempotency keys", zap.Int64("removed", removed))
+		}
+	}
+}
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,215 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"shary_be/internal/caller"
+	"shary_be/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// memoryStore keeps records in memory, ignoring expiry
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]*models.IdempotencyRecord)}
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, scope, key, requestHash string, expiredBefore time.Time) (*models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[scope+"|"+key]; ok {
+		copied := *record
+		return &copied, nil
+	}
+	s.records[scope+"|"+key] = &models.IdempotencyRecord{Scope: scope, Key: key, RequestHash: requestHash}
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, scope, key string, statusCode int, headers models.ResponseHeader, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := s.records[scope+"|"+key]
+	record.StatusCode = &statusCode
+	record.Headers = headers
+	record.Body = body
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, scope+"|"+key)
+	return nil
+}
+
+func (s *memoryStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// countingHandler creates a resource on every call and reports how many it created
+func countingHandler(status int) (http.Handler, *int) {
+	calls := 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":` + strconv.Itoa(calls) + `}`))
+	}), &calls
+}
+
+// testUserID is the user the requests sent by post are made by
+const testUserID = 1
+
+func post(handler http.Handler, path, key, body string) *httptest.ResponseRecorder {
+	return postAs(handler, testUserID, path, key, body)
+}
+
+// postAs sends a request made by userID, or by no user when it is 0
+func postAs(handler http.Handler, userID int, path, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(Header, key)
+	}
+	if userID != 0 {
+		r = r.WithContext(caller.WithUserID(r.Context(), userID))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestMiddleware_ReplaysFirstResponse(t *testing.T) {
+	next, calls := countingHandler(http.StatusCreated)
+	handler := Middleware(newMemoryStore(), 24*time.Hour, zap.NewNop())(next)
+
+	first := post(handler, "/api/v1/items", "key-1", `{"title":"Bike"}`)
+	retry := post(handler, "/api/v1/items", "key-1", `{"title":"Bike"}`)
+
+	if *calls != 1 {
+		t.Fatalf("handler called %d times, want 1", *calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("retry Content-Type = %q, want application/json", retry.Header().Get("Content-Type"))
+	}
+	if retry.Header().Get(ReplayedHeader) != "true" || first.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("%s header: first %q, retry %q", ReplayedHeader, first.Header().Get(ReplayedHeader), retry.Header().Get(ReplayedHeader))
+	}
+}
+
+func TestMiddleware_KeysAreScopedByRoute(t *testing.T) {
+	next, calls := countingHandler(http.StatusCreated)
+	handler := Middleware(newMemoryStore(), 24*time.Hour, zap.NewNop())(next)
+
+	post(handler, "/api/v1/items", "key-1", `{}`)
+	post(handler, "/api/v1/categories", "key-1", `{}`)
+	post(handler, "/api/v1/items", "", `{}`)
+	post(handler, "/api/v1/items", "", `{}`)
+
+	if *calls != 4 {
+		t.Errorf("handler called %d times, want 4", *calls)
+	}
+}
+
+func TestMiddleware_KeysAreScopedByUser(t *testing.T) {
+	next, calls := countingHandler(http.StatusCreated)
+	handler := Middleware(newMemoryStore(), 24*time.Hour, zap.NewNop())(next)
+
+	first := postAs(handler, 1, "/api/v1/items", "key-1", `{}`)
+	other := postAs(handler, 2, "/api/v1/items", "key-1", `{}`)
+	if *calls != 2 {
+		t.Errorf("handler called %d times, want 2", *calls)
+	}
+	if other.Header().Get(ReplayedHeader) != "" || other.Body.String() == first.Body.String() {
+		t.Error("another user's request got the first response replayed")
+	}
+
+	rec := postAs(handler, 0, "/api/v1/items", "key-1", `{}`)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("keyed request without a user status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if *calls != 2 {
+		t.Errorf("handler called %d times for a keyed request without a user, want 2", *calls)
+	}
+}
+
+func TestMiddleware_KeysAreScopedByScopePath(t *testing.T) {
+	next, calls := countingHandler(http.StatusCreated)
+	handler := Middleware(newMemoryStore(), 24*time.Hour, zap.NewNop())(next)
+
+	post(handler, "/api/v1/items", "key-1", `{}`)
+
+	// A request to the unversioned alias is the same request as to /api/v1
+	r := httptest.NewRequest("POST", "/api/items", strings.NewReader(`{}`))
+	r.Header.Set(Header, "key-1")
+	r = r.WithContext(WithScopePath(caller.WithUserID(r.Context(), testUserID), "/api/v1/items"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	if *calls != 1 {
+		t.Errorf("handler called %d times, want 1", *calls)
+	}
+	if rec.Header().Get(ReplayedHeader) != "true" {
+		t.Error("request to the alias was not replayed")
+	}
+}
+
+func TestMiddleware_RejectsReuseWithDifferentBody(t *testing.T) {
+	next, calls := countingHandler(http.StatusCreated)
+	handler := Middleware(newMemoryStore(), 24*time.Hour, zap.NewNop())(next)
+
+	post(handler, "/api/v1/items", "key-1", `{"title":"Bike"}`)
+	rec := post(handler, "/api/v1/items", "key-1", `{"title":"Tent"}`)
+
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "idempotency_key_reused") {
+		t.Errorf("reuse = %d %s, want 409 idempotency_key_reused", rec.Code, rec.Body)
+	}
+	if *calls != 1 {
+		t.Errorf("handler called %d times, want 1", *calls)
+	}
+}
+
+func TestMiddleware_RejectsRetryWhileInProgress(t *testing.T) {
+	store := newMemoryStore()
+	sum := sha256.Sum256([]byte(`{}`))
+	store.Reserve(context.Background(), "user 1 POST /api/v1/items", "key-1", hex.EncodeToString(sum[:]), time.Time{})
+
+	next, calls := countingHandler(http.StatusCreated)
+	handler := Middleware(store, 24*time.Hour, zap.NewNop())(next)
+
+	rec := post(handler, "/api/v1/items", "key-1", `{}`)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "request_in_progress") {
+		t.Errorf("retry = %d %s, want 409 request_in_progress", rec.Code, rec.Body)
+	}
+	if *calls != 0 {
+		t.Errorf("handler called %d times, want 0", *calls)
+	}
+}
+
+func TestMiddleware_DoesNotStoreServerErrors(t *testing.T) {
+	next, calls := countingHandler(http.StatusServiceUnavailable)
+	handler := Middleware(newMemoryStore(), 24*time.Hour, zap.NewNop())(next)
+
+	post(handler, "/api/v1/items", "key-1", `{}`)
+	post(handler, "/api/v1/items", "key-1", `{}`)
+
+	if *calls != 2 {
+		t.Errorf("handler called %d times, want 2", *calls)
+	}
+}
@@ -0,0 +1,67 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	_ "image/png" // register PNG decoder
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoder
+)
+
+// MaxPixels bounds the decoded size of an image to protect against decompression bombs
+const MaxPixels = 50_000_000
+
+// JPEGQuality is the quality used when re-encoding images as JPEG
+const JPEGQuality = 85
+
+// ErrImageTooLarge is returned when an image has more pixels than MaxPixels
+var ErrImageTooLarge = errors.New("image dimensions are too large")
+
+// Decode decodes a JPEG, PNG or WebP image after checking its dimensions
+func Decode(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, "", ErrImageTooLarge
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return img, format, nil
+}
+
+// Fit scales the image down so that neither side exceeds maxSize, keeping the aspect ratio.
+// Images that already fit are returned unchanged.
+func Fit(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*maxSize/width)
+		width = maxSize
+	} else {
+		width = max(1, width*maxSize/height)
+		height = maxSize
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// EncodeJPEG writes the image as a JPEG
+func EncodeJPEG(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: JPEGQuality})
+}
@@ -0,0 +1,84 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func newTestImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		name                  string
+		width, height         int
+		maxSize               int
+		wantWidth, wantHeight int
+	}{
+		{name: "landscape", width: 400, height: 200, maxSize: 100, wantWidth: 100, wantHeight: 50},
+		{name: "portrait", width: 200, height: 400, maxSize: 100, wantWidth: 50, wantHeight: 100},
+		{name: "already fits", width: 80, height: 60, maxSize: 100, wantWidth: 80, wantHeight: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fit(newTestImage(tt.width, tt.height), tt.maxSize).Bounds()
+			if got.Dx() != tt.wantWidth || got.Dy() != tt.wantHeight {
+				t.Errorf("Fit() size = %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newTestImage(30, 20)); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	img, format, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if format != "png" || img.Bounds().Dx() != 30 {
+		t.Errorf("Decode() = %s %dx%d, want png 30x20", format, img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	if _, _, err := Decode([]byte("not an image")); err == nil {
+		t.Errorf("Decode() garbage error = nil, want error")
+	}
+}
+
+func TestVariant_Render(t *testing.T) {
+	v := Variant{Name: "thumb", MaxSize: 50}
+
+	data, err := v.Render(newTestImage(200, 100))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	img, format, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() rendered variant error = %v", err)
+	}
+	if format != "jpeg" || img.Bounds().Dx() != 50 || img.Bounds().Dy() != 25 {
+		t.Errorf("Render() = %s %dx%d, want jpeg 50x25", format, img.Bounds().Dx(), img.Bounds().Dy())
+	}
+}
+
+func TestVariantKey(t *testing.T) {
+	v := Variant{Name: "medium"}
+	if got, want := VariantKey("items/1/abc.png", v), "items/1/abc_medium.jpg"; got != want {
+		t.Errorf("VariantKey() = %q, want %q", got, want)
+	}
+}
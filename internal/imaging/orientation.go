@@ -0,0 +1,145 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+// EXIF orientation values
+const (
+	OrientationNormal     = 1
+	OrientationFlipH      = 2
+	OrientationRotate180  = 3
+	OrientationFlipV      = 4
+	OrientationTranspose  = 5
+	OrientationRotate90   = 6
+	OrientationTransverse = 7
+	OrientationRotate270  = 8
+)
+
+const exifOrientationTag = 0x0112
+
+// Orientation reads the EXIF orientation of a JPEG image.
+// It returns OrientationNormal when the data is not a JPEG or carries no orientation.
+func Orientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return OrientationNormal
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return OrientationNormal
+		}
+		marker := data[pos+1]
+		// Start of scan: metadata segments are all before image data
+		if marker == 0xDA || marker == 0xD9 {
+			return OrientationNormal
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return OrientationNormal
+		}
+		segment := data[pos+4 : pos+2+length]
+
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			if o := tiffOrientation(segment[6:]); o != 0 {
+				return o
+			}
+		}
+
+		pos += 2 + length
+	}
+
+	return OrientationNormal
+}
+
+// tiffOrientation finds the orientation tag in IFD0 of a TIFF structure, or returns 0
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) != exifOrientationTag {
+			continue
+		}
+		o := int(order.Uint16(tiff[entry+8 : entry+10]))
+		if o < OrientationNormal || o > OrientationRotate270 {
+			return 0
+		}
+		return o
+	}
+
+	return 0
+}
+
+// ApplyOrientation transforms the image so it displays upright for the given EXIF orientation
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= OrientationNormal || orientation > OrientationRotate270 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dstWidth, dstHeight := width, height
+	if orientation >= OrientationTranspose {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case OrientationFlipH:
+				dx, dy = width-1-x, y
+			case OrientationRotate180:
+				dx, dy = width-1-x, height-1-y
+			case OrientationFlipV:
+				dx, dy = x, height-1-y
+			case OrientationTranspose:
+				dx, dy = y, x
+			case OrientationRotate90:
+				dx, dy = height-1-y, x
+			case OrientationTransverse:
+				dx, dy = height-1-y, width-1-x
+			case OrientationRotate270:
+				dx, dy = y, width-1-x
+			}
+
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,80 @@
+package imaging
+
+import (
+	"image"
+	"math/bits"
+
+	"golang.org/x/image/draw"
+)
+
+// DHash computes a 64-bit difference hash of the image. Visually similar images,
+// including rescaled or recompressed copies, have hashes with a small Hamming distance.
+func DHash(img image.Image) uint64 {
+	// One extra column so each row yields 8 left/right comparisons
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// HammingDistance returns the number of bits that differ between two hashes
+func HammingDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// HashBands is the number of 16-bit bands a hash is split into to look up similar hashes
+// in an index instead of comparing against every stored hash
+const HashBands = 4
+
+// maxBandDistance bounds the bits NearBandValues flips per band. Beyond it the values
+// cover so much of each band that comparing every hash is cheaper.
+const maxBandDistance = 3
+
+// HashBand returns band i of the hash, counting from the most significant bits
+func HashBand(hash uint64, i int) uint16 {
+	return uint16(hash >> (16 * (HashBands - 1 - i)))
+}
+
+// NearBandValues returns for each band of the hash the band values within
+// maxDistance/HashBands bits of it. A hash within maxDistance bits of this one differs
+// in fewer bits than that in at least one band, so only hashes with a band among the
+// values can be that close. It returns false when maxDistance is too large for the
+// bands to narrow the search.
+func NearBandValues(hash uint64, maxDistance int) ([HashBands][]uint16, bool) {
+	var values [HashBands][]uint16
+	if maxDistance < 0 {
+		return values, true
+	}
+	bandDistance := maxDistance / HashBands
+	if bandDistance > maxBandDistance {
+		return values, false
+	}
+
+	for i := range values {
+		values[i] = flipBits(nil, HashBand(hash, i), 0, bandDistance)
+	}
+	return values, true
+}
+
+// flipBits appends value and every value made by flipping up to n of its bits at
+// position from or above
+func flipBits(values []uint16, value uint16, from, n int) []uint16 {
+	values = append(values, value)
+	if n == 0 {
+		return values
+	}
+	for bit := from; bit < 16; bit++ {
+		values = flipBits(values, value^1<<bit, bit+1, n-1)
+	}
+	return values
+}
@@ -0,0 +1,113 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// newPatternImage draws large blocks so the picture survives rescaling and recompression
+func newPatternImage(width, height int, invert bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8((x * 4 / width) * 60)
+			if (y*4/height)%2 == 1 {
+				v = 255 - v
+			}
+			if invert {
+				v = 255 - v
+			}
+			img.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestDHash_SimilarImages(t *testing.T) {
+	original := newPatternImage(640, 480, false)
+
+	// A smaller, recompressed copy of the same picture
+	var buf bytes.Buffer
+	if err := EncodeJPEG(&buf, Fit(original, 200)); err != nil {
+		t.Fatalf("EncodeJPEG() error = %v", err)
+	}
+	copied, _, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if d := HammingDistance(DHash(original), DHash(copied)); d > 4 {
+		t.Errorf("HammingDistance() of rescaled copy = %d, want <= 4", d)
+	}
+
+	different := newPatternImage(640, 480, true)
+	if d := HammingDistance(DHash(original), DHash(different)); d < 16 {
+		t.Errorf("HammingDistance() of different image = %d, want >= 16", d)
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{a: 0, b: 0, want: 0},
+		{a: 0b1011, b: 0b0001, want: 2},
+		{a: ^uint64(0), b: 0, want: 64},
+	}
+
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%b, %b) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNearBandValues(t *testing.T) {
+	const hash = uint64(0x0123456789abcdef)
+
+	values, ok := NearBandValues(hash, 5)
+	if !ok {
+		t.Fatal("NearBandValues(5) cannot narrow the search")
+	}
+	for i, band := range values {
+		// The band itself and its 16 one-bit neighbours
+		if len(band) != 17 {
+			t.Errorf("band %d has %d values, want 17", i, len(band))
+		}
+	}
+
+	matches := func(other uint64) bool {
+		for i, band := range values {
+			for _, value := range band {
+				if HashBand(other, i) == value {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	// Spread five differing bits over the bands in every way that keeps them close
+	for _, flipped := range []uint64{
+		0,
+		1<<0 | 1<<16 | 1<<32 | 1<<48 | 1<<63,
+		1<<1 | 1<<2 | 1<<17 | 1<<18 | 1<<33,
+		1<<60 | 1<<61 | 1<<62 | 1<<63 | 1<<5,
+	} {
+		if !matches(hash ^ flipped) {
+			t.Errorf("hash %d bits away does not match a band", HammingDistance(hash, hash^flipped))
+		}
+	}
+
+	// Two differing bits in every band are more than five bits away
+	if matches(hash ^ (3 | 3<<16 | 3<<32 | 3<<48)) {
+		t.Error("hash 8 bits away matches a band")
+	}
+
+	if _, ok := NearBandValues(hash, 16); ok {
+		t.Error("NearBandValues(16) narrows the search")
+	}
+}
@@ -0,0 +1,42 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+)
+
+// SanitizedPhoto is a photo re-encoded by Sanitize
+type SanitizedPhoto struct {
+	// Data is the re-encoded photo without metadata
+	Data []byte
+	// Format is FormatJPEG or FormatPNG
+	Format string
+	// Image is the decoded photo with its orientation applied
+	Image image.Image
+}
+
+// Sanitize decodes a photo, applies its EXIF orientation and encodes it again.
+// Re-encoding drops all metadata such as EXIF GPS coordinates. JPEG and PNG keep
+// their format; other formats are converted to JPEG.
+func Sanitize(data []byte) (*SanitizedPhoto, error) {
+	img, format, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	img = ApplyOrientation(img, Orientation(data))
+
+	var buf bytes.Buffer
+	if format == FormatPNG {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return &SanitizedPhoto{Data: buf.Bytes(), Format: FormatPNG, Image: img}, nil
+	}
+
+	if err := EncodeJPEG(&buf, img); err != nil {
+		return nil, err
+	}
+	return &SanitizedPhoto{Data: buf.Bytes(), Format: FormatJPEG, Image: img}, nil
+}
@@ -0,0 +1,108 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// withEXIFOrientation inserts an APP1 EXIF segment with the orientation and a GPS
+// marker right after the JPEG start-of-image marker
+func withEXIFOrientation(t *testing.T, jpegData []byte, orientation byte) []byte {
+	t.Helper()
+
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, // big-endian header, IFD0 at offset 8
+		0x00, 0x01, // one entry
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, orientation, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	payload = append(payload, []byte("GPS 43.2389,76.8897")...)
+
+	length := len(payload) + 2
+	segment := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, payload...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeJPEG(&buf, newTestImage(8, 4)); err != nil {
+		t.Fatalf("EncodeJPEG() error = %v", err)
+	}
+
+	if got := Orientation(buf.Bytes()); got != OrientationNormal {
+		t.Errorf("Orientation() without EXIF = %d, want %d", got, OrientationNormal)
+	}
+	if got := Orientation(withEXIFOrientation(t, buf.Bytes(), OrientationRotate90)); got != OrientationRotate90 {
+		t.Errorf("Orientation() = %d, want %d", got, OrientationRotate90)
+	}
+	if got := Orientation([]byte("not a jpeg")); got != OrientationNormal {
+		t.Errorf("Orientation() garbage = %d, want %d", got, OrientationNormal)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	marker := color.RGBA{R: 255, A: 255}
+	src.Set(0, 0, marker) // top-left pixel
+
+	tests := []struct {
+		orientation int
+		wantW       int
+		wantH       int
+		wantX       int
+		wantY       int
+	}{
+		{orientation: OrientationNormal, wantW: 3, wantH: 2, wantX: 0, wantY: 0},
+		{orientation: OrientationFlipH, wantW: 3, wantH: 2, wantX: 2, wantY: 0},
+		{orientation: OrientationRotate180, wantW: 3, wantH: 2, wantX: 2, wantY: 1},
+		{orientation: OrientationFlipV, wantW: 3, wantH: 2, wantX: 0, wantY: 1},
+		{orientation: OrientationTranspose, wantW: 2, wantH: 3, wantX: 0, wantY: 0},
+		{orientation: OrientationRotate90, wantW: 2, wantH: 3, wantX: 1, wantY: 0},
+		{orientation: OrientationTransverse, wantW: 2, wantH: 3, wantX: 1, wantY: 2},
+		{orientation: OrientationRotate270, wantW: 2, wantH: 3, wantX: 0, wantY: 2},
+	}
+
+	for _, tt := range tests {
+		got := ApplyOrientation(src, tt.orientation)
+		if got.Bounds().Dx() != tt.wantW || got.Bounds().Dy() != tt.wantH {
+			t.Errorf("ApplyOrientation(%d) size = %dx%d, want %dx%d", tt.orientation, got.Bounds().Dx(), got.Bounds().Dy(), tt.wantW, tt.wantH)
+			continue
+		}
+		if r, _, _, _ := got.At(tt.wantX, tt.wantY).RGBA(); r>>8 != 255 {
+			t.Errorf("ApplyOrientation(%d) marker not at (%d,%d)", tt.orientation, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeJPEG(&buf, newTestImage(40, 20)); err != nil {
+		t.Fatalf("EncodeJPEG() error = %v", err)
+	}
+	original := withEXIFOrientation(t, buf.Bytes(), OrientationRotate90)
+
+	sanitized, err := Sanitize(original)
+	if err != nil {
+		t.Fatalf("Sanitize() error = %v", err)
+	}
+	if sanitized.Format != FormatJPEG {
+		t.Errorf("Sanitize() format = %q, want %q", sanitized.Format, FormatJPEG)
+	}
+	if bytes.Contains(sanitized.Data, []byte("Exif")) || bytes.Contains(sanitized.Data, []byte("GPS")) {
+		t.Errorf("Sanitize() output still contains metadata")
+	}
+
+	img, _, err := Decode(sanitized.Data)
+	if err != nil {
+		t.Fatalf("Decode() sanitized error = %v", err)
+	}
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 40 {
+		t.Errorf("Sanitize() size = %dx%d, want 20x40", img.Bounds().Dx(), img.Bounds().Dy())
+	}
+}
@@ -0,0 +1,56 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"path"
+	"strings"
+)
+
+// Output formats
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// Variant describes a resized rendition of an uploaded photo. Variants are always
+// encoded as JPEG: the standard library has no WebP encoder, so WebP variants are not
+// produced.
+type Variant struct {
+	Name    string
+	MaxSize int
+}
+
+// DefaultVariants are generated for every uploaded photo
+var DefaultVariants = []Variant{
+	{Name: "thumb", MaxSize: 320},
+	{Name: "medium", MaxSize: 800},
+	{Name: "large", MaxSize: 1600},
+}
+
+// VariantKey derives the storage key of a variant from the key of the original,
+// e.g. "items/1/abc.png" becomes "items/1/abc_thumb.jpg"
+func VariantKey(originalKey string, v Variant) string {
+	base := strings.TrimSuffix(originalKey, path.Ext(originalKey))
+	return fmt.Sprintf("%s_%s%s", base, v.Name, v.Extension())
+}
+
+// Extension returns the file extension of the variant
+func (v Variant) Extension() string {
+	return ".jpg"
+}
+
+// ContentType returns the MIME type of the variant
+func (v Variant) ContentType() string {
+	return "image/jpeg"
+}
+
+// Render resizes the image for the variant and encodes it as JPEG
+func (v Variant) Render(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := EncodeJPEG(&buf, Fit(img, v.MaxSize)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
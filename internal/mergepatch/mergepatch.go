@@ -0,0 +1,87 @@
+// Package mergepatch applies JSON Merge Patch documents (RFC 7396). A patch is a JSON
+// object listing the members to change: a null value removes a member, an object is
+// merged recursively and any other value replaces the member.
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotObject is returned by ApplyTo when the patch is not a JSON object
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// Apply merges patch into the JSON document doc and returns the result
+func Apply(doc []byte, patch []byte) ([]byte, error) {
+	var target interface{}
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if err := json.Unmarshal(doc, &target); err != nil {
+			return nil, fmt.Errorf("invalid document: %w", err)
+		}
+	}
+
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	return json.Marshal(merge(target, p))
+}
+
+// merge implements the MergePatch function of RFC 7396, section 2
+func merge(target interface{}, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = merge(t[key], value)
+		}
+	}
+	return t
+}
+
+// ApplyTo merges patch into the JSON representation of the struct v points to and
+// decodes the result back into it. Members removed by the patch leave their fields at
+// the zero value, so null clears pointers, slices and maps. Members v has no field for
+// are rejected, so misspelled keys are not silently ignored.
+func ApplyTo(v interface{}, patch []byte) error {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return fmt.Errorf("invalid merge patch: %w", err)
+	}
+	if _, ok := p.(map[string]interface{}); !ok {
+		return ErrNotObject
+	}
+
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	merged, err := Apply(doc, patch)
+	if err != nil {
+		return err
+	}
+
+	// Start from the zero value so members removed by the patch stay cleared
+	rv := reflect.ValueOf(v).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid merge patch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Test cases from RFC 7396, appendix A
+func TestApply(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("Apply(%s, %s) error = %v", tt.doc, tt.patch, err)
+			continue
+		}
+		if !jsonEqual(t, got, []byte(tt.want)) {
+			t.Errorf("Apply(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+type listing struct {
+	Title      string                 `json:"title"`
+	CategoryID *int                   `json:"category_id"`
+	Tags       []string               `json:"tags"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+func TestApplyTo(t *testing.T) {
+	category := 3
+	v := listing{
+		Title:      "Bike",
+		CategoryID: &category,
+		Tags:       []string{"sport"},
+		Attributes: map[string]interface{}{"size": "M", "color": "red"},
+	}
+
+	err := ApplyTo(&v, []byte(`{"category_id":null,"title":"Trek bike","attributes":{"color":null}}`))
+	if err != nil {
+		t.Fatalf("ApplyTo() error = %v", err)
+	}
+
+	want := listing{
+		Title:      "Trek bike",
+		Tags:       []string{"sport"},
+		Attributes: map[string]interface{}{"size": "M"},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("ApplyTo() = %+v, want %+v", v, want)
+	}
+}
+
+func TestApplyTo_Rejects(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":      `{"title":`,
+		"not an object": `["title"]`,
+		"unknown field": `{"titel":"Bike"}`,
+		"wrong type":    `{"title":5}`,
+	}
+
+	for name, patch := range tests {
+		v := listing{Title: "Bike"}
+		if err := ApplyTo(&v, []byte(patch)); err == nil {
+			t.Errorf("%s: ApplyTo(%s) error = nil, want an error", name, patch)
+		}
+	}
+
+	v := listing{}
+	if err := ApplyTo(&v, []byte(`null`)); !errors.Is(err, ErrNotObject) {
+		t.Errorf("ApplyTo(null) error = %v, want ErrNotObject", err)
+	}
+}
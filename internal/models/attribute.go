@@ -0,0 +1,248 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Attribute types supported in category attribute schemas
+const (
+	AttributeTypeString = "string"
+	AttributeTypeNumber = "number"
+	AttributeTypeEnum   = "enum"
+	AttributeTypeBool   = "bool"
+)
+
+// Attribute filter operators
+const (
+	AttributeFilterEq  = "eq"
+	AttributeFilterMin = "min"
+	AttributeFilterMax = "max"
+)
+
+// attributeKeyPattern restricts attribute keys to snake_case identifiers
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// IsValidAttributeKey reports whether the key can be used as an attribute name
+func IsValidAttributeKey(key string) bool {
+	return attributeKeyPattern.MatchString(key)
+}
+
+// AttributeDefinition describes a single typed attribute that items of a category can have
+type AttributeDefinition struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label,omitempty"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// AttributeSchema is the list of attributes defined by a category, stored as JSONB
+type AttributeSchema []AttributeDefinition
+
+// Value implements driver.Valuer
+func (s AttributeSchema) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (s *AttributeSchema) Scan(src interface{}) error {
+	return scanJSON(src, s)
+}
+
+// Validate checks that the schema itself is well formed
+func (s AttributeSchema) Validate() error {
+	seen := make(map[string]bool, len(s))
+	for _, def := range s {
+		if !IsValidAttributeKey(def.Key) {
+			return fmt.Errorf("invalid attribute key %q", def.Key)
+		}
+		if seen[def.Key] {
+			return fmt.Errorf("duplicate attribute key %q", def.Key)
+		}
+		seen[def.Key] = true
+
+		switch def.Type {
+		case AttributeTypeString, AttributeTypeNumber, AttributeTypeBool:
+			if len(def.Options) > 0 {
+				return fmt.Errorf("attribute %q: options are only allowed for enum attributes", def.Key)
+			}
+		case AttributeTypeEnum:
+			if len(def.Options) == 0 {
+				return fmt.Errorf("attribute %q: enum attributes require options", def.Key)
+			}
+		default:
+			return fmt.Errorf("attribute %q: unsupported type %q", def.Key, def.Type)
+		}
+	}
+	return nil
+}
+
+// ItemAttributes holds the attribute values of an item, stored as JSONB
+type ItemAttributes map[string]interface{}
+
+// Value implements driver.Valuer
+func (a ItemAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (a *ItemAttributes) Scan(src interface{}) error {
+	return scanJSON(src, a)
+}
+
+// Rules broken by attribute values, reported alongside the rules of request validation
+const (
+	AttributeRuleRequired = "required"
+	AttributeRuleUnknown  = "unknown"
+	AttributeRuleType     = "type"
+	AttributeRuleOneOf    = "oneof"
+)
+
+// AttributeProblem names the rule an attribute value broke and the rule's parameter,
+// e.g. the expected type or the allowed options
+type AttributeProblem struct {
+	Rule  string
+	Param string
+}
+
+// String describes the problem in English
+func (p AttributeProblem) String() string {
+	switch p.Rule {
+	case AttributeRuleRequired:
+		return "is required"
+	case AttributeRuleUnknown:
+		return "is not defined for this category"
+	case AttributeRuleType:
+		return "must be a " + p.Param
+	case AttributeRuleOneOf:
+		return "must be one of: " + strings.ReplaceAll(p.Param, " ", ", ")
+	}
+	return "is invalid"
+}
+
+// AttributeValidationError lists the attributes of an item that do not match the category schema
+type AttributeValidationError struct {
+	// Fields maps an attribute key to the reason it was rejected
+	Fields map[string]AttributeProblem
+}
+
+func (e *AttributeValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+": "+e.Fields[key].String())
+	}
+	return "invalid attributes: " + strings.Join(parts, "; ")
+}
+
+// ValidateAttributes checks item attribute values against the schema.
+// Unknown keys, missing required attributes and values of the wrong type are rejected.
+func (s AttributeSchema) ValidateAttributes(values ItemAttributes) error {
+	fields := make(map[string]AttributeProblem)
+
+	defs := make(map[string]AttributeDefinition, len(s))
+	for _, def := range s {
+		defs[def.Key] = def
+		if _, ok := values[def.Key]; !ok && def.Required {
+			fields[def.Key] = AttributeProblem{Rule: AttributeRuleRequired}
+		}
+	}
+
+	for key, value := range values {
+		def, ok := defs[key]
+		if !ok {
+			fields[key] = AttributeProblem{Rule: AttributeRuleUnknown}
+			continue
+		}
+		if value == nil {
+			if def.Required {
+				fields[key] = AttributeProblem{Rule: AttributeRuleRequired}
+			}
+			continue
+		}
+		if problem, ok := def.check(value); !ok {
+			fields[key] = problem
+		}
+	}
+
+	if len(fields) > 0 {
+		return &AttributeValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// check reports whether the value fits the definition, and the problem if it does not
+func (d AttributeDefinition) check(value interface{}) (AttributeProblem, bool) {
+	switch d.Type {
+	case AttributeTypeString:
+		if _, ok := value.(string); !ok {
+			return AttributeProblem{Rule: AttributeRuleType, Param: "string"}, false
+		}
+	case AttributeTypeNumber:
+		if _, ok := value.(float64); !ok {
+			return AttributeProblem{Rule: AttributeRuleType, Param: "number"}, false
+		}
+	case AttributeTypeBool:
+		if _, ok := value.(bool); !ok {
+			return AttributeProblem{Rule: AttributeRuleType, Param: "boolean"}, false
+		}
+	case AttributeTypeEnum:
+		str, ok := value.(string)
+		if !ok {
+			return AttributeProblem{Rule: AttributeRuleType, Param: "string"}, false
+		}
+		for _, option := range d.Options {
+			if option == str {
+				return AttributeProblem{}, true
+			}
+		}
+		// Space separated, like the oneof rule of request validation
+		return AttributeProblem{Rule: AttributeRuleOneOf, Param: strings.Join(d.Options, " ")}, false
+	}
+	return AttributeProblem{}, true
+}
+
+// AttributeFilter filters items by the value of a single attribute
+type AttributeFilter struct {
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+func scanJSON(src interface{}, dest interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return errors.New("unsupported type for JSON column")
+	}
+}
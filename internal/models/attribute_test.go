@@ -0,0 +1,126 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAttributeSchema_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  AttributeSchema
+		wantErr bool
+	}{
+		{
+			name: "valid schema",
+			schema: AttributeSchema{
+				{Key: "year", Type: AttributeTypeNumber, Required: true},
+				{Key: "transmission", Type: AttributeTypeEnum, Options: []string{"manual", "automatic"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "invalid key",
+			schema:  AttributeSchema{{Key: "Year", Type: AttributeTypeNumber}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate key",
+			schema: AttributeSchema{
+				{Key: "size", Type: AttributeTypeString},
+				{Key: "size", Type: AttributeTypeString},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "enum without options",
+			schema:  AttributeSchema{{Key: "size", Type: AttributeTypeEnum}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			schema:  AttributeSchema{{Key: "size", Type: "date"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AttributeSchema.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttributeSchema_ValidateAttributes(t *testing.T) {
+	schema := AttributeSchema{
+		{Key: "year", Type: AttributeTypeNumber, Required: true},
+		{Key: "transmission", Type: AttributeTypeEnum, Options: []string{"manual", "automatic"}},
+		{Key: "electric", Type: AttributeTypeBool},
+		{Key: "color", Type: AttributeTypeString},
+	}
+
+	tests := []struct {
+		name       string
+		attributes string
+		wantFields []string
+	}{
+		{
+			name:       "valid attributes",
+			attributes: `{"year": 2015, "transmission": "manual", "electric": false, "color": "red"}`,
+		},
+		{
+			name:       "missing required",
+			attributes: `{"color": "red"}`,
+			wantFields: []string{"year"},
+		},
+		{
+			name:       "wrong types",
+			attributes: `{"year": "2015", "electric": "yes"}`,
+			wantFields: []string{"year", "electric"},
+		},
+		{
+			name:       "enum option not allowed",
+			attributes: `{"year": 2015, "transmission": "robot"}`,
+			wantFields: []string{"transmission"},
+		},
+		{
+			name:       "unknown key",
+			attributes: `{"year": 2015, "size": "M"}`,
+			wantFields: []string{"size"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values ItemAttributes
+			if err := json.Unmarshal([]byte(tt.attributes), &values); err != nil {
+				t.Fatalf("failed to decode attributes: %v", err)
+			}
+
+			err := schema.ValidateAttributes(values)
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Errorf("ValidateAttributes() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var validationErr *AttributeValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ValidateAttributes() error = %v, want *AttributeValidationError", err)
+			}
+			if len(validationErr.Fields) != len(tt.wantFields) {
+				t.Errorf("ValidateAttributes() fields = %v, want %v", validationErr.Fields, tt.wantFields)
+			}
+			for _, field := range tt.wantFields {
+				if _, ok := validationErr.Fields[field]; !ok {
+					t.Errorf("ValidateAttributes() missing error for %q", field)
+				}
+			}
+		})
+	}
+}
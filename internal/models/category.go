@@ -2,26 +2,48 @@ package models
 
 import (
 	"time"
-
-	"github.com/go-playground/validator/v10"
 )
 
 // Category represents a category of rent items
 type Category struct {
-	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name" validate:"required,min=1,max=50"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID              int             `json:"id" db:"id"`
+	Name            string          `json:"name" db:"name" validate:"required,min=1,max=50"`
+	Slug            string          `json:"slug" db:"slug"`
+	ParentID        *int            `json:"parent_id,omitempty" db:"parent_id" validate:"omitempty,min=1"`
+	AttributeSchema AttributeSchema `json:"attribute_schema" db:"attribute_schema"`
+	ItemsCount      int             `json:"items_count" db:"items_count"`
+	Version         int             `json:"version" db:"version"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
 }
 
 // CreateCategoryRequest represents the request to create a new category
 type CreateCategoryRequest struct {
-	Name string `json:"name" validate:"required,min=1,max=50"`
+	Name            string          `json:"name" validate:"required,min=1,max=50"`
+	Slug            string          `json:"slug,omitempty" validate:"omitempty,max=100"`
+	ParentID        *int            `json:"parent_id,omitempty" validate:"omitempty,min=1"`
+	AttributeSchema AttributeSchema `json:"attribute_schema,omitempty"`
+}
+
+// ReplaceCategoryRequest is the editable representation of a category. PUT replaces a
+// category with it, so omitted fields are cleared and an empty slug is generated from
+// the name; PATCH merges a JSON Merge Patch into the category's current representation.
+type ReplaceCategoryRequest struct {
+	Name            string          `json:"name" validate:"required,min=1,max=50"`
+	Slug            string          `json:"slug" validate:"omitempty,max=100"`
+	ParentID        *int            `json:"parent_id" validate:"omitempty,min=1"`
+	AttributeSchema AttributeSchema `json:"attribute_schema"`
 }
 
-// UpdateCategoryRequest represents the request to update a category
-type UpdateCategoryRequest struct {
-	Name *string `json:"name,omitempty" validate:"omitempty,min=1,max=50"`
+// ReplaceRequest returns the editable representation of the category, the document
+// merge patches are applied to
+func (c *Category) ReplaceRequest() *ReplaceCategoryRequest {
+	return &ReplaceCategoryRequest{
+		Name:            c.Name,
+		Slug:            c.Slug,
+		ParentID:        c.ParentID,
+		AttributeSchema: c.AttributeSchema,
+	}
 }
 
 // CategoryResponse is a struct for the API response that includes full category info
@@ -30,20 +52,128 @@ type CategoryResponse struct {
 	Name string `json:"name" db:"name"`
 }
 
-// Validate validates the struct using go-playground/validator
+// CategoryTranslation is a localized name of a category
+type CategoryTranslation struct {
+	CategoryID int       `json:"category_id" db:"category_id"`
+	Lang       string    `json:"lang" db:"lang"`
+	Name       string    `json:"name" db:"name"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UpsertCategoryTranslationRequest represents the request to set a localized category name
+type UpsertCategoryTranslationRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+// MergeCategoryRequest represents the request to merge a category into another one
+type MergeCategoryRequest struct {
+	TargetID int `json:"target_id" validate:"required,min=1"`
+}
+
+// MergeCategoryResult describes the outcome of a category merge
+type MergeCategoryResult struct {
+	SourceID   int `json:"source_id"`
+	TargetID   int `json:"target_id"`
+	ItemsMoved int `json:"items_moved"`
+}
+
+// CategoryTreeNode is a category together with its nested subcategories
+type CategoryTreeNode struct {
+	ID         int                 `json:"id"`
+	Name       string              `json:"name"`
+	Slug       string              `json:"slug"`
+	ParentID   *int                `json:"parent_id,omitempty"`
+	ItemsCount int                 `json:"items_count"`
+	Children   []*CategoryTreeNode `json:"children"`
+}
+
+// BuildCategoryTree arranges a flat list of categories into a tree.
+// Categories whose parent is missing from the list are treated as roots.
+// The order of the input is preserved among siblings.
+func BuildCategoryTree(categories []Category) []*CategoryTreeNode {
+	nodes := make(map[int]*CategoryTreeNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &CategoryTreeNode{
+			ID:         c.ID,
+			Name:       c.Name,
+			Slug:       c.Slug,
+			ParentID:   c.ParentID,
+			ItemsCount: c.ItemsCount,
+			Children:   []*CategoryTreeNode{},
+		}
+	}
+
+	roots := []*CategoryTreeNode{}
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	return roots
+}
+
+// AggregateItemCounts adds the item counts of every subcategory to its ancestors,
+// so each category reports the number of items in its whole subtree
+func AggregateItemCounts(categories []Category) {
+	index := make(map[int]int, len(categories))
+	for i, c := range categories {
+		index[c.ID] = i
+	}
+
+	direct := make([]int, len(categories))
+	for i, c := range categories {
+		direct[i] = c.ItemsCount
+	}
+
+	for i, c := range categories {
+		visited := map[int]bool{c.ID: true}
+		parentID := c.ParentID
+		for parentID != nil && !visited[*parentID] {
+			p, ok := index[*parentID]
+			if !ok {
+				break
+			}
+			visited[*parentID] = true
+			categories[p].ItemsCount += direct[i]
+			parentID = categories[p].ParentID
+		}
+	}
+}
+
+// Validate validates the struct using the shared validator
 func (c *Category) Validate() error {
-	validate := validator.New()
 	return validate.Struct(c)
 }
 
 // Validate validates the CreateCategoryRequest
 func (cc *CreateCategoryRequest) Validate() error {
-	validate := validator.New()
-	return validate.Struct(cc)
+	if err := validate.Struct(cc); err != nil {
+		return err
+	}
+	return cc.AttributeSchema.Validate()
+}
+
+// Validate validates the ReplaceCategoryRequest
+func (rc *ReplaceCategoryRequest) Validate() error {
+	if err := validate.Struct(rc); err != nil {
+		return err
+	}
+	return rc.AttributeSchema.Validate()
+}
+
+// Validate validates the UpsertCategoryTranslationRequest
+func (ut *UpsertCategoryTranslationRequest) Validate() error {
+	return validate.Struct(ut)
 }
 
-// Validate validates the UpdateCategoryRequest
-func (uc *UpdateCategoryRequest) Validate() error {
-	validate := validator.New()
-	return validate.Struct(uc)
+// Validate validates the MergeCategoryRequest
+func (mc *MergeCategoryRequest) Validate() error {
+	return validate.Struct(mc)
 }
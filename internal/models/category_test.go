@@ -72,3 +72,57 @@ func TestCreateCategoryRequest_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildCategoryTree(t *testing.T) {
+	electronicsID := 1
+	phonesID := 2
+	missingID := 99
+
+	categories := []Category{
+		{ID: electronicsID, Name: "Электроника"},
+		{ID: phonesID, Name: "Смартфоны", ParentID: &electronicsID},
+		{ID: 3, Name: "Аксессуары", ParentID: &phonesID},
+		{ID: 4, Name: "Транспорт"},
+		{ID: 5, Name: "Сироты", ParentID: &missingID},
+	}
+
+	tree := BuildCategoryTree(categories)
+
+	if len(tree) != 3 {
+		t.Fatalf("BuildCategoryTree() roots = %d, want 3", len(tree))
+	}
+	if tree[0].ID != electronicsID || len(tree[0].Children) != 1 {
+		t.Fatalf("BuildCategoryTree() first root = %+v, want category 1 with one child", tree[0])
+	}
+	phones := tree[0].Children[0]
+	if phones.ID != phonesID || len(phones.Children) != 1 || phones.Children[0].ID != 3 {
+		t.Errorf("BuildCategoryTree() nested child = %+v, want category 2 with child 3", phones)
+	}
+	if tree[2].ID != 5 {
+		t.Errorf("BuildCategoryTree() orphan root = %d, want 5", tree[2].ID)
+	}
+	if tree[1].Children == nil {
+		t.Errorf("BuildCategoryTree() leaf children = nil, want empty slice")
+	}
+}
+
+func TestAggregateItemCounts(t *testing.T) {
+	electronicsID := 1
+	phonesID := 2
+
+	categories := []Category{
+		{ID: electronicsID, Name: "Электроника", ItemsCount: 1},
+		{ID: phonesID, Name: "Смартфоны", ParentID: &electronicsID, ItemsCount: 2},
+		{ID: 3, Name: "Аксессуары", ParentID: &phonesID, ItemsCount: 4},
+		{ID: 4, Name: "Транспорт", ItemsCount: 8},
+	}
+
+	AggregateItemCounts(categories)
+
+	want := map[int]int{1: 7, 2: 6, 3: 4, 4: 8}
+	for _, c := range categories {
+		if c.ItemsCount != want[c.ID] {
+			t.Errorf("AggregateItemCounts() category %d count = %d, want %d", c.ID, c.ItemsCount, want[c.ID])
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// IdempotencyRecord is a request sent with an Idempotency-Key and, once it has been
+// handled, the response to replay when the request is retried. StatusCode is nil while
+// the first request is still being processed.
+type IdempotencyRecord struct {
+	Scope       string         `db:"scope"`
+	Key         string         `db:"key"`
+	RequestHash string         `db:"request_hash"`
+	StatusCode  *int           `db:"status_code"`
+	Headers     ResponseHeader `db:"headers"`
+	Body        []byte         `db:"body"`
+	CreatedAt   time.Time      `db:"created_at"`
+}
+
+// ResponseHeader is the header of a stored response, saved as a JSON object
+type ResponseHeader http.Header
+
+// Value implements driver.Valuer
+func (h ResponseHeader) Value() (driver.Value, error) {
+	if h == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (h *ResponseHeader) Scan(src interface{}) error {
+	return scanJSON(src, h)
+}
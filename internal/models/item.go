@@ -3,78 +3,89 @@ package models
 import (
 	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/lib/pq"
 )
 
 // Item represents an item available for rent
 type Item struct {
-	ID          int       `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title" validate:"required,min=1,max=200"`
-	Description string    `json:"description" db:"description" validate:"required,min=10,max=2000"`
-	Price       int       `json:"price" db:"price" validate:"required,min=0"`
-	Location    string    `json:"location" db:"location" validate:"required,min=1,max=500"`
-	HasPhotos   bool      `json:"has_photos" db:"has_photos"`
-	AuthorID    int       `json:"author_id" db:"author_id"`
-	CategoryID  *int      `json:"category_id,omitempty" db:"category_id"`
-	Tags        []string  `json:"tags,omitempty" db:"tags"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int            `json:"id" db:"id"`
+	Title       string         `json:"title" db:"title" validate:"required,min=1,max=200"`
+	Description string         `json:"description" db:"description" validate:"required,min=10,max=2000"`
+	Price       int            `json:"price" db:"price" validate:"required,min=0"`
+	Location    string         `json:"location" db:"location" validate:"required,min=1,max=500"`
+	HasPhotos   bool           `json:"has_photos" db:"has_photos"`
+	AuthorID    int            `json:"author_id" db:"author_id"`
+	CategoryID  *int           `json:"category_id,omitempty" db:"category_id"`
+	Tags        []string       `json:"tags,omitempty" db:"tags"`
+	Attributes  ItemAttributes `json:"attributes" db:"attributes"`
+	Version     int            `json:"version" db:"version"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
 }
 
 // ItemToUpdate
 type ItemToUpdate struct {
-	ID          int       `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title"`
-	Description string    `json:"description" db:"description"`
-	Price       float64   `json:"price" db:"price"`
-	Location    string    `json:"location" db:"location"`
-	HasPhotos   bool      `json:"has_photos" db:"has_photos"`
-	AuthorID    int       `json:"author_id" db:"author_id"`
-	CategoryID  int       `json:"category_id" db:"category_id"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int            `json:"id" db:"id"`
+	Title       string         `json:"title" db:"title"`
+	Description string         `json:"description" db:"description"`
+	Price       float64        `json:"price" db:"price"`
+	Location    string         `json:"location" db:"location"`
+	HasPhotos   bool           `json:"has_photos" db:"has_photos"`
+	AuthorID    int            `json:"author_id" db:"author_id"`
+	CategoryID  *int           `json:"category_id" db:"category_id"`
+	Attributes  ItemAttributes `json:"attributes" db:"attributes"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
 }
 
-// CreateItemRequest represents the request to create a new item
+// CreateItemRequest represents the request to create a new item.
+// With ImportPhotos set, the photos are copied into our own storage instead of linked to.
 type CreateItemRequest struct {
-	Title       string   `json:"title" validate:"required,min=1,max=200"`
-	Description string   `json:"description" validate:"required,min=10,max=2000"`
-	Price       int      `json:"price" validate:"required,min=0"`
-	Location    string   `json:"location" validate:"required,min=1,max=500"`
-	Photos      []string `json:"photos" validate:"omitempty,min=1,max=10"`
-	CategoryID  *int     `json:"category_id,omitempty" validate:"omitempty,min=1"`
-	AuthorID    int      `json:"author_id" validate:"required,min=1"`
-	Tags        []string `json:"tags,omitempty"`
+	Title        string         `json:"title" validate:"required,min=1,max=200"`
+	Description  string         `json:"description" validate:"required,min=10,max=2000"`
+	Price        int            `json:"price" validate:"required,min=0"`
+	Location     string         `json:"location" validate:"required,min=1,max=500"`
+	Photos       []string       `json:"photos" validate:"omitempty,min=1,dive,url"`
+	CategoryID   *int           `json:"category_id,omitempty" validate:"omitempty,min=1"`
+	AuthorID     int            `json:"author_id" validate:"required,min=1"`
+	Tags         []string       `json:"tags,omitempty"`
+	Attributes   ItemAttributes `json:"attributes,omitempty"`
+	ImportPhotos bool           `json:"import_photos,omitempty"`
 }
 
-// UpdateItemRequest represents the request to update an item
-type UpdateItemRequest struct {
-	Title            *string  `json:"title"`
-	Description      *string  `json:"description"`
-	Price            *float64 `json:"price"`
-	Location         *string  `json:"location"`
-	CategoryID       *int     `json:"category_id"`
-	PhotosToAdd      []string `json:"photos_to_add"`
-	PhotoIDsToDelete []int    `json:"photo_ids_to_delete"`
-	Tags             []string `json:"tags"`
+// ReplaceItemRequest is the editable representation of an item. PUT replaces an item
+// with it, so omitted fields are cleared; PATCH merges a JSON Merge Patch into the
+// item's current representation. Photos are managed through the item photo endpoints,
+// which enforce the photo limit; requests with other fields are rejected.
+type ReplaceItemRequest struct {
+	Title       string         `json:"title" validate:"required,min=1,max=200"`
+	Description string         `json:"description" validate:"required,min=10,max=2000"`
+	Price       int            `json:"price" validate:"required,min=0"`
+	Location    string         `json:"location" validate:"required,min=1,max=500"`
+	CategoryID  *int           `json:"category_id" validate:"omitempty,min=1"`
+	Attributes  ItemAttributes `json:"attributes"`
 }
 
 // ItemFilter represents filters for listing items
 type ItemFilter struct {
-	MinPrice   *int    `json:"min_price,omitempty"`
-	MaxPrice   *int    `json:"max_price,omitempty"`
-	Location   *string `json:"location,omitempty"`
-	Search     *string `json:"search,omitempty"`
-	Limit      int     `json:"limit,omitempty"`
-	Offset     int     `json:"offset,omitempty"`
-	CategoryID *int    `json:"category_id,omitempty" validate:"omitempty,min=1"`
+	MinPrice   *int              `json:"min_price,omitempty"`
+	MaxPrice   *int              `json:"max_price,omitempty"`
+	Location   *string           `json:"location,omitempty"`
+	Search     *string           `json:"search,omitempty"`
+	Limit      int               `json:"limit,omitempty"`
+	Offset     int               `json:"offset,omitempty"`
+	CategoryID *int              `json:"category_id,omitempty" validate:"omitempty,min=1"`
+	Attributes []AttributeFilter `json:"attributes,omitempty"`
+	Lang       string            `json:"lang,omitempty"`
 }
 
-// CategoryInfo represents a short category info for embedding in other responses
+// CategoryInfo represents a short category info for embedding in other responses.
+// Both fields are null for items without a category. Version is the category's
+// version, which goes into the item's ETag because the category name is part of the item.
 type CategoryInfo struct {
-	ID   int    `json:"id" db:"id"`
-	Name string `json:"name" db:"name"`
+	ID      *int    `json:"id" db:"id"`
+	Name    *string `json:"name" db:"name"`
+	Version *int    `json:"-" db:"version"`
 }
 
 // ItemResponse is a struct for the API response that includes full category info
@@ -88,10 +99,25 @@ type ItemResponse struct {
 	Photos      pq.StringArray `json:"photos" db:"photos"`
 	AuthorID    int            `json:"author_id" db:"author_id"`
 	Category    CategoryInfo   `json:"category" db:"category"`
+	Attributes  ItemAttributes `json:"attributes" db:"attributes"`
+	Version     int            `json:"version" db:"version"`
 	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
 }
 
+// ReplaceRequest returns the editable representation of the item, the document merge
+// patches are applied to
+func (ir *ItemResponse) ReplaceRequest() *ReplaceItemRequest {
+	return &ReplaceItemRequest{
+		Title:       ir.Title,
+		Description: ir.Description,
+		Price:       int(ir.Price),
+		Location:    ir.Location,
+		CategoryID:  ir.Category.ID,
+		Attributes:  ir.Attributes,
+	}
+}
+
 // ToResponse converts ItemResponse with pq.StringArray to one with []string for JSON
 func (ir *ItemResponse) ToResponse() *ItemResponse {
 	return &ItemResponse{
@@ -104,25 +130,24 @@ func (ir *ItemResponse) ToResponse() *ItemResponse {
 		Photos:      []string(ir.Photos),
 		AuthorID:    ir.AuthorID,
 		Category:    ir.Category,
+		Attributes:  ir.Attributes,
+		Version:     ir.Version,
 		CreatedAt:   ir.CreatedAt,
 		UpdatedAt:   ir.UpdatedAt,
 	}
 }
 
-// Validate validates the struct using go-playground/validator
+// Validate validates the struct using the shared validator
 func (i *Item) Validate() error {
-	validate := validator.New()
 	return validate.Struct(i)
 }
 
 // Validate validates the CreateItemRequest
 func (c *CreateItemRequest) Validate() error {
-	validate := validator.New()
 	return validate.Struct(c)
 }
 
-// Validate validates the UpdateItemRequest
-func (u *UpdateItemRequest) Validate() error {
-	validate := validator.New()
-	return validate.Struct(u)
+// Validate validates the ReplaceItemRequest
+func (r *ReplaceItemRequest) Validate() error {
+	return validate.Struct(r)
 }
@@ -1,34 +1,98 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"time"
-
-	"github.com/go-playground/validator/v10"
 )
 
+// ItemPhoto is a photo of an item. PHash is its perceptual hash, unknown for photos
+// added by URL, and DuplicateOf points at the photo of another author's item it was
+// flagged as a copy of.
 type ItemPhoto struct {
-	ID        int       `json:"id" db:"id" validate:"required,min=1"`
-	ItemID    int       `json:"item_id" db:"item_id" validate:"required,min=1"`
-	URL       string    `json:"url" db:"url" validate:"required,url"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID          int           `json:"id" db:"id" validate:"required,min=1"`
+	ItemID      int           `json:"item_id" db:"item_id" validate:"required,min=1"`
+	URL         string        `json:"url" db:"url" validate:"required,url"`
+	Variants    PhotoVariants `json:"variants" db:"variants"`
+	Position    int           `json:"position" db:"position"`
+	IsCover     bool          `json:"is_cover" db:"is_cover"`
+	PHash       *int64        `json:"-" db:"phash"`
+	DuplicateOf *int          `json:"-" db:"duplicate_of"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// NewItemPhoto is a photo about to be added to an item
+type NewItemPhoto struct {
+	URL         string
+	PHash       *int64
+	DuplicateOf *int
+}
+
+// SimilarPhoto is a stored photo whose perceptual hash is close to another photo's
+type SimilarPhoto struct {
+	ID       int `db:"id"`
+	ItemID   int `db:"item_id"`
+	Distance int `db:"distance"`
+}
+
+// FlaggedPhoto is a photo flagged as a near-duplicate of a photo on another author's item
+type FlaggedPhoto struct {
+	ID                int       `json:"id" db:"id"`
+	ItemID            int       `json:"item_id" db:"item_id"`
+	URL               string    `json:"url" db:"url"`
+	AuthorID          int       `json:"author_id" db:"author_id"`
+	DuplicateOfID     int       `json:"duplicate_of_id" db:"duplicate_of_id"`
+	DuplicateOfItemID int       `json:"duplicate_of_item_id" db:"duplicate_of_item_id"`
+	DuplicateOfURL    string    `json:"duplicate_of_url" db:"duplicate_of_url"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+}
+
+// PhotoVariants maps a variant name (e.g. "thumb") to the URL of the resized photo
+type PhotoVariants map[string]string
+
+// Value implements driver.Valuer
+func (v PhotoVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (v *PhotoVariants) Scan(src interface{}) error {
+	return scanJSON(src, v)
 }
 
+// CreateItemPhotoRequest adds photos to an item by URL. With Import set, the photos
+// are copied into our own storage instead of linked to.
 type CreateItemPhotoRequest struct {
 	ItemID int      `json:"item_id" validate:"required,min=1"`
-	Photos []string `json:"photos" validate:"required,min=1"`
+	Photos []string `json:"photos" validate:"required,min=1,dive,url"`
+	Import bool     `json:"import,omitempty"`
 }
 
 type DeleteItemPhotosRequest struct {
-	PhotoIDs []int `json:"photo_ids" validate:"required,min=1"`
+	PhotoIDs []int `json:"photo_ids" validate:"required,min=1,unique"`
+}
+
+// ReorderItemPhotosRequest lists every photo of an item in the desired display order
+type ReorderItemPhotosRequest struct {
+	PhotoIDs []int `json:"photo_ids" validate:"required,min=1,unique,dive,min=1"`
 }
 
 func (i *ItemPhoto) Validate() error {
-	validate := validator.New()
 	return validate.Struct(i)
 }
 
 func (c *CreateItemPhotoRequest) Validate() error {
-	validate := validator.New()
 	return validate.Struct(c)
 }
+
+func (r *ReorderItemPhotosRequest) Validate() error {
+	return validate.Struct(r)
+}
@@ -73,3 +73,41 @@ func TestCreateItemPhotoRequest_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestReorderItemPhotosRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ReorderItemPhotosRequest
+		wantErr bool
+	}{
+		{
+			name:    "valid order",
+			req:     ReorderItemPhotosRequest{PhotoIDs: []int{3, 1, 2}},
+			wantErr: false,
+		},
+		{
+			name:    "empty order",
+			req:     ReorderItemPhotosRequest{},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate photo",
+			req:     ReorderItemPhotosRequest{PhotoIDs: []int{1, 1}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid photo ID",
+			req:     ReorderItemPhotosRequest{PhotoIDs: []int{1, 0}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReorderItemPhotosRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
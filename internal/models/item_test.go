@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -68,6 +69,11 @@ func TestItem_Validate(t *testing.T) {
 
 func TestCreateItemRequest_Validate(t *testing.T) {
 	categoryID := 1
+	// The configured per-item limit is checked by the item service, not here
+	manyPhotos := make([]string, 12)
+	for i := range manyPhotos {
+		manyPhotos[i] = fmt.Sprintf("https://example.com/bike%d.jpg", i)
+	}
 	tests := []struct {
 		name    string
 		req     CreateItemRequest
@@ -97,6 +103,32 @@ func TestCreateItemRequest_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "photo is not a URL",
+			req: CreateItemRequest{
+				Title:       "Mountain Bike",
+				Description: "High-quality mountain bike perfect for trail riding",
+				Price:       25,
+				Location:    "San Francisco, CA",
+				Photos:      []string{"bike.jpg"},
+				CategoryID:  &categoryID,
+				AuthorID:    1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "more photos than the default limit",
+			req: CreateItemRequest{
+				Title:       "Mountain Bike",
+				Description: "High-quality mountain bike perfect for trail riding",
+				Price:       25,
+				Location:    "San Francisco, CA",
+				Photos:      manyPhotos,
+				CategoryID:  &categoryID,
+				AuthorID:    1,
+			},
+			wantErr: false,
+		},
 		{
 			name: "valid request with tags",
 			req: CreateItemRequest{
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PrivatePhotoRecord records who stored a private photo, so that only they can get
+// new signed URLs for it
+type PrivatePhotoRecord struct {
+	Key       string    `db:"key"`
+	OwnerID   int       `db:"owner_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
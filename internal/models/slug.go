@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxSlugLength limits generated slugs so suffixes still fit the column
+const maxSlugLength = 100
+
+// cyrillicToLatin maps Russian and Kazakh letters to their Latin transliteration
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'ә': "a", 'ғ': "g", 'қ': "q", 'ң': "n", 'ө': "o", 'ұ': "u", 'ү': "u",
+	'һ': "h", 'і': "i",
+}
+
+// Slugify converts a name into a lowercase, URL-safe slug.
+// Cyrillic letters are transliterated and any other characters become single hyphens.
+func Slugify(name string) string {
+	var b strings.Builder
+	pendingHyphen := false
+
+	for _, r := range strings.ToLower(name) {
+		var part string
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			part = string(r)
+		default:
+			latin, ok := cyrillicToLatin[r]
+			if !ok {
+				pendingHyphen = true
+				continue
+			}
+			part = latin
+		}
+
+		if part == "" {
+			continue
+		}
+		if pendingHyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		pendingHyphen = false
+		b.WriteString(part)
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+
+	return slug
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "latin", input: "Electronics", want: "electronics"},
+		{name: "russian", input: "Одежда и обувь", want: "odezhda-i-obuv"},
+		{name: "soft sign dropped", input: "Красота и здоровье", want: "krasota-i-zdorove"},
+		{name: "kazakh letters", input: "Үй және бақ", want: "uy-zhane-baq"},
+		{name: "punctuation collapsed", input: "  Игрушки & хобби!! ", want: "igrushki-hobbi"},
+		{name: "digits kept", input: "Book 2", want: "book-2"},
+		{name: "nothing usable", input: "!!!", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.input); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify_Length(t *testing.T) {
+	got := Slugify(strings.Repeat("ab ", 100))
+	if len(got) > maxSlugLength {
+		t.Errorf("Slugify() length = %d, want at most %d", len(got), maxSlugLength)
+	}
+	if strings.HasSuffix(got, "-") {
+		t.Errorf("Slugify() = %q, want no trailing hyphen", got)
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// User represents a person renting out or renting items. The identity number is
+// personal data and is never sent to clients, nor is whether the user is an administrator.
+type User struct {
+	ID        int       `json:"id" db:"id"`
+	FirstName string    `json:"first_name" db:"first_name"`
+	LastName  string    `json:"last_name" db:"last_name"`
+	Identity  string    `json:"-" db:"identity"`
+	Phone     *string   `json:"phone" db:"phone"`
+	AvatarURL *string   `json:"avatar_url" db:"avatar_url"`
+	Verified  bool      `json:"verified" db:"verified"`
+	IsAdmin   bool      `json:"-" db:"is_admin"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateUserRequest represents a user created by an operator rather than through the
+// API, such as an administrator. Identity is the 12-digit individual identification number.
+type CreateUserRequest struct {
+	FirstName string  `validate:"required,min=1,max=100"`
+	LastName  string  `validate:"required,min=1,max=100"`
+	Identity  string  `validate:"required,len=12,numeric"`
+	Phone     *string `validate:"omitempty,min=1,max=20"`
+	IsAdmin   bool
+}
+
+// ReplaceUserRequest is the editable representation of a user. PUT replaces a user's
+// profile with it, so omitted fields are cleared; PATCH merges a JSON Merge Patch into
+// the user's current profile.
+type ReplaceUserRequest struct {
+	FirstName string  `json:"first_name" validate:"required,min=1,max=100"`
+	LastName  string  `json:"last_name" validate:"required,min=1,max=100"`
+	Phone     *string `json:"phone" validate:"omitempty,min=1,max=20"`
+	AvatarURL *string `json:"avatar_url" validate:"omitempty,url"`
+}
+
+// ReplaceRequest returns the editable representation of the user, the document merge
+// patches are applied to
+func (u *User) ReplaceRequest() *ReplaceUserRequest {
+	return &ReplaceUserRequest{
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Phone:     u.Phone,
+		AvatarURL: u.AvatarURL,
+	}
+}
+
+// Validate validates the CreateUserRequest
+func (r *CreateUserRequest) Validate() error {
+	return validate.Struct(r)
+}
+
+// Validate validates the ReplaceUserRequest
+func (r *ReplaceUserRequest) Validate() error {
+	return validate.Struct(r)
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestReplaceUserRequest_Validate(t *testing.T) {
+	phone := "+77011234567"
+	avatar := "not a url"
+	empty := ""
+
+	tests := []struct {
+		name    string
+		req     ReplaceUserRequest
+		wantErr bool
+	}{
+		{
+			name:    "valid request",
+			req:     ReplaceUserRequest{FirstName: "Aigerim", LastName: "Nurlanova", Phone: &phone},
+			wantErr: false,
+		},
+		{
+			name:    "cleared optional fields",
+			req:     ReplaceUserRequest{FirstName: "Aigerim", LastName: "Nurlanova"},
+			wantErr: false,
+		},
+		{
+			name:    "missing last name",
+			req:     ReplaceUserRequest{FirstName: "Aigerim"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid avatar URL",
+			req:     ReplaceUserRequest{FirstName: "Aigerim", LastName: "Nurlanova", AvatarURL: &avatar},
+			wantErr: true,
+		},
+		{
+			name:    "empty phone",
+			req:     ReplaceUserRequest{FirstName: "Aigerim", LastName: "Nurlanova", Phone: &empty},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReplaceUserRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateUserRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateUserRequest
+		wantErr bool
+	}{
+		{
+			name:    "valid request",
+			req:     CreateUserRequest{FirstName: "Aigerim", LastName: "Nurlanova", Identity: "900101300123", IsAdmin: true},
+			wantErr: false,
+		},
+		{
+			name:    "missing identity",
+			req:     CreateUserRequest{FirstName: "Aigerim", LastName: "Nurlanova"},
+			wantErr: true,
+		},
+		{
+			name:    "short identity",
+			req:     CreateUserRequest{FirstName: "Aigerim", LastName: "Nurlanova", Identity: "90010130012"},
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric identity",
+			req:     CreateUserRequest{FirstName: "Aigerim", LastName: "Nurlanova", Identity: "90010130012a"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateUserRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
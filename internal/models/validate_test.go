@@ -0,0 +1,32 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestValidate_UsesJSONNames(t *testing.T) {
+	req := CreateItemRequest{
+		Title:       "Mountain Bike",
+		Description: "High-quality mountain bike perfect for trail riding",
+		Price:       25,
+		Location:    "San Francisco, CA",
+		Photos:      []string{"https://example.com/a.jpg", "not a url"},
+	}
+
+	var validationErrs validator.ValidationErrors
+	if err := req.Validate(); !errors.As(err, &validationErrs) {
+		t.Fatalf("Validate() error = %v, want validator.ValidationErrors", err)
+	}
+
+	var got []string
+	for _, fe := range validationErrs {
+		got = append(got, fe.Namespace())
+	}
+	want := []string{"CreateItemRequest.photos[1]", "CreateItemRequest.author_id"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Validate() fields = %v, want %v", got, want)
+	}
+}
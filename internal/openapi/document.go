@@ -0,0 +1,242 @@
+package openapi
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version documents are written in
+const Version = "3.0.3"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	generator *generator
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path, keyed by lowercase HTTP method
+type PathItem map[string]*Operation
+
+// Components holds the reusable schemas referenced from operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Operation describes a single API operation
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+// Parameter describes a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the body of a request or response in one content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// NewDocument creates an empty document
+func NewDocument(title string, version string) *Document {
+	g := newGenerator()
+	return &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: title, Version: version},
+		Paths:      make(map[string]*PathItem),
+		Components: Components{Schemas: g.components},
+		generator:  g,
+	}
+}
+
+// Schema returns the schema of the Go value's type. Named struct types are added to
+// the components and referenced.
+func (d *Document) Schema(v interface{}) *Schema {
+	return d.generator.schemaOf(v)
+}
+
+// Name sets the component name used for the Go value's type instead of its type name
+func (d *Document) Name(v interface{}, name string) {
+	d.generator.name(v, name)
+}
+
+// pathParamPattern matches the parameters of a path, e.g. {id}
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Add registers an operation. Parameters in the path are added to the operation, as
+// integers when their name is id or ends in _id and as strings otherwise.
+func (d *Document) Add(method string, path string, op *Operation) {
+	var params []Parameter
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		name := match[1]
+		schema := &Schema{Type: "string"}
+		if name == "id" || strings.HasSuffix(name, "_id") {
+			schema = &Schema{Type: "integer"}
+		}
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	op.Parameters = append(params, op.Parameters...)
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	(*item)[strings.ToLower(method)] = op
+}
+
+// AddDeprecatedAliases describes the operations under prefix again under aliasPrefix,
+// marked as deprecated, for paths kept for older clients
+func (d *Document) AddDeprecatedAliases(prefix string, aliasPrefix string) {
+	for path, item := range d.Paths {
+		rest, ok := strings.CutPrefix(path, prefix+"/")
+		if !ok {
+			continue
+		}
+
+		alias := make(PathItem, len(*item))
+		for method, op := range *item {
+			deprecated := *op
+			deprecated.Deprecated = true
+			deprecated.Description = "Deprecated alias of " + path + "."
+			alias[method] = &deprecated
+		}
+		d.Paths[aliasPrefix+"/"+rest] = &alias
+	}
+}
+
+// Has reports whether the document describes the operation
+func (d *Document) Has(method string, path string) bool {
+	item, ok := d.Paths[path]
+	if !ok {
+		return false
+	}
+	_, ok = (*item)[strings.ToLower(method)]
+	return ok
+}
+
+// NewOperation creates an operation under the tag
+func NewOperation(tag string, summary string) *Operation {
+	return &Operation{
+		Tags:      []string{tag},
+		Summary:   summary,
+		Responses: make(map[string]*Response),
+	}
+}
+
+// Query adds an optional query parameter
+func (o *Operation) Query(name string, typ string, description string) *Operation {
+	o.Parameters = append(o.Parameters, Parameter{
+		Name:        name,
+		In:          "query",
+		Description: description,
+		Schema:      &Schema{Type: typ},
+	})
+	return o
+}
+
+// Header adds an optional request header
+func (o *Operation) Header(name string, description string) *Operation {
+	o.Parameters = append(o.Parameters, Parameter{
+		Name:        name,
+		In:          "header",
+		Description: description,
+		Schema:      &Schema{Type: "string"},
+	})
+	return o
+}
+
+// Body sets a required JSON request body
+func (o *Operation) Body(schema *Schema) *Operation {
+	return o.BodyContent("application/json", schema)
+}
+
+// BodyContent sets a required request body of the given content type
+func (o *Operation) BodyContent(contentType string, schema *Schema) *Operation {
+	o.RequestBody = &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{contentType: {Schema: schema}},
+	}
+	return o
+}
+
+// Multipart sets a required multipart/form-data request body with file fields.
+// A field given as "photos[]" holds several files.
+func (o *Operation) Multipart(fields ...string) *Operation {
+	form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, field := range fields {
+		file := &Schema{Type: "string", Format: "binary"}
+		if name, ok := strings.CutSuffix(field, "[]"); ok {
+			form.Properties[name] = &Schema{Type: "array", Items: file}
+			field = name
+		} else {
+			form.Properties[field] = file
+		}
+		form.Required = append(form.Required, field)
+	}
+
+	o.RequestBody = &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"multipart/form-data": {Schema: form}},
+	}
+	return o
+}
+
+// Returns adds a response. A nil schema describes a response without a body.
+func (o *Operation) Returns(status int, description string, schema *Schema) *Operation {
+	return o.ReturnsContent(status, description, "application/json", schema)
+}
+
+// ReturnsContent adds a response with a body of the given content type
+func (o *Operation) ReturnsContent(status int, description string, contentType string, schema *Schema) *Operation {
+	response := &Response{Description: description}
+	if schema != nil {
+		response.Content = map[string]MediaType{contentType: {Schema: schema}}
+	}
+	o.Responses[strconv.Itoa(status)] = response
+	return o
+}
+
+// Default sets the response for every status not listed, e.g. errors
+func (o *Operation) Default(description string, schema *Schema) *Operation {
+	o.Responses["default"] = &Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+	return o
+}
@@ -0,0 +1,51 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed docs.html
+var docsPage string
+
+// swaggerUI holds the vendored swagger-ui 5.18.2 files the documentation page loads,
+// so the page works offline and under a strict Content-Security-Policy
+//
+//go:embed swagger-ui/swagger-ui.css swagger-ui/swagger-ui-bundle.js
+var swaggerUI embed.FS
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsPage))
+
+// Handler serves the document as JSON
+func Handler(d *Document) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(d)
+	})
+}
+
+// DocsHandler serves an interactive documentation page for the document at specURL,
+// loading its scripts and styles from assetsURL, where AssetsHandler serves them
+func DocsHandler(title string, specURL string, assetsURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		docsTemplate.Execute(w, struct {
+			Title     string
+			SpecURL   string
+			AssetsURL string
+		}{title, specURL, assetsURL})
+	})
+}
+
+// AssetsHandler serves the scripts and styles of the documentation page; it expects
+// the path to be stripped down to the file name
+func AssetsHandler() http.Handler {
+	files, err := fs.Sub(swaggerUI, "swagger-ui")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
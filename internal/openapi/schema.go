@@ -0,0 +1,246 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+}
+
+// Object creates an inline object schema, for responses that are not described by a model
+func Object(properties map[string]*Schema) *Schema {
+	return &Schema{Type: "object", Properties: properties}
+}
+
+// ArrayOf creates an array schema
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// Primitive creates a schema of a primitive type, e.g. "string" or "integer"
+func Primitive(typ string) *Schema {
+	return &Schema{Type: typ}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// generator derives schemas from Go types the way encoding/json marshals them and
+// reads constraints from their validate tags
+type generator struct {
+	names      map[reflect.Type]string
+	components map[string]*Schema
+}
+
+func newGenerator() *generator {
+	return &generator{
+		names:      make(map[reflect.Type]string),
+		components: make(map[string]*Schema),
+	}
+}
+
+func (g *generator) name(v interface{}, name string) {
+	g.names[reflect.TypeOf(v)] = name
+}
+
+func (g *generator) schemaOf(v interface{}) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json sends byte slices base64 encoded
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	}
+	// Interfaces may hold any JSON value
+	return &Schema{}
+}
+
+// ref adds a named struct to the components and returns a reference to it
+func (g *generator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		g.names[t] = name
+	}
+
+	if _, ok := g.components[name]; !ok {
+		// Reserve the name first so recursive types, e.g. category trees, terminate
+		g.components[name] = &Schema{}
+		*g.components[name] = *g.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// object describes a struct's exported fields under their JSON names
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(t, s)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Embedded structs without a JSON name are flattened, as encoding/json does
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.fields(embedded, s)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := g.schema(field.Type)
+		if applyRules(fieldSchema, field.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fieldSchema
+	}
+}
+
+// applyRules adds the constraints of a validate tag to a schema and reports whether
+// the field is required. Rules after "dive" constrain the items of an array.
+func applyRules(s *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	rules, itemRules, _ := strings.Cut(tag, ",dive")
+	itemRules = strings.TrimPrefix(itemRules, ",")
+	if itemRules != "" && s.Items != nil && s.Items.Ref == "" {
+		applyRules(s.Items, itemRules)
+	}
+
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "gte":
+			setBound(s, param, true, false)
+		case "max", "lte":
+			setBound(s, param, false, false)
+		case "gt":
+			setBound(s, param, true, true)
+		case "lt":
+			setBound(s, param, false, true)
+		case "len":
+			setBound(s, param, true, false)
+			setBound(s, param, false, false)
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "url":
+			s.Format = "uri"
+		case "email":
+			s.Format = "email"
+		case "unique":
+			s.UniqueItems = true
+		}
+	}
+	return required
+}
+
+// setBound sets a lower or upper bound, which applies to the length of strings, the
+// number of array items and the value of numbers
+func setBound(s *Schema, param string, lower bool, exclusive bool) {
+	switch s.Type {
+	case "string", "array":
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return
+		}
+		switch {
+		case s.Type == "string" && lower:
+			s.MinLength = &n
+		case s.Type == "string":
+			s.MaxLength = &n
+		case lower:
+			s.MinItems = &n
+		default:
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		if lower {
+			s.Minimum, s.ExclusiveMinimum = &n, exclusive
+		} else {
+			s.Maximum, s.ExclusiveMaximum = &n, exclusive
+		}
+	}
+}
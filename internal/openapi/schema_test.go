@@ -0,0 +1,106 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testTag struct {
+	Label string `json:"label"`
+}
+
+type testNode struct {
+	ID       int         `json:"id"`
+	Children []*testNode `json:"children,omitempty"`
+}
+
+type testBase struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testRequest struct {
+	testBase
+	Title    string                 `json:"title" validate:"required,min=1,max=200"`
+	Price    int                    `json:"price" validate:"required,min=0"`
+	Rating   *float64               `json:"rating,omitempty" validate:"omitempty,gt=0,lte=5"`
+	Photos   []string               `json:"photos" validate:"omitempty,min=1,max=10,dive,url"`
+	IDs      []int                  `json:"ids" validate:"required,unique,dive,min=1"`
+	State    string                 `json:"state" validate:"oneof=new used"`
+	Tags     []testTag              `json:"tags"`
+	Extra    map[string]interface{} `json:"extra"`
+	Internal string                 `json:"-"`
+	NoTag    bool
+	private  string
+}
+
+func intPtr(n int) *int             { return &n }
+func floatPtr(n float64) *float64   { return &n }
+func ref(name string) *Schema       { return &Schema{Ref: "#/components/schemas/" + name} }
+func arrayOf(items *Schema) *Schema { return &Schema{Type: "array", Items: items} }
+
+func TestDocument_Schema(t *testing.T) {
+	d := NewDocument("test", "1")
+
+	if got := d.Schema(testRequest{}); !reflect.DeepEqual(got, ref("testRequest")) {
+		t.Fatalf("Schema() = %+v, want a reference", got)
+	}
+
+	want := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"created_at": {Type: "string", Format: "date-time"},
+			"title":      {Type: "string", MinLength: intPtr(1), MaxLength: intPtr(200)},
+			"price":      {Type: "integer", Format: "int32", Minimum: floatPtr(0)},
+			"rating": {Type: "number", Format: "double", Nullable: true,
+				Minimum: floatPtr(0), ExclusiveMinimum: true, Maximum: floatPtr(5)},
+			"photos": {Type: "array", MinItems: intPtr(1), MaxItems: intPtr(10),
+				Items: &Schema{Type: "string", Format: "uri"}},
+			"ids": {Type: "array", UniqueItems: true,
+				Items: &Schema{Type: "integer", Format: "int32", Minimum: floatPtr(1)}},
+			"state": {Type: "string", Enum: []string{"new", "used"}},
+			"tags":  arrayOf(ref("testTag")),
+			"extra": {Type: "object", AdditionalProperties: &Schema{}},
+			"NoTag": {Type: "boolean"},
+		},
+		Required: []string{"title", "price", "ids"},
+	}
+	if got := d.Components.Schemas["testRequest"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("testRequest schema = %+v, want %+v", got, want)
+	}
+	if _, ok := d.Components.Schemas["testTag"]; !ok {
+		t.Errorf("nested struct testTag was not added to the components")
+	}
+}
+
+func TestDocument_SchemaRecursiveAndRenamed(t *testing.T) {
+	d := NewDocument("test", "1")
+	d.Name(testNode{}, "Node")
+
+	if got := d.Schema([]testNode{}); !reflect.DeepEqual(got, arrayOf(ref("Node"))) {
+		t.Fatalf("Schema() = %+v, want an array of Node", got)
+	}
+	children := d.Components.Schemas["Node"].Properties["children"]
+	if !reflect.DeepEqual(children, arrayOf(ref("Node"))) {
+		t.Errorf("children = %+v, want an array referencing Node", children)
+	}
+}
+
+func TestDocument_Add(t *testing.T) {
+	d := NewDocument("test", "1")
+	d.Add("PUT", "/items/{item_id}/photos/{slug}", NewOperation("items", "Update").Query("lang", "string", ""))
+
+	if !d.Has("PUT", "/items/{item_id}/photos/{slug}") || d.Has("GET", "/items/{item_id}/photos/{slug}") {
+		t.Fatalf("Has() does not match the added operation")
+	}
+
+	params := (*d.Paths["/items/{item_id}/photos/{slug}"])["put"].Parameters
+	want := []Parameter{
+		{Name: "item_id", In: "path", Required: true, Schema: &Schema{Type: "integer"}},
+		{Name: "slug", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "lang", In: "query", Schema: &Schema{Type: "string"}},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("parameters = %+v, want %+v", params, want)
+	}
+}
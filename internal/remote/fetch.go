@@ -0,0 +1,190 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrUnsupportedScheme is returned for URLs that are not http or https
+	ErrUnsupportedScheme = errors.New("only http and https URLs can be fetched")
+	// ErrBlockedAddress is returned when a host resolves to a private or otherwise non-public address
+	ErrBlockedAddress = errors.New("address is not publicly routable")
+	// ErrTooManyRedirects is returned when a fetch follows more redirects than allowed
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrTooLarge is returned when the response body exceeds the size limit
+	ErrTooLarge = errors.New("response is too large")
+	// ErrUnexpectedStatus is returned for responses other than 200 OK
+	ErrUnexpectedStatus = errors.New("unexpected response status")
+)
+
+// blockedPrefixes are address ranges that must never be reached from the server:
+// loopback, private, link-local (including cloud metadata), CGNAT, multicast,
+// documentation and reserved ranges
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// IsPublicAddr reports whether the address is publicly routable
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// FetcherOptions limits remote fetches
+type FetcherOptions struct {
+	// Timeout bounds the whole fetch, including redirects and reading the body
+	Timeout time.Duration
+	// MaxBytes is the largest response body accepted
+	MaxBytes int64
+	// MaxRedirects is the number of redirects followed before giving up
+	MaxRedirects int
+}
+
+// Fetcher downloads remote files on behalf of users without letting them reach
+// internal services. Addresses are checked after DNS resolution, when the
+// connection is made, so a hostname cannot be re-pointed between check and use.
+type Fetcher struct {
+	client    *http.Client
+	maxBytes  int64
+	allowAddr func(netip.Addr) bool
+}
+
+// NewFetcher creates a fetcher that only connects to public addresses
+func NewFetcher(opts FetcherOptions) *Fetcher {
+	return newFetcher(opts, IsPublicAddr)
+}
+
+func newFetcher(opts FetcherOptions, allowAddr func(netip.Addr) bool) *Fetcher {
+	f := &Fetcher{
+		maxBytes:  opts.MaxBytes,
+		allowAddr: allowAddr,
+	}
+
+	dialer := &net.Dialer{
+		Timeout: opts.Timeout,
+		Control: f.checkAddress,
+	}
+
+	f.client = &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &http.Transport{
+			// Never go through a proxy: it would make the connection on our behalf unchecked
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   opts.Timeout,
+			ResponseHeaderTimeout: opts.Timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			return checkScheme(req.URL)
+		},
+	}
+
+	return f
+}
+
+// Fetch downloads the body of the URL
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScheme(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+	if resp.ContentLength > f.maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > f.maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	return data, nil
+}
+
+// checkAddress runs before every connection with the resolved address
+func (f *Fetcher) checkAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !f.allowAddr(addr) {
+		return fmt.Errorf("%s: %w", addr, ErrBlockedAddress)
+	}
+
+	return nil
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrUnsupportedScheme
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testOptions = FetcherOptions{
+	Timeout:      5 * time.Second,
+	MaxBytes:     1024,
+	MaxRedirects: 2,
+}
+
+// newLoopbackFetcher allows loopback so tests can talk to httptest servers
+func newLoopbackFetcher() *Fetcher {
+	return newFetcher(testOptions, func(addr netip.Addr) bool {
+		return addr.IsLoopback()
+	})
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "8.8.8.8", want: true},
+		{addr: "2a00:1450:4001:80e::200e", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.20.0.5", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "100.64.0.1", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "::1", want: false},
+		{addr: "fd00::1", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestFetcher_BlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer server.Close()
+
+	_, err := NewFetcher(testOptions).Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Fetch() error = %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/photo.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("jpeg bytes"))
+	})
+	mux.HandleFunc("/large.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	})
+	mux.HandleFunc("/missing.jpg", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/to-file", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := newLoopbackFetcher()
+
+	data, err := fetcher.Fetch(context.Background(), server.URL+"/photo.jpg")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(data) != "jpeg bytes" {
+		t.Errorf("Fetch() = %q, want %q", data, "jpeg bytes")
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "too large", url: server.URL + "/large.jpg", wantErr: ErrTooLarge},
+		{name: "not found", url: server.URL + "/missing.jpg", wantErr: ErrUnexpectedStatus},
+		{name: "redirect loop", url: server.URL + "/loop", wantErr: ErrTooManyRedirects},
+		{name: "redirect to file", url: server.URL + "/to-file", wantErr: ErrUnsupportedScheme},
+		{name: "file scheme", url: "file:///etc/passwd", wantErr: ErrUnsupportedScheme},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := fetcher.Fetch(context.Background(), tt.url); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Fetch() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
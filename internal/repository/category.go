@@ -1,14 +1,37 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"shary_be/internal/models"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// ErrSlugExists is returned by Create and Update when another category already has the
+// slug. The slug is unique in the database, so this also catches a category that took
+// it after the caller checked SlugExists.
+var ErrSlugExists = errors.New("category slug already exists")
+
+// slugConstraint is the unique constraint on categories.slug
+const slugConstraint = "categories_slug_key"
+
+// subtreeItemsCountColumn counts the items in category c and all of its subcategories,
+// as GetAll's counts add up to once aggregated by the service
+const subtreeItemsCountColumn = `(
+			WITH RECURSIVE subtree AS (
+				SELECT c.id
+				UNION
+				SELECT child.id FROM categories child
+				INNER JOIN subtree s ON child.parent_id = s.id
+			)
+			SELECT COUNT(*) FROM items i WHERE i.category_id IN (SELECT id FROM subtree)
+		) AS items_count`
+
 type CategoryRepository struct {
 	db *sqlx.DB
 }
@@ -17,12 +40,27 @@ func NewCategoryRepository(db *sqlx.DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
-// GetAll retrieves all categories
-func (r *CategoryRepository) GetAll() ([]models.Category, error) {
+// GetAll retrieves all categories with names in the given language,
+// falling back to the default name when no translation exists.
+// ItemsCount holds the number of items assigned directly to each category.
+func (r *CategoryRepository) GetAll(ctx context.Context, lang string) ([]models.Category, error) {
 	var categories []models.Category
-	query := `SELECT * FROM categories ORDER BY name ASC`
+	query := `
+		SELECT
+			c.id, COALESCE(t.name, c.name) AS name, c.slug, c.parent_id, c.attribute_schema,
+			COALESCE(ic.items_count, 0) AS items_count,
+			c.version, c.created_at, c.updated_at
+		FROM categories c
+		LEFT JOIN category_translations t ON t.category_id = c.id AND t.lang = $1
+		LEFT JOIN (
+			SELECT category_id, COUNT(*) AS items_count
+			FROM items
+			WHERE category_id IS NOT NULL
+			GROUP BY category_id
+		) ic ON ic.category_id = c.id
+		ORDER BY name ASC`
 
-	err := r.db.Select(&categories, query)
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &categories, query, lang)
 	if err != nil {
 		return nil, err
 	}
@@ -30,47 +68,67 @@ func (r *CategoryRepository) GetAll() ([]models.Category, error) {
 	return categories, nil
 }
 
-// Create adds a new category
-func (r *CategoryRepository) Create(category *models.Category) error {
+// Create adds a new category and sets the ID, version and timestamps the database gave
+// it. It returns ErrSlugExists if the slug is taken; a transaction ctx carries can go on
+// after that.
+func (r *CategoryRepository) Create(ctx context.Context, category *models.Category) error {
 	query := `
-		INSERT INTO categories (name)
-		VALUES ($1)
-		RETURNING id`
+		INSERT INTO categories (name, slug, parent_id, attribute_schema)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, version, created_at, updated_at`
 
-	now := time.Now()
-	category.CreatedAt = now
-	category.UpdatedAt = now
-
-	return r.db.QueryRow(
-		query,
-		category.Name,
-	).Scan(&category.ID)
+	err := withSavepoint(ctx, r.db, func() error {
+		return querierFrom(ctx, r.db).QueryRowContext(
+			ctx,
+			query,
+			category.Name,
+			category.Slug,
+			category.ParentID,
+			category.AttributeSchema,
+		).Scan(&category.ID, &category.Version, &category.CreatedAt, &category.UpdatedAt)
+	})
+	if isUniqueViolation(err, slugConstraint) {
+		return ErrSlugExists
+	}
+	return err
 }
 
-// Update updates an existing category
-func (r *CategoryRepository) Update(category *models.Category) error {
+// Update updates an existing category and sets its new version.
+// It returns sql.ErrNoRows if the category does not exist and ErrSlugExists if the
+// slug is taken; a transaction ctx carries can go on after either.
+func (r *CategoryRepository) Update(ctx context.Context, category *models.Category) error {
 	query := `
 		UPDATE categories
-		SET name = $1, updated_at = $2
-		WHERE id = $3
-		RETURNING id`
+		SET name = $1, slug = $2, parent_id = $3, attribute_schema = $4, updated_at = $5
+		WHERE id = $6
+		RETURNING version`
 
 	now := time.Now()
 	category.UpdatedAt = now
 
-	return r.db.QueryRow(
-		query,
-		category.Name,
-		category.UpdatedAt,
-		category.ID,
-	).Scan(&category.ID)
+	err := withSavepoint(ctx, r.db, func() error {
+		return querierFrom(ctx, r.db).QueryRowContext(
+			ctx,
+			query,
+			category.Name,
+			category.Slug,
+			category.ParentID,
+			category.AttributeSchema,
+			category.UpdatedAt,
+			category.ID,
+		).Scan(&category.Version)
+	})
+	if isUniqueViolation(err, slugConstraint) {
+		return ErrSlugExists
+	}
+	return err
 }
 
 // Delete deletes a category by ID
-func (r *CategoryRepository) Delete(id int) error {
+func (r *CategoryRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM categories WHERE id = $1`
 
-	result, err := r.db.Exec(query, id)
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -88,11 +146,61 @@ func (r *CategoryRepository) Delete(id int) error {
 }
 
 // GetByID retrieves a category by ID
-func (r *CategoryRepository) GetByID(id int) (*models.Category, error) {
+func (r *CategoryRepository) GetByID(ctx context.Context, id int) (*models.Category, error) {
 	var category models.Category
 	query := `SELECT * FROM categories WHERE id = $1`
 
-	err := r.db.Get(&category, query, id)
+	err := querierFrom(ctx, r.db).GetContext(ctx, &category, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// GetLocalizedByID retrieves a category by ID with its name in the given language,
+// falling back to the default name when no translation exists. ItemsCount holds the
+// number of items in the category and its subcategories.
+func (r *CategoryRepository) GetLocalizedByID(ctx context.Context, id int, lang string) (*models.Category, error) {
+	var category models.Category
+	query := `
+		SELECT
+			c.id, COALESCE(t.name, c.name) AS name, c.slug, c.parent_id, c.attribute_schema,
+			` + subtreeItemsCountColumn + `,
+			c.version, c.created_at, c.updated_at
+		FROM categories c
+		LEFT JOIN category_translations t ON t.category_id = c.id AND t.lang = $2
+		WHERE c.id = $1`
+
+	err := querierFrom(ctx, r.db).GetContext(ctx, &category, query, id, lang)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// GetLocalizedBySlug retrieves a category by slug with its name in the given language,
+// falling back to the default name when no translation exists. ItemsCount holds the
+// number of items in the category and its subcategories.
+func (r *CategoryRepository) GetLocalizedBySlug(ctx context.Context, slug string, lang string) (*models.Category, error) {
+	var category models.Category
+	query := `
+		SELECT
+			c.id, COALESCE(t.name, c.name) AS name, c.slug, c.parent_id, c.attribute_schema,
+			` + subtreeItemsCountColumn + `,
+			c.version, c.created_at, c.updated_at
+		FROM categories c
+		LEFT JOIN category_translations t ON t.category_id = c.id AND t.lang = $2
+		WHERE c.slug = $1`
+
+	err := querierFrom(ctx, r.db).GetContext(ctx, &category, query, slug, lang)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -102,3 +210,170 @@ func (r *CategoryRepository) GetByID(id int) (*models.Category, error) {
 
 	return &category, nil
 }
+
+// SlugExists checks whether a slug is used by any category other than excludeID
+func (r *CategoryRepository) SlugExists(ctx context.Context, slug string, excludeID int) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM categories WHERE slug = $1 AND id <> $2)`
+
+	err := querierFrom(ctx, r.db).GetContext(ctx, &exists, query, slug, excludeID)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// GetTranslations retrieves all translations of a category
+func (r *CategoryRepository) GetTranslations(ctx context.Context, categoryID int) ([]models.CategoryTranslation, error) {
+	var translations []models.CategoryTranslation
+	query := `SELECT * FROM category_translations WHERE category_id = $1 ORDER BY lang ASC`
+
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &translations, query, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// UpsertTranslation creates or replaces the translation of a category for a language
+func (r *CategoryRepository) UpsertTranslation(ctx context.Context, translation *models.CategoryTranslation) error {
+	query := `
+		INSERT INTO category_translations (category_id, lang, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (category_id, lang)
+		DO UPDATE SET name = EXCLUDED.name, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at`
+
+	return querierFrom(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		translation.CategoryID,
+		translation.Lang,
+		translation.Name,
+		time.Now(),
+	).Scan(&translation.CreatedAt, &translation.UpdatedAt)
+}
+
+// DeleteTranslation deletes the translation of a category for a language
+func (r *CategoryRepository) DeleteTranslation(ctx context.Context, categoryID int, lang string) error {
+	query := `DELETE FROM category_translations WHERE category_id = $1 AND lang = $2`
+
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, categoryID, lang)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetDescendantIDs retrieves the IDs of a category and all of its subcategories
+func (r *CategoryRepository) GetDescendantIDs(ctx context.Context, id int) ([]int, error) {
+	var ids []int
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id FROM categories c
+			INNER JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT id FROM subtree`
+
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &ids, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// LockByIDs locks the given categories for the rest of the transaction.
+// Rows are locked in ID order so concurrent callers cannot deadlock, and the
+// lock blocks new items from referencing the categories until the transaction ends.
+func (r *CategoryRepository) LockByIDs(ctx context.Context, ids []int) ([]models.Category, error) {
+	var categories []models.Category
+	query := `SELECT * FROM categories WHERE id = ANY($1) ORDER BY id FOR UPDATE`
+
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &categories, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// CountItems counts items assigned directly to a category
+func (r *CategoryRepository) CountItems(ctx context.Context, categoryID int) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM items WHERE category_id = $1`
+
+	err := querierFrom(ctx, r.db).GetContext(ctx, &count, query, categoryID)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// LockItemAttributes locks the items assigned directly to a category for the rest of
+// the transaction and returns their attributes by item ID
+func (r *CategoryRepository) LockItemAttributes(ctx context.Context, categoryID int) (map[int]models.ItemAttributes, error) {
+	var rows []struct {
+		ID         int                   `db:"id"`
+		Attributes models.ItemAttributes `db:"attributes"`
+	}
+	query := `SELECT id, attributes FROM items WHERE category_id = $1 ORDER BY id FOR UPDATE`
+
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &rows, query, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := make(map[int]models.ItemAttributes, len(rows))
+	for _, row := range rows {
+		attributes[row.ID] = row.Attributes
+	}
+	return attributes, nil
+}
+
+// ReassignItems moves all items from one category to another
+func (r *CategoryRepository) ReassignItems(ctx context.Context, fromID, toID int) (int, error) {
+	query := `
+		UPDATE items
+		SET category_id = $1, updated_at = $2
+		WHERE category_id = $3`
+
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, toID, time.Now(), fromID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// ReparentChildren moves the direct subcategories of a category under a new parent
+// (nil makes them root categories)
+func (r *CategoryRepository) ReparentChildren(ctx context.Context, fromID int, toParentID *int) error {
+	query := `
+		UPDATE categories
+		SET parent_id = $1, updated_at = $2
+		WHERE parent_id = $3`
+
+	_, err := querierFrom(ctx, r.db).ExecContext(ctx, query, toParentID, time.Now(), fromID)
+	return err
+}
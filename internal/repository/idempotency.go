@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"shary_be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyRepository handles database operations for idempotency keys
+type IdempotencyRepository struct {
+	db *sqlx.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency key repository
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve claims a key for a request. It returns nil when the key was free, or had
+// been created before expiredBefore, and is now held by this request; otherwise it
+// returns the record of the request that holds it.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, scope, key, requestHash string, expiredBefore time.Time) (*models.IdempotencyRecord, error) {
+	var holder *models.IdempotencyRecord
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		tx := querierFrom(ctx, r.db)
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND created_at < $3`,
+			scope, key, expiredBefore); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO idempotency_keys (scope, key, request_hash, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (scope, key) DO NOTHING`,
+			scope, key, requestHash, time.Now())
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 1 {
+			return nil
+		}
+
+		var record models.IdempotencyRecord
+		query := `SELECT * FROM idempotency_keys WHERE scope = $1 AND key = $2`
+		if err := tx.GetContext(ctx, &record, query, scope, key); err != nil {
+			return err
+		}
+		holder = &record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return holder, nil
+}
+
+// Complete stores the response to the request holding a key
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, statusCode int, headers models.ResponseHeader, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $1, headers = $2, body = $3
+		WHERE scope = $4 AND key = $5`
+
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, statusCode, headers, body, scope, key)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Release frees a key whose request produced no response worth replaying, so that a
+// retry is processed again
+func (r *IdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status_code IS NULL`
+
+	_, err := querierFrom(ctx, r.db).ExecContext(ctx, query, scope, key)
+	return err
+}
+
+// DeleteExpired removes the keys created before the given time and returns how many
+// were removed
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -11,6 +12,20 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// categorySubtreeQuery selects the IDs of a category and all of its descendants.
+// The placeholder is left unnumbered so it can be used with Rebind.
+const categorySubtreeQuery = `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION
+		SELECT c.id FROM categories c
+		INNER JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT id FROM subtree`
+
+// itemPhotoURLsColumn selects the photo URLs of item i in display order, cover first
+const itemPhotoURLsColumn = `ARRAY(SELECT p.url FROM item_photos p WHERE p.item_id = i.id ORDER BY p.is_cover DESC, p.position, p.id) AS photos`
+
 // ItemRepository handles database operations for items
 type ItemRepository struct {
 	db *sqlx.DB
@@ -21,79 +36,89 @@ func NewItemRepository(db *sqlx.DB) *ItemRepository {
 	return &ItemRepository{db: db}
 }
 
-// Create creates a new item in the database
-func (r *ItemRepository) Create(item *models.Item, photos []string) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	itemQuery := `
-		INSERT INTO items (title, description, price, location, has_photos, author_id, category_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id`
-
-	now := time.Now()
-	item.CreatedAt = now
-	item.UpdatedAt = now
-	item.HasPhotos = len(photos) > 0
-
-	err = tx.QueryRow(
-		itemQuery,
-		item.Title,
-		item.Description,
-		item.Price,
-		item.Location,
-		item.HasPhotos,
-		item.AuthorID,
-		item.CategoryID,
-		item.CreatedAt,
-		item.UpdatedAt,
-	).Scan(&item.ID)
+// Create creates a new item in the database, together with its photos, and sets the
+// ID, version and timestamps the database gave it
+func (r *ItemRepository) Create(ctx context.Context, item *models.Item, photos []string) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		tx := querierFrom(ctx, r.db)
+
+		itemQuery := `
+			INSERT INTO items (title, description, price, location, has_photos, author_id, category_id, attributes, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id, version, created_at, updated_at`
+
+		now := time.Now()
+		item.CreatedAt = now
+		item.UpdatedAt = now
+		item.HasPhotos = len(photos) > 0
+
+		err := tx.QueryRowContext(
+			ctx,
+			itemQuery,
+			item.Title,
+			item.Description,
+			item.Price,
+			item.Location,
+			item.HasPhotos,
+			item.AuthorID,
+			item.CategoryID,
+			item.Attributes,
+			item.CreatedAt,
+			item.UpdatedAt,
+		).Scan(&item.ID, &item.Version, &item.CreatedAt, &item.UpdatedAt)
+
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		return err
-	}
+		if item.HasPhotos {
+			photoQuery := `
+				INSERT INTO item_photos (item_id, url, position, is_cover, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6)`
+
+			// Photos keep the order they were given in, the first one is the cover
+			for i, photoURL := range photos {
+				_, err := tx.ExecContext(
+					ctx,
+					photoQuery,
+					item.ID,
+					photoURL,
+					i,
+					i == 0,
+					now,
+					now,
+				)
+				if err != nil {
+					return err
+				}
+			}
 
-	if item.HasPhotos {
-		photoQuery := `
-			INSERT INTO item_photos (item_id, url, created_at, updated_at)
-			VALUES ($1, $2, $3, $4)`
-
-		for _, photoURL := range photos {
-			_, err := tx.Exec(
-				photoQuery,
-				item.ID,
-				photoURL,
-				now,
-				now,
-			)
-			if err != nil {
+			// Each photo made a new version of the item
+			versionQuery := `SELECT version, updated_at FROM items WHERE id = $1`
+			if err := tx.QueryRowContext(ctx, versionQuery, item.ID).Scan(&item.Version, &item.UpdatedAt); err != nil {
 				return err
 			}
 		}
-	}
-	return tx.Commit()
+		return nil
+	})
 }
 
-// GetByID retrieves an item by ID
-func (r *ItemRepository) GetByID(id int) (*models.ItemResponse, error) {
+// GetByID retrieves an item by ID with the category name in the given language
+func (r *ItemRepository) GetByID(ctx context.Context, id int, lang string) (*models.ItemResponse, error) {
 	var item models.ItemResponse
 	query := `
 		SELECT 
 			i.id, i.title, i.description, i.price, i.location, i.has_photos,
 			i.author_id, 
-			c.id AS "category.id", c.name AS "category.name",
-			i.created_at, i.updated_at,
-			COALESCE(array_agg(p.url) FILTER (WHERE p.url IS NOT NULL), '{}') AS photos
+			c.id AS "category.id", COALESCE(ct.name, c.name) AS "category.name", c.version AS "category.version",
+			i.attributes, i.version, i.created_at, i.updated_at,
+			` + itemPhotoURLsColumn + `
 		FROM items i
 		LEFT JOIN categories c ON i.category_id = c.id
-		LEFT JOIN item_photos p ON i.id = p.item_id
+		LEFT JOIN category_translations ct ON ct.category_id = c.id AND ct.lang = $2
 		WHERE i.id = $1
-		GROUP BY i.id, c.id, c.name
 	`
-	err := r.db.Get(&item, query, id)
+	err := querierFrom(ctx, r.db).GetContext(ctx, &item, query, id, lang)
 	if err != nil {
 		return nil, err
 	}
@@ -101,7 +126,7 @@ func (r *ItemRepository) GetByID(id int) (*models.ItemResponse, error) {
 }
 
 // GetAll retrieves all items with optional filtering
-func (r *ItemRepository) GetAll(filter *models.ItemFilter) ([]models.ItemResponse, error) {
+func (r *ItemRepository) GetAll(ctx context.Context, filter *models.ItemFilter) ([]models.ItemResponse, error) {
 	var items []models.ItemResponse
 
 	var queryBuilder strings.Builder
@@ -109,16 +134,25 @@ func (r *ItemRepository) GetAll(filter *models.ItemFilter) ([]models.ItemRespons
 	// Build dynamic query with filters
 	queryBuilder.WriteString(`
         SELECT
-            i.id, i.title, i.description, i.price, i.location, i.has_photos, i.author_id, i.created_at, i.updated_at,
+            i.id, i.title, i.description, i.price, i.location, i.has_photos, i.author_id, i.attributes, i.version, i.created_at, i.updated_at,
             c.id AS "category.id",
-            c.name AS "category.name"
+            COALESCE(ct.name, c.name) AS "category.name",
+            c.version AS "category.version",
+            ` + itemPhotoURLsColumn + `
         FROM items i
         LEFT JOIN categories c ON i.category_id = c.id
+        LEFT JOIN category_translations ct ON ct.category_id = c.id AND ct.lang = ?
         WHERE 1=1
     `)
 
 	var args []interface{}
 
+	lang := ""
+	if filter != nil {
+		lang = filter.Lang
+	}
+	args = append(args, lang)
+
 	// Add filters
 	if filter != nil {
 		if filter.MinPrice != nil {
@@ -139,9 +173,22 @@ func (r *ItemRepository) GetAll(filter *models.ItemFilter) ([]models.ItemRespons
 			args = append(args, searchTerm, searchTerm)
 		}
 		if filter.CategoryID != nil {
-			queryBuilder.WriteString(" AND i.category_id = ?")
+			queryBuilder.WriteString(" AND i.category_id IN (" + categorySubtreeQuery + ")")
 			args = append(args, *filter.CategoryID)
 		}
+		for _, attr := range filter.Attributes {
+			switch attr.Op {
+			case models.AttributeFilterMin:
+				queryBuilder.WriteString(" AND jsonb_typeof(i.attributes -> ?) = 'number' AND (i.attributes ->> ?)::numeric >= ?::numeric")
+				args = append(args, attr.Key, attr.Key, attr.Value)
+			case models.AttributeFilterMax:
+				queryBuilder.WriteString(" AND jsonb_typeof(i.attributes -> ?) = 'number' AND (i.attributes ->> ?)::numeric <= ?::numeric")
+				args = append(args, attr.Key, attr.Key, attr.Value)
+			default:
+				queryBuilder.WriteString(" AND i.attributes ->> ? = ?")
+				args = append(args, attr.Key, attr.Value)
+			}
+		}
 	}
 
 	// Add ordering
@@ -161,7 +208,7 @@ func (r *ItemRepository) GetAll(filter *models.ItemFilter) ([]models.ItemRespons
 
 	query := r.db.Rebind(queryBuilder.String())
 
-	err := r.db.Select(&items, query, args...)
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &items, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all items with filter: %w", err)
 	}
@@ -169,22 +216,58 @@ func (r *ItemRepository) GetAll(filter *models.ItemFilter) ([]models.ItemRespons
 	return items, nil
 }
 
+// Exists checks whether an item with the given ID exists
+func (r *ItemRepository) Exists(ctx context.Context, id int) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM items WHERE id = $1)`
+
+	err := querierFrom(ctx, r.db).GetContext(ctx, &exists, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// GetAuthorID returns the author of an item, or sql.ErrNoRows if it does not exist
+func (r *ItemRepository) GetAuthorID(ctx context.Context, id int) (int, error) {
+	var authorID int
+	query := `SELECT author_id FROM items WHERE id = $1`
+
+	err := querierFrom(ctx, r.db).GetContext(ctx, &authorID, query, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return authorID, nil
+}
+
+// LockByID locks an item row for the rest of the transaction, serializing concurrent
+// changes to its photos. It returns sql.ErrNoRows if the item does not exist.
+func (r *ItemRepository) LockByID(ctx context.Context, id int) error {
+	var lockedID int
+	query := `SELECT id FROM items WHERE id = $1 FOR UPDATE`
+
+	return querierFrom(ctx, r.db).GetContext(ctx, &lockedID, query, id)
+}
+
 // Update updates an item in the database
-func (r *ItemRepository) Update(tx *sqlx.Tx, item *models.ItemToUpdate) error {
+func (r *ItemRepository) Update(ctx context.Context, item *models.ItemToUpdate) error {
 	query := `
 		UPDATE items 
-		SET title = $1, description = $2, price = $3, location = $4, has_photos = $5, category_id = $6, updated_at = $7
-		WHERE id = $8`
+		SET title = $1, description = $2, price = $3, location = $4, has_photos = $5, category_id = $6, attributes = $7, updated_at = $8
+		WHERE id = $9`
 
 	item.UpdatedAt = time.Now()
 
-	result, err := tx.Exec(query,
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query,
 		item.Title,
 		item.Description,
 		item.Price,
 		item.Location,
 		item.HasPhotos,
 		item.CategoryID,
+		item.Attributes,
 		item.UpdatedAt,
 		item.ID,
 	)
@@ -205,10 +288,32 @@ func (r *ItemRepository) Update(tx *sqlx.Tx, item *models.ItemToUpdate) error {
 }
 
 // Delete deletes an item by ID
-func (r *ItemRepository) Delete(id int) error {
+func (r *ItemRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM items WHERE id = $1`
 
-	result, err := r.db.Exec(query, id)
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteVersion deletes an item only if it still has the given version. It returns
+// sql.ErrNoRows if the item was changed or deleted in the meantime.
+func (r *ItemRepository) DeleteVersion(ctx context.Context, id, version int) error {
+	query := `DELETE FROM items WHERE id = $1 AND version = $2`
+
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, id, version)
 	if err != nil {
 		return err
 	}
@@ -225,12 +330,12 @@ func (r *ItemRepository) Delete(id int) error {
 	return nil
 }
 
-// GetByLocation retrieves items by location
-func (r *ItemRepository) GetByLocation(location string) ([]models.ItemResponse, error) {
+// GetByLocation retrieves items by location with category names in the given language
+func (r *ItemRepository) GetByLocation(ctx context.Context, location string, lang string) ([]models.ItemResponse, error) {
 	var items []models.ItemResponse
-	query := `SELECT i.id, i.title, i.description, i.price, i.location, i.has_photos, i.author_id, i.category_id, i.created_at, i.updated_at, c.id AS "category.id", c.name AS "category.name" FROM items i LEFT JOIN categories c ON i.category_id = c.id WHERE LOWER(i.location) LIKE LOWER($1) ORDER BY i.created_at DESC`
+	query := `SELECT i.id, i.title, i.description, i.price, i.location, i.has_photos, i.author_id, i.attributes, i.version, i.created_at, i.updated_at, c.id AS "category.id", COALESCE(ct.name, c.name) AS "category.name", c.version AS "category.version", ` + itemPhotoURLsColumn + ` FROM items i LEFT JOIN categories c ON i.category_id = c.id LEFT JOIN category_translations ct ON ct.category_id = c.id AND ct.lang = $2 WHERE LOWER(i.location) LIKE LOWER($1) ORDER BY i.created_at DESC`
 
-	err := r.db.Select(&items, query, "%"+location+"%")
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &items, query, "%"+location+"%", lang)
 	if err != nil {
 		return nil, err
 	}
@@ -238,12 +343,12 @@ func (r *ItemRepository) GetByLocation(location string) ([]models.ItemResponse,
 	return items, nil
 }
 
-// GetAvailableItems retrieves only available items
-func (r *ItemRepository) GetAvailableItems() ([]models.ItemResponse, error) {
+// GetAvailableItems retrieves only available items with category names in the given language
+func (r *ItemRepository) GetAvailableItems(ctx context.Context, lang string) ([]models.ItemResponse, error) {
 	var items []models.ItemResponse
-	query := `SELECT i.id, i.title, i.description, i.price, i.location, i.has_photos, i.author_id, i.category_id, i.created_at, i.updated_at, c.id AS "category.id", c.name AS "category.name" FROM items i LEFT JOIN categories c ON i.category_id = c.id ORDER BY i.created_at DESC`
+	query := `SELECT i.id, i.title, i.description, i.price, i.location, i.has_photos, i.author_id, i.attributes, i.version, i.created_at, i.updated_at, c.id AS "category.id", COALESCE(ct.name, c.name) AS "category.name", c.version AS "category.version", ` + itemPhotoURLsColumn + ` FROM items i LEFT JOIN categories c ON i.category_id = c.id LEFT JOIN category_translations ct ON ct.category_id = c.id AND ct.lang = $1 ORDER BY i.created_at DESC`
 
-	err := r.db.Select(&items, query)
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &items, query, lang)
 	if err != nil {
 		return nil, err
 	}
@@ -251,11 +356,12 @@ func (r *ItemRepository) GetAvailableItems() ([]models.ItemResponse, error) {
 	return items, nil
 }
 
-// GetByCategory gets items by category with category info
-func (r *ItemRepository) GetByCategory(categoryID int) ([]models.ItemResponse, error) {
+// GetByCategory gets items by category, including its subcategories, with category info
+// in the given language
+func (r *ItemRepository) GetByCategory(ctx context.Context, categoryID int, lang string) ([]models.ItemResponse, error) {
 	var items []models.ItemResponse
 
-	const query = `
+	query := r.db.Rebind(`
         SELECT
             i.id,
             i.title,
@@ -264,20 +370,26 @@ func (r *ItemRepository) GetByCategory(categoryID int) ([]models.ItemResponse, e
             i.location,
             i.has_photos,
             i.author_id,
+            i.attributes,
+            i.version,
             i.created_at,
             i.updated_at,
             c.id AS "category.id",
-            c.name AS "category.name"
+            COALESCE(ct.name, c.name) AS "category.name",
+            c.version AS "category.version",
+            ` + itemPhotoURLsColumn + `
         FROM
             items i
         INNER JOIN
             categories c ON i.category_id = c.id
+        LEFT JOIN
+            category_translations ct ON ct.category_id = c.id AND ct.lang = ?
         WHERE
-            i.category_id = $1
+            i.category_id IN (` + categorySubtreeQuery + `)
         ORDER BY
-            i.created_at DESC`
+            i.created_at DESC`)
 
-	err := r.db.Select(&items, query, categoryID)
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &items, query, lang, categoryID)
 	if err != nil {
 		return nil, err
 	}
@@ -285,12 +397,12 @@ func (r *ItemRepository) GetByCategory(categoryID int) ([]models.ItemResponse, e
 	return items, nil
 }
 
-// GetPhotosByItemID retrieves all photos for an item
-func (r *ItemRepository) GetPhotosByItemID(itemID int) ([]models.ItemPhoto, error) {
+// GetPhotosByItemID retrieves all photos for an item, cover first
+func (r *ItemRepository) GetPhotosByItemID(ctx context.Context, itemID int) ([]models.ItemPhoto, error) {
 	var photos []models.ItemPhoto
-	query := `SELECT * FROM item_photos WHERE item_id = $1`
+	query := `SELECT * FROM item_photos WHERE item_id = $1 ORDER BY ` + photoDisplayOrder
 
-	err := r.db.Select(&photos, query, itemID)
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &photos, query, itemID)
 	if err != nil {
 		return nil, err
 	}
@@ -298,68 +410,14 @@ func (r *ItemRepository) GetPhotosByItemID(itemID int) ([]models.ItemPhoto, erro
 	return photos, nil
 }
 
-// AddPhotos inserts new photos for an item within a transaction
-func (r *ItemRepository) AddPhotos(tx *sqlx.Tx, itemID int, photoURLs []string) error {
-	if len(photoURLs) == 0 {
-		return nil
-	}
-
-	stmt, err := tx.Prepare(`INSERT INTO item_photos (item_id, url) VALUES ($1, $2)`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	for _, url := range photoURLs {
-		if _, err := stmt.Exec(itemID, url); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-// DeletePhotos removes photos by their IDs within a transaction
-func (r *ItemRepository) DeletePhotos(tx *sqlx.Tx, photoIDs []int, itemID int) error {
-	if len(photoIDs) == 0 {
-		return nil
-	}
-
-	stmt, err := tx.Prepare(`DELETE FROM item_photos WHERE id = $1 AND item_id = $2`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	for _, photoID := range photoIDs {
-		if _, err := stmt.Exec(photoID, itemID); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// CountByItemID counts photos by item ID, using the provided querier (e.g., tx or db)
-func (r *ItemRepository) CountPhotosByItemID(querier sqlx.Ext, itemID int) (int, error) {
-	var count int
-	query := `SELECT COUNT(*) FROM item_photos WHERE item_id = $1`
-
-	err := sqlx.Get(querier, &count, query, itemID)
-	if err != nil {
-		return 0, err
-	}
-
-	return count, nil
-}
-
 // UpdateHasPhotos updates the has_photos flag for an item
-func (r *ItemRepository) UpdateHasPhotos(tx *sqlx.Tx, itemID int, hasPhotos bool) error {
+func (r *ItemRepository) UpdateHasPhotos(ctx context.Context, itemID int, hasPhotos bool) error {
 	query := `
         UPDATE items
         SET has_photos = $1, updated_at = $2
         WHERE id = $3`
 
-	result, err := tx.Exec(query, hasPhotos, time.Now(), itemID)
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, hasPhotos, time.Now(), itemID)
 	if err != nil {
 		return err
 	}
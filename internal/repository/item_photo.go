@@ -1,13 +1,30 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"shary_be/internal/imaging"
 	"shary_be/internal/models"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
+// photoDisplayOrder orders photos the way they are presented: the cover first, then by position
+const photoDisplayOrder = `is_cover DESC, position, id`
+
+// ensureCoverQuery makes the first photo of an item ($1) its cover when the item has none,
+// e.g. after the cover was deleted
+const ensureCoverQuery = `
+	UPDATE item_photos SET is_cover = TRUE
+	WHERE id = (SELECT id FROM item_photos WHERE item_id = $1 ORDER BY position, id LIMIT 1)
+	AND NOT EXISTS (SELECT 1 FROM item_photos WHERE item_id = $1 AND is_cover)`
+
 type ItemPhotoRepository struct {
 	db *sqlx.DB
 }
@@ -16,12 +33,12 @@ func NewItemPhotoRepository(db *sqlx.DB) *ItemPhotoRepository {
 	return &ItemPhotoRepository{db: db}
 }
 
-// GetPhotosByItemID retrieves all photos for an item
-func (r *ItemPhotoRepository) GetPhotosByItemID(itemID int) ([]models.ItemPhoto, error) {
+// GetPhotosByItemID retrieves all photos for an item, cover first
+func (r *ItemPhotoRepository) GetPhotosByItemID(ctx context.Context, itemID int) ([]models.ItemPhoto, error) {
 	var photos []models.ItemPhoto
-	query := `SELECT * FROM item_photos WHERE item_id = $1`
+	query := `SELECT * FROM item_photos WHERE item_id = $1 ORDER BY ` + photoDisplayOrder
 
-	err := r.db.Select(&photos, query, itemID)
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &photos, query, itemID)
 	if err != nil {
 		return nil, err
 	}
@@ -29,13 +46,53 @@ func (r *ItemPhotoRepository) GetPhotosByItemID(itemID int) ([]models.ItemPhoto,
 	return photos, nil
 }
 
-// Add adds a new photos for an item
-func (r *ItemPhotoRepository) Add(tx *sqlx.Tx, itemID int, urls []string) error {
+// GetAll retrieves all photos ordered by ID
+func (r *ItemPhotoRepository) GetAll(ctx context.Context) ([]models.ItemPhoto, error) {
+	var photos []models.ItemPhoto
+	query := `SELECT * FROM item_photos ORDER BY id`
+
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &photos, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// GetReferencedURLs retrieves the URLs of all photos and their resized variants
+func (r *ItemPhotoRepository) GetReferencedURLs(ctx context.Context) ([]string, error) {
+	var urls []string
 	query := `
-		INSERT INTO item_photos (item_id, url)
-		SELECT $1, unnest($2::text[])`
+		SELECT url FROM item_photos
+		UNION
+		SELECT v.value FROM item_photos p, jsonb_each_text(p.variants) v`
 
-	result, err := tx.Exec(query, itemID, pq.Array(urls))
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &urls, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// Add adds a new photos for an item after its existing photos
+func (r *ItemPhotoRepository) Add(ctx context.Context, itemID int, photos []models.NewItemPhoto) error {
+	query := `
+		INSERT INTO item_photos (item_id, url, phash, duplicate_of, position)
+		SELECT $1, u.url, u.phash, u.duplicate_of,
+			COALESCE((SELECT MAX(position) + 1 FROM item_photos WHERE item_id = $1), 0) + u.ord - 1
+		FROM unnest($2::text[], $3::bigint[], $4::int[]) WITH ORDINALITY AS u(url, phash, duplicate_of, ord)`
+
+	urls := make([]string, len(photos))
+	hashes := make([]*int64, len(photos))
+	duplicateOf := make([]*int, len(photos))
+	for i, photo := range photos {
+		urls[i] = photo.URL
+		hashes[i] = photo.PHash
+		duplicateOf[i] = photo.DuplicateOf
+	}
+
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, itemID, pq.Array(urls), pq.Array(hashes), pq.Array(duplicateOf))
 	if err != nil {
 		return err
 	}
@@ -52,11 +109,13 @@ func (r *ItemPhotoRepository) Add(tx *sqlx.Tx, itemID int, urls []string) error
 	return nil
 }
 
-// Delete deletes a photos by ID
-func (r *ItemPhotoRepository) Delete(tx *sqlx.Tx, ids []int) error {
-	query := `DELETE FROM item_photos WHERE id = ANY($1)`
+// Delete deletes photos of an item by ID. It returns sql.ErrNoRows when any of the
+// distinct IDs is not a photo of the item, after deleting the others; callers run it in
+// a transaction to undo that.
+func (r *ItemPhotoRepository) Delete(ctx context.Context, itemID int, ids []int) error {
+	query := `DELETE FROM item_photos WHERE id = ANY($1) AND item_id = $2`
 
-	result, err := tx.Exec(query, pq.Array(ids))
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, pq.Array(ids), itemID)
 	if err != nil {
 		return err
 	}
@@ -65,22 +124,235 @@ func (r *ItemPhotoRepository) Delete(tx *sqlx.Tx, ids []int) error {
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
+	if rowsAffected != int64(len(ids)) {
 		return sql.ErrNoRows
 	}
 
 	return nil
 }
 
-// CountByItemID counts photos by item ID, using the provided querier (e.g., tx or db).
-func (r *ItemPhotoRepository) CountByItemID(querier sqlx.Ext, itemID int) (int, error) {
+// CountByItemID counts photos by item ID
+func (r *ItemPhotoRepository) CountByItemID(ctx context.Context, itemID int) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM item_photos WHERE item_id = $1`
 
-	err := sqlx.Get(querier, &count, query, itemID)
+	err := querierFrom(ctx, r.db).GetContext(ctx, &count, query, itemID)
 	if err != nil {
 		return 0, err
 	}
 
 	return count, nil
 }
+
+// UpdateVariants stores the resized variants of the photo with the given URL
+func (r *ItemPhotoRepository) UpdateVariants(ctx context.Context, url string, variants models.PhotoVariants) error {
+	query := `
+		UPDATE item_photos
+		SET variants = $1, updated_at = $2
+		WHERE url = $3`
+
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, variants, time.Now(), url)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateURL replaces the URL of a photo
+func (r *ItemPhotoRepository) UpdateURL(ctx context.Context, id int, url string) error {
+	query := `
+		UPDATE item_photos
+		SET url = $1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, url, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// EnsureCover makes the first photo of an item its cover if it has none
+func (r *ItemPhotoRepository) EnsureCover(ctx context.Context, itemID int) error {
+	_, err := querierFrom(ctx, r.db).ExecContext(ctx, ensureCoverQuery, itemID)
+	return err
+}
+
+// LockIDsByItemID locks the photos of an item for the rest of the transaction
+// and returns their IDs
+func (r *ItemPhotoRepository) LockIDsByItemID(ctx context.Context, itemID int) ([]int, error) {
+	var ids []int
+	query := `SELECT id FROM item_photos WHERE item_id = $1 ORDER BY id FOR UPDATE`
+
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &ids, query, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// UpdatePositions sets the position of each photo to its index in ids
+func (r *ItemPhotoRepository) UpdatePositions(ctx context.Context, itemID int, ids []int) error {
+	query := `
+		UPDATE item_photos p
+		SET position = u.ord - 1, updated_at = $3
+		FROM unnest($2::int[]) WITH ORDINALITY AS u(id, ord)
+		WHERE p.id = u.id AND p.item_id = $1`
+
+	_, err := querierFrom(ctx, r.db).ExecContext(ctx, query, itemID, pq.Array(ids), time.Now())
+	return err
+}
+
+// SetCover makes the photo the cover of its item, clearing the previous cover
+func (r *ItemPhotoRepository) SetCover(ctx context.Context, itemID int, photoID int) error {
+	tx := querierFrom(ctx, r.db)
+	now := time.Now()
+
+	// Clear the old cover first: the unique cover index is checked row by row
+	clearQuery := `
+		UPDATE item_photos
+		SET is_cover = FALSE, updated_at = $3
+		WHERE item_id = $1 AND is_cover AND id <> $2`
+
+	if _, err := tx.ExecContext(ctx, clearQuery, itemID, photoID, now); err != nil {
+		return err
+	}
+
+	setQuery := `
+		UPDATE item_photos
+		SET is_cover = TRUE, updated_at = $3
+		WHERE item_id = $1 AND id = $2`
+
+	result, err := tx.ExecContext(ctx, setQuery, itemID, photoID, now)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// phashBandExprs select the bands of item_photos.phash as imaging.HashBand numbers
+// them; each has an index
+var phashBandExprs = [imaging.HashBands]string{
+	`((p.phash >> 48) & 65535)`,
+	`((p.phash >> 32) & 65535)`,
+	`((p.phash >> 16) & 65535)`,
+	`(p.phash & 65535)`,
+}
+
+// FindSimilar returns the stored photo closest to the perceptual hash among photos of
+// items not authored by excludeAuthorID, or nil if none is within maxDistance bits.
+// Only photos sharing a nearby hash band are compared, which the band indexes find
+// without reading every photo.
+func (r *ItemPhotoRepository) FindSimilar(ctx context.Context, hash int64, excludeAuthorID int, maxDistance int) (*models.SimilarPhoto, error) {
+	args := []interface{}{hash, excludeAuthorID, maxDistance}
+
+	candidates := ""
+	if bands, ok := imaging.NearBandValues(uint64(hash), maxDistance); ok {
+		conditions := make([]string, 0, len(bands))
+		for i, values := range bands {
+			band := make([]int64, len(values))
+			for j, value := range values {
+				band[j] = int64(value)
+			}
+			args = append(args, pq.Array(band))
+			conditions = append(conditions, fmt.Sprintf("%s = ANY($%d)", phashBandExprs[i], len(args)))
+		}
+		candidates = "AND (" + strings.Join(conditions, " OR ") + ")"
+	}
+
+	var photo models.SimilarPhoto
+	query := `
+		SELECT id, item_id, distance
+		FROM (
+			SELECT p.id, p.item_id,
+				length(replace(((p.phash # $1)::bit(64))::text, '0', '')) AS distance
+			FROM item_photos p
+			INNER JOIN items i ON i.id = p.item_id
+			WHERE p.phash IS NOT NULL AND i.author_id <> $2 ` + candidates + `
+		) candidates
+		WHERE distance <= $3
+		ORDER BY distance, id
+		LIMIT 1`
+
+	err := querierFrom(ctx, r.db).GetContext(ctx, &photo, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &photo, nil
+}
+
+// GetFlagged retrieves photos flagged as near-duplicates, newest first
+func (r *ItemPhotoRepository) GetFlagged(ctx context.Context) ([]models.FlaggedPhoto, error) {
+	var photos []models.FlaggedPhoto
+	query := `
+		SELECT
+			p.id, p.item_id, p.url, i.author_id,
+			o.id AS duplicate_of_id, o.item_id AS duplicate_of_item_id, o.url AS duplicate_of_url,
+			p.created_at
+		FROM item_photos p
+		INNER JOIN items i ON i.id = p.item_id
+		INNER JOIN item_photos o ON o.id = p.duplicate_of
+		ORDER BY p.created_at DESC, p.id DESC`
+
+	err := querierFrom(ctx, r.db).SelectContext(ctx, &photos, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// UpdateHash stores the perceptual hash of a photo
+func (r *ItemPhotoRepository) UpdateHash(ctx context.Context, id int, hash int64) error {
+	query := `
+		UPDATE item_photos
+		SET phash = $1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query, hash, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
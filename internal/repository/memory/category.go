@@ -0,0 +1,442 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"shary_be/internal/models"
+	"shary_be/internal/repository"
+)
+
+// CategoryRepository stores categories and their translations in a DB
+type CategoryRepository struct {
+	db *DB
+}
+
+// NewCategoryRepository creates a new category repository
+func NewCategoryRepository(db *DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// GetAll retrieves all categories with names in the given language,
+// falling back to the default name when no translation exists.
+// ItemsCount holds the number of items assigned directly to each category.
+func (r *CategoryRepository) GetAll(ctx context.Context, lang string) ([]models.Category, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	counts := map[int]int{}
+	for _, item := range t.items {
+		if item.CategoryID != nil {
+			counts[*item.CategoryID]++
+		}
+	}
+
+	categories := make([]models.Category, 0, len(t.categories))
+	for _, category := range t.categories {
+		category = t.localizedCategory(category, lang)
+		category.ItemsCount = counts[category.ID]
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Name != categories[j].Name {
+			return categories[i].Name < categories[j].Name
+		}
+		return categories[i].ID < categories[j].ID
+	})
+
+	return categories, nil
+}
+
+// Create adds a new category
+func (r *CategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if err := t.checkCategory(0, category.Slug, category.ParentID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+
+	t.lastCategoryID++
+	category.ID = t.lastCategoryID
+	category.Version = 1
+
+	stored := copyCategory(*category)
+	stored.ItemsCount = 0
+	t.categories[stored.ID] = stored
+
+	return nil
+}
+
+// Update updates an existing category and sets its new version.
+// It returns sql.ErrNoRows if the category does not exist.
+func (r *CategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	stored, ok := t.categories[category.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if err := t.checkCategory(category.ID, category.Slug, category.ParentID); err != nil {
+		return err
+	}
+
+	category.UpdatedAt = time.Now()
+
+	updated := copyCategory(*category)
+	updated.ItemsCount = 0
+	updated.CreatedAt = stored.CreatedAt
+	updated.Version = stored.Version
+	t.categories[category.ID] = updated
+	t.touchCategory(category.ID, category.UpdatedAt)
+
+	category.Version = t.categories[category.ID].Version
+	return nil
+}
+
+// Delete deletes a category by ID, together with its translations
+func (r *CategoryRepository) Delete(ctx context.Context, id int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if _, ok := t.categories[id]; !ok {
+		return sql.ErrNoRows
+	}
+	for _, item := range t.items {
+		if item.CategoryID != nil && *item.CategoryID == id {
+			return foreignKeyError("items", "category_id")
+		}
+	}
+	for _, category := range t.categories {
+		if category.ParentID != nil && *category.ParentID == id {
+			return foreignKeyError("categories", "parent_id")
+		}
+	}
+
+	for key := range t.translations {
+		if key.categoryID == id {
+			delete(t.translations, key)
+		}
+	}
+	delete(t.categories, id)
+
+	return nil
+}
+
+// GetByID retrieves a category by ID
+func (r *CategoryRepository) GetByID(ctx context.Context, id int) (*models.Category, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	category, ok := r.db.tables.categories[id]
+	if !ok {
+		return nil, nil
+	}
+
+	category = copyCategory(category)
+	return &category, nil
+}
+
+// GetLocalizedByID retrieves a category by ID with its name in the given language,
+// falling back to the default name when no translation exists. ItemsCount holds the
+// number of items in the category and its subcategories.
+func (r *CategoryRepository) GetLocalizedByID(ctx context.Context, id int, lang string) (*models.Category, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	category, ok := t.categories[id]
+	if !ok {
+		return nil, nil
+	}
+
+	category = t.localizedCategory(category, lang)
+	category.ItemsCount = t.subtreeItemsCount(id)
+	return &category, nil
+}
+
+// GetLocalizedBySlug retrieves a category by slug with its name in the given language,
+// falling back to the default name when no translation exists. ItemsCount holds the
+// number of items in the category and its subcategories.
+func (r *CategoryRepository) GetLocalizedBySlug(ctx context.Context, slug string, lang string) (*models.Category, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	for _, category := range t.categories {
+		if category.Slug == slug {
+			category = t.localizedCategory(category, lang)
+			category.ItemsCount = t.subtreeItemsCount(category.ID)
+			return &category, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SlugExists checks whether a slug is used by any category other than excludeID
+func (r *CategoryRepository) SlugExists(ctx context.Context, slug string, excludeID int) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.tables.slugExists(slug, excludeID), nil
+}
+
+// GetTranslations retrieves all translations of a category
+func (r *CategoryRepository) GetTranslations(ctx context.Context, categoryID int) ([]models.CategoryTranslation, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var translations []models.CategoryTranslation
+	for key, translation := range r.db.tables.translations {
+		if key.categoryID == categoryID {
+			translations = append(translations, translation)
+		}
+	}
+	sort.Slice(translations, func(i, j int) bool { return translations[i].Lang < translations[j].Lang })
+
+	return translations, nil
+}
+
+// UpsertTranslation creates or replaces the translation of a category for a language
+func (r *CategoryRepository) UpsertTranslation(ctx context.Context, translation *models.CategoryTranslation) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if _, ok := t.categories[translation.CategoryID]; !ok {
+		return foreignKeyError("category_translations", "category_id")
+	}
+
+	now := time.Now()
+	key := translationKey{categoryID: translation.CategoryID, lang: translation.Lang}
+	translation.CreatedAt = now
+	if existing, ok := t.translations[key]; ok {
+		translation.CreatedAt = existing.CreatedAt
+	}
+	translation.UpdatedAt = now
+
+	t.translations[key] = *translation
+	t.touchCategory(translation.CategoryID, now)
+
+	return nil
+}
+
+// DeleteTranslation deletes the translation of a category for a language
+func (r *CategoryRepository) DeleteTranslation(ctx context.Context, categoryID int, lang string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	key := translationKey{categoryID: categoryID, lang: lang}
+	if _, ok := t.translations[key]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(t.translations, key)
+	t.touchCategory(categoryID, time.Now())
+
+	return nil
+}
+
+// GetDescendantIDs retrieves the IDs of a category and all of its subcategories
+func (r *CategoryRepository) GetDescendantIDs(ctx context.Context, id int) ([]int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var ids []int
+	for descendantID := range r.db.tables.subtree(id) {
+		ids = append(ids, descendantID)
+	}
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+// LockByIDs returns the given categories that exist, in ID order; transactions are
+// serialized, so they cannot change until the transaction ends
+func (r *CategoryRepository) LockByIDs(ctx context.Context, ids []int) ([]models.Category, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	var categories []models.Category
+	for _, id := range ids {
+		if category, ok := t.categories[id]; ok {
+			categories = append(categories, copyCategory(category))
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+
+	return categories, nil
+}
+
+// CountItems counts items assigned directly to a category
+func (r *CategoryRepository) CountItems(ctx context.Context, categoryID int) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	count := 0
+	for _, item := range r.db.tables.items {
+		if item.CategoryID != nil && *item.CategoryID == categoryID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// LockItemAttributes returns the attributes of the items assigned directly to a
+// category by item ID
+func (r *CategoryRepository) LockItemAttributes(ctx context.Context, categoryID int) (map[int]models.ItemAttributes, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	attributes := make(map[int]models.ItemAttributes)
+	for id, item := range r.db.tables.items {
+		if item.CategoryID != nil && *item.CategoryID == categoryID {
+			attributes[id] = item.Attributes
+		}
+	}
+	return attributes, nil
+}
+
+// ReassignItems moves all items from one category to another
+func (r *CategoryRepository) ReassignItems(ctx context.Context, fromID, toID int) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if _, ok := t.categories[toID]; !ok {
+		return 0, foreignKeyError("items", "category_id")
+	}
+
+	now := time.Now()
+	moved := 0
+	for id, item := range t.items {
+		if item.CategoryID != nil && *item.CategoryID == fromID {
+			item.CategoryID = &toID
+			t.items[id] = item
+			t.touchItem(id, now)
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+// ReparentChildren moves the direct subcategories of a category under a new parent
+// (nil makes them root categories)
+func (r *CategoryRepository) ReparentChildren(ctx context.Context, fromID int, toParentID *int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if toParentID != nil {
+		if _, ok := t.categories[*toParentID]; !ok {
+			return foreignKeyError("categories", "parent_id")
+		}
+	}
+
+	now := time.Now()
+	for id, category := range t.categories {
+		if category.ParentID != nil && *category.ParentID == fromID {
+			category.ParentID = copyInt(toParentID)
+			t.categories[id] = category
+			t.touchCategory(id, now)
+		}
+	}
+
+	return nil
+}
+
+// checkCategory checks the unique slug and the parent of category id, 0 for a new one
+func (t *tables) checkCategory(id int, slug string, parentID *int) error {
+	if t.slugExists(slug, id) {
+		return repository.ErrSlugExists
+	}
+	if parentID != nil {
+		if _, ok := t.categories[*parentID]; !ok {
+			return foreignKeyError("categories", "parent_id")
+		}
+	}
+	return nil
+}
+
+// slugExists checks whether a slug is used by any category other than excludeID
+func (t *tables) slugExists(slug string, excludeID int) bool {
+	for id, category := range t.categories {
+		if category.Slug == slug && id != excludeID {
+			return true
+		}
+	}
+	return false
+}
+
+// subtree returns the IDs of a category and all of its descendants
+func (t *tables) subtree(id int) map[int]bool {
+	ids := map[int]bool{}
+	if _, ok := t.categories[id]; !ok {
+		return ids
+	}
+
+	ids[id] = true
+	for added := true; added; {
+		added = false
+		for childID, category := range t.categories {
+			if category.ParentID != nil && ids[*category.ParentID] && !ids[childID] {
+				ids[childID] = true
+				added = true
+			}
+		}
+	}
+	return ids
+}
+
+// subtreeItemsCount counts the items in a category and all of its descendants
+func (t *tables) subtreeItemsCount(id int) int {
+	ids := t.subtree(id)
+	count := 0
+	for _, item := range t.items {
+		if item.CategoryID != nil && ids[*item.CategoryID] {
+			count++
+		}
+	}
+	return count
+}
+
+// localizedName returns the name of a category in lang, or its default name
+func (t *tables) localizedName(category models.Category, lang string) string {
+	if translation, ok := t.translations[translationKey{categoryID: category.ID, lang: lang}]; ok {
+		return translation.Name
+	}
+	return category.Name
+}
+
+// localizedCategory returns a copy of a category named in lang
+func (t *tables) localizedCategory(category models.Category, lang string) models.Category {
+	category = copyCategory(category)
+	category.Name = t.localizedName(category, lang)
+	return category
+}
+
+// copyCategory copies a category so callers cannot change the stored one
+func copyCategory(category models.Category) models.Category {
+	category.ParentID = copyInt(category.ParentID)
+	schema := make(models.AttributeSchema, len(category.AttributeSchema))
+	for i, def := range category.AttributeSchema {
+		def.Options = append([]string(nil), def.Options...)
+		schema[i] = def
+	}
+	category.AttributeSchema = schema
+	return category
+}
@@ -0,0 +1,156 @@
+// Package memory provides in-memory implementations of the repositories, so services
+// and handlers can be tested without PostgreSQL. They behave like the PostgreSQL
+// repositories, including the versions kept by database triggers and the foreign keys,
+// but transactions are serialized: a DB is meant for tests, not for serving traffic.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"shary_be/internal/models"
+)
+
+// DB holds the tables shared by the repositories of one test
+type DB struct {
+	// txMu serializes transactions, standing in for row locks
+	txMu sync.Mutex
+	// mu guards tables
+	mu     sync.Mutex
+	tables tables
+}
+
+// tables is the data of a DB, copied as a whole when a transaction starts
+type tables struct {
+	items         map[int]models.Item
+	photos        map[int]models.ItemPhoto
+	categories    map[int]models.Category
+	translations  map[translationKey]models.CategoryTranslation
+	users         map[int]models.User
+	privatePhotos map[string]models.PrivatePhotoRecord
+
+	lastItemID     int
+	lastPhotoID    int
+	lastCategoryID int
+	lastUserID     int
+}
+
+// translationKey is the primary key of a category translation
+type translationKey struct {
+	categoryID int
+	lang       string
+}
+
+// NewDB creates an empty database
+func NewDB() *DB {
+	return &DB{tables: tables{
+		items:         map[int]models.Item{},
+		photos:        map[int]models.ItemPhoto{},
+		categories:    map[int]models.Category{},
+		translations:  map[translationKey]models.CategoryTranslation{},
+		users:         map[int]models.User{},
+		privatePhotos: map[string]models.PrivatePhotoRecord{},
+	}}
+}
+
+// snapshot copies the tables. Rows are copied by value; the maps and slices inside
+// them are never modified in place, so they can be shared.
+func (t *tables) snapshot() tables {
+	s := *t
+	s.items = make(map[int]models.Item, len(t.items))
+	for id, item := range t.items {
+		s.items[id] = item
+	}
+	s.photos = make(map[int]models.ItemPhoto, len(t.photos))
+	for id, photo := range t.photos {
+		s.photos[id] = photo
+	}
+	s.categories = make(map[int]models.Category, len(t.categories))
+	for id, category := range t.categories {
+		s.categories[id] = category
+	}
+	s.translations = make(map[translationKey]models.CategoryTranslation, len(t.translations))
+	for key, translation := range t.translations {
+		s.translations[key] = translation
+	}
+	s.users = make(map[int]models.User, len(t.users))
+	for id, user := range t.users {
+		s.users[id] = user
+	}
+	s.privatePhotos = make(map[string]models.PrivatePhotoRecord, len(t.privatePhotos))
+	for key, photo := range t.privatePhotos {
+		s.privatePhotos[key] = photo
+	}
+	return s
+}
+
+// touchItem does what any update of an item does in PostgreSQL: it bumps the version
+func (t *tables) touchItem(id int, now time.Time) {
+	item, ok := t.items[id]
+	if !ok {
+		return
+	}
+	item.Version++
+	item.UpdatedAt = now
+	t.items[id] = item
+}
+
+// touchCategory bumps the version of a category
+func (t *tables) touchCategory(id int, now time.Time) {
+	category, ok := t.categories[id]
+	if !ok {
+		return
+	}
+	category.Version++
+	category.UpdatedAt = now
+	t.categories[id] = category
+}
+
+// foreignKeyError reports a row referencing a row that does not exist, or a row
+// being deleted while others still reference it
+func foreignKeyError(table, column string) error {
+	return fmt.Errorf("memory: foreign key violation on %s.%s", table, column)
+}
+
+// uniqueError reports a duplicate value in a unique column
+func uniqueError(table, column string) error {
+	return fmt.Errorf("memory: duplicate key value violates unique constraint on %s.%s", table, column)
+}
+
+// txKey is the context key marking the DB a transaction is running on
+type txKey struct{}
+
+// TxManager runs units of work in transactions of a DB
+type TxManager struct {
+	db *DB
+}
+
+// NewTxManager creates a new transaction manager
+func NewTxManager(db *DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// WithinTx runs fn in a transaction, rolling the tables back if fn returns an error.
+// Transactions run one at a time; a WithinTx nested in another one joins it.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if db, ok := ctx.Value(txKey{}).(*DB); ok && db == m.db {
+		return fn(ctx)
+	}
+
+	m.db.txMu.Lock()
+	defer m.db.txMu.Unlock()
+
+	m.db.mu.Lock()
+	snapshot := m.db.tables.snapshot()
+	m.db.mu.Unlock()
+
+	if err := fn(context.WithValue(ctx, txKey{}, m.db)); err != nil {
+		m.db.mu.Lock()
+		m.db.tables = snapshot
+		m.db.mu.Unlock()
+		return err
+	}
+	return nil
+}
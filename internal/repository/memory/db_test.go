@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"shary_be/internal/models"
+)
+
+// newItem stores an item with the given photos by a new author
+func newItem(t *testing.T, db *DB, photos ...string) *models.Item {
+	t.Helper()
+	ctx := context.Background()
+
+	author := &models.User{FirstName: "Aigerim", LastName: "Sadykova", Identity: "900101300123"}
+	if err := NewUserRepository(db).Create(ctx, author); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	item := &models.Item{Title: "Bike", Description: "A city bike", Price: 1000, Location: "Almaty", AuthorID: author.ID}
+	if err := NewItemRepository(db).Create(ctx, item, photos); err != nil {
+		t.Fatalf("Create item: %v", err)
+	}
+	return item
+}
+
+func TestTxManager_RollsBackOnError(t *testing.T) {
+	db := NewDB()
+	item := newItem(t, db)
+	items := NewItemRepository(db)
+	photos := NewItemPhotoRepository(db)
+	ctx := context.Background()
+
+	errFailed := errors.New("failed")
+	err := NewTxManager(db).WithinTx(ctx, func(ctx context.Context) error {
+		if err := photos.Add(ctx, item.ID, []models.NewItemPhoto{{URL: "https://example.com/1.jpg"}}); err != nil {
+			return err
+		}
+		if err := items.UpdateHasPhotos(ctx, item.ID, true); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("WithinTx error = %v, want %v", err, errFailed)
+	}
+
+	got, err := items.GetByID(ctx, item.ID, "ru")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.HasPhotos || len(got.Photos) != 0 || got.Version != 1 {
+		t.Errorf("after rollback: has_photos %v, photos %v, version %d; want false, none, 1", got.HasPhotos, got.Photos, got.Version)
+	}
+}
+
+func TestItemPhotoRepository_ChangesBumpItemVersion(t *testing.T) {
+	db := NewDB()
+	item := newItem(t, db, "https://example.com/1.jpg", "https://example.com/2.jpg")
+	items := NewItemRepository(db)
+	photos := NewItemPhotoRepository(db)
+	ctx := context.Background()
+
+	version := func() int {
+		t.Helper()
+		got, err := items.GetByID(ctx, item.ID, "ru")
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		return got.Version
+	}
+
+	// One bump per inserted photo, as the trigger runs for each row
+	if got := version(); got != 3 {
+		t.Fatalf("version after create = %d, want 3", got)
+	}
+
+	stored, _ := photos.GetPhotosByItemID(ctx, item.ID)
+	if err := photos.UpdateVariants(ctx, stored[0].URL, models.PhotoVariants{"thumb": "https://example.com/1_thumb.jpg"}); err != nil {
+		t.Fatalf("UpdateVariants: %v", err)
+	}
+	if got := version(); got != 3 {
+		t.Errorf("version after variants = %d, want 3: variants are not part of the item", got)
+	}
+
+	if err := photos.SetCover(ctx, item.ID, stored[1].ID); err != nil {
+		t.Fatalf("SetCover: %v", err)
+	}
+	if got := version(); got != 5 {
+		t.Errorf("version after cover change = %d, want 5", got)
+	}
+}
@@ -0,0 +1,420 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"shary_be/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ItemRepository stores items in a DB
+type ItemRepository struct {
+	db *DB
+}
+
+// NewItemRepository creates a new item repository
+func NewItemRepository(db *DB) *ItemRepository {
+	return &ItemRepository{db: db}
+}
+
+// Create creates a new item, together with its photos
+func (r *ItemRepository) Create(ctx context.Context, item *models.Item, photos []string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if err := t.checkItemReferences(item.AuthorID, item.CategoryID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	item.HasPhotos = len(photos) > 0
+
+	t.lastItemID++
+	item.ID = t.lastItemID
+
+	item.Version = 1
+
+	stored := *item
+	stored.CategoryID = copyInt(item.CategoryID)
+	stored.Attributes = copyAttributes(item.Attributes)
+	t.items[stored.ID] = stored
+
+	// Photos keep the order they were given in, the first one is the cover
+	for i, url := range photos {
+		t.insertPhoto(models.ItemPhoto{
+			ItemID:   item.ID,
+			URL:      url,
+			Position: i,
+			IsCover:  i == 0,
+		}, now)
+	}
+	// Each photo made a new version of the item
+	item.Version = t.items[item.ID].Version
+
+	return nil
+}
+
+// GetByID retrieves an item by ID with the category name in the given language
+func (r *ItemRepository) GetByID(ctx context.Context, id int, lang string) (*models.ItemResponse, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	item, ok := t.items[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	response := t.itemResponse(item, lang)
+	return &response, nil
+}
+
+// GetAll retrieves all items with optional filtering
+func (r *ItemRepository) GetAll(ctx context.Context, filter *models.ItemFilter) ([]models.ItemResponse, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if filter == nil {
+		return t.itemResponses("", nil), nil
+	}
+
+	var subtree map[int]bool
+	if filter.CategoryID != nil {
+		subtree = t.subtree(*filter.CategoryID)
+	}
+
+	var matchErr error
+	items := t.itemResponses(filter.Lang, func(item models.Item) bool {
+		if filter.MinPrice != nil && item.Price < *filter.MinPrice {
+			return false
+		}
+		if filter.MaxPrice != nil && item.Price > *filter.MaxPrice {
+			return false
+		}
+		if filter.Location != nil && *filter.Location != "" && !containsFold(item.Location, *filter.Location) {
+			return false
+		}
+		if filter.Search != nil && *filter.Search != "" &&
+			!containsFold(item.Title, *filter.Search) && !containsFold(item.Description, *filter.Search) {
+			return false
+		}
+		if subtree != nil && (item.CategoryID == nil || !subtree[*item.CategoryID]) {
+			return false
+		}
+		for _, attr := range filter.Attributes {
+			ok, err := matchAttribute(item.Attributes, attr)
+			if err != nil {
+				matchErr = err
+			}
+			if !ok {
+				return false
+			}
+		}
+		return true
+	})
+	if matchErr != nil {
+		return nil, fmt.Errorf("failed to get all items with filter: %w", matchErr)
+	}
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(items) {
+			items = items[:0]
+		} else {
+			items = items[filter.Offset:]
+		}
+	}
+	if filter.Limit > 0 && filter.Limit < len(items) {
+		items = items[:filter.Limit]
+	}
+
+	return items, nil
+}
+
+// Exists checks whether an item with the given ID exists
+func (r *ItemRepository) Exists(ctx context.Context, id int) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	_, ok := r.db.tables.items[id]
+	return ok, nil
+}
+
+// GetAuthorID returns the author of an item, or sql.ErrNoRows if it does not exist
+func (r *ItemRepository) GetAuthorID(ctx context.Context, id int) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	item, ok := r.db.tables.items[id]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return item.AuthorID, nil
+}
+
+// LockByID checks that an item exists; transactions are serialized, so the item
+// cannot change until the transaction ends. It returns sql.ErrNoRows if it does not exist.
+func (r *ItemRepository) LockByID(ctx context.Context, id int) error {
+	_, err := r.GetAuthorID(ctx, id)
+	return err
+}
+
+// Update updates an item
+func (r *ItemRepository) Update(ctx context.Context, item *models.ItemToUpdate) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	stored, ok := t.items[item.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if err := t.checkItemReferences(stored.AuthorID, item.CategoryID); err != nil {
+		return err
+	}
+
+	item.UpdatedAt = time.Now()
+
+	stored.Title = item.Title
+	stored.Description = item.Description
+	stored.Price = int(item.Price)
+	stored.Location = item.Location
+	stored.HasPhotos = item.HasPhotos
+	stored.CategoryID = copyInt(item.CategoryID)
+	stored.Attributes = copyAttributes(item.Attributes)
+	t.items[item.ID] = stored
+	t.touchItem(item.ID, item.UpdatedAt)
+
+	return nil
+}
+
+// Delete deletes an item by ID, together with its photos
+func (r *ItemRepository) Delete(ctx context.Context, id int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if _, ok := t.items[id]; !ok {
+		return sql.ErrNoRows
+	}
+	t.deleteItem(id)
+	return nil
+}
+
+// DeleteVersion deletes an item only if it still has the given version. It returns
+// sql.ErrNoRows if the item was changed or deleted in the meantime.
+func (r *ItemRepository) DeleteVersion(ctx context.Context, id, version int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	item, ok := t.items[id]
+	if !ok || item.Version != version {
+		return sql.ErrNoRows
+	}
+	t.deleteItem(id)
+	return nil
+}
+
+// GetByLocation retrieves items by location with category names in the given language
+func (r *ItemRepository) GetByLocation(ctx context.Context, location string, lang string) ([]models.ItemResponse, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.tables.itemResponses(lang, func(item models.Item) bool {
+		return containsFold(item.Location, location)
+	}), nil
+}
+
+// GetAvailableItems retrieves only available items with category names in the given language
+func (r *ItemRepository) GetAvailableItems(ctx context.Context, lang string) ([]models.ItemResponse, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.tables.itemResponses(lang, nil), nil
+}
+
+// GetByCategory gets items by category, including its subcategories, with category info
+// in the given language
+func (r *ItemRepository) GetByCategory(ctx context.Context, categoryID int, lang string) ([]models.ItemResponse, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	subtree := t.subtree(categoryID)
+	return t.itemResponses(lang, func(item models.Item) bool {
+		return item.CategoryID != nil && subtree[*item.CategoryID]
+	}), nil
+}
+
+// UpdateHasPhotos updates the has_photos flag for an item
+func (r *ItemRepository) UpdateHasPhotos(ctx context.Context, itemID int, hasPhotos bool) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	item, ok := t.items[itemID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	item.HasPhotos = hasPhotos
+	t.items[itemID] = item
+	t.touchItem(itemID, time.Now())
+
+	return nil
+}
+
+// checkItemReferences checks the foreign keys of an item
+func (t *tables) checkItemReferences(authorID int, categoryID *int) error {
+	if _, ok := t.users[authorID]; !ok {
+		return foreignKeyError("items", "author_id")
+	}
+	if categoryID != nil {
+		if _, ok := t.categories[*categoryID]; !ok {
+			return foreignKeyError("items", "category_id")
+		}
+	}
+	return nil
+}
+
+// deleteItem deletes an item and, like ON DELETE CASCADE, its photos
+func (t *tables) deleteItem(id int) {
+	var photoIDs []int
+	for photoID, photo := range t.photos {
+		if photo.ItemID == id {
+			photoIDs = append(photoIDs, photoID)
+		}
+	}
+	t.deletePhotos(photoIDs, time.Now())
+	delete(t.items, id)
+}
+
+// itemResponses returns the items match accepts, or all items when it is nil,
+// newest first with category names in lang
+func (t *tables) itemResponses(lang string, match func(models.Item) bool) []models.ItemResponse {
+	items := make([]models.Item, 0, len(t.items))
+	for _, item := range t.items {
+		if match == nil || match(item) {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+		return items[i].ID > items[j].ID
+	})
+
+	responses := make([]models.ItemResponse, len(items))
+	for i, item := range items {
+		responses[i] = t.itemResponse(item, lang)
+	}
+	return responses
+}
+
+// itemResponse joins an item with its category, in lang, and its photo URLs
+func (t *tables) itemResponse(item models.Item, lang string) models.ItemResponse {
+	response := models.ItemResponse{
+		ID:          item.ID,
+		Title:       item.Title,
+		Description: item.Description,
+		Price:       float64(item.Price),
+		Location:    item.Location,
+		HasPhotos:   item.HasPhotos,
+		Photos:      pq.StringArray{},
+		AuthorID:    item.AuthorID,
+		Attributes:  copyAttributes(item.Attributes),
+		Version:     item.Version,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+	}
+
+	if item.CategoryID != nil {
+		if category, ok := t.categories[*item.CategoryID]; ok {
+			name := t.localizedName(category, lang)
+			version := category.Version
+			response.Category = models.CategoryInfo{
+				ID:      copyInt(item.CategoryID),
+				Name:    &name,
+				Version: &version,
+			}
+		}
+	}
+
+	for _, photo := range t.itemPhotos(item.ID) {
+		response.Photos = append(response.Photos, photo.URL)
+	}
+
+	return response
+}
+
+// matchAttribute reports whether item attributes pass an attribute filter, comparing
+// values the way the PostgreSQL repository compares JSONB values
+func matchAttribute(attributes models.ItemAttributes, filter models.AttributeFilter) (bool, error) {
+	value, ok := attributes[filter.Key]
+	if !ok {
+		return false, nil
+	}
+
+	switch filter.Op {
+	case models.AttributeFilterMin, models.AttributeFilterMax:
+		number, ok := value.(float64)
+		if !ok {
+			return false, nil
+		}
+		bound, err := strconv.ParseFloat(filter.Value, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid input syntax for type numeric: %q", filter.Value)
+		}
+		if filter.Op == models.AttributeFilterMin {
+			return number >= bound, nil
+		}
+		return number <= bound, nil
+	default:
+		// ->> returns strings unquoted and other values as JSON text
+		text, ok := value.(string)
+		if !ok {
+			b, err := json.Marshal(value)
+			if err != nil {
+				return false, err
+			}
+			text = string(b)
+		}
+		return text == filter.Value, nil
+	}
+}
+
+// containsFold reports whether substr is within s, ignoring case like LOWER(s) LIKE LOWER('%substr%')
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// copyAttributes copies item attributes the way they come back from JSONB: nil becomes
+// empty and numbers become float64
+func copyAttributes(attributes models.ItemAttributes) models.ItemAttributes {
+	c := models.ItemAttributes{}
+	if b, err := json.Marshal(attributes); err == nil {
+		json.Unmarshal(b, &c)
+	}
+	return c
+}
+
+// copyInt copies an optional integer
+func copyInt(v *int) *int {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
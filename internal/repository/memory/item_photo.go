@@ -0,0 +1,456 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"math/bits"
+	"sort"
+	"time"
+
+	"shary_be/internal/imaging"
+	"shary_be/internal/models"
+)
+
+// ItemPhotoRepository stores the photos of items in a DB
+type ItemPhotoRepository struct {
+	db *DB
+}
+
+// NewItemPhotoRepository creates a new item photo repository
+func NewItemPhotoRepository(db *DB) *ItemPhotoRepository {
+	return &ItemPhotoRepository{db: db}
+}
+
+// GetPhotosByItemID retrieves all photos for an item, cover first
+func (r *ItemPhotoRepository) GetPhotosByItemID(ctx context.Context, itemID int) ([]models.ItemPhoto, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.tables.itemPhotos(itemID), nil
+}
+
+// GetAll retrieves all photos ordered by ID
+func (r *ItemPhotoRepository) GetAll(ctx context.Context) ([]models.ItemPhoto, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	photos := make([]models.ItemPhoto, 0, len(t.photos))
+	for _, photo := range t.photos {
+		photos = append(photos, copyPhoto(photo))
+	}
+	sort.Slice(photos, func(i, j int) bool { return photos[i].ID < photos[j].ID })
+
+	return photos, nil
+}
+
+// GetReferencedURLs retrieves the URLs of all photos and their resized variants
+func (r *ItemPhotoRepository) GetReferencedURLs(ctx context.Context) ([]string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	seen := map[string]bool{}
+	var urls []string
+	add := func(url string) {
+		if !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	for _, photo := range r.db.tables.photos {
+		add(photo.URL)
+		for _, url := range photo.Variants {
+			add(url)
+		}
+	}
+
+	return urls, nil
+}
+
+// Add adds a new photos for an item after its existing photos
+func (r *ItemPhotoRepository) Add(ctx context.Context, itemID int, photos []models.NewItemPhoto) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if len(photos) == 0 {
+		return sql.ErrNoRows
+	}
+	if _, ok := t.items[itemID]; !ok {
+		return foreignKeyError("item_photos", "item_id")
+	}
+	for _, photo := range photos {
+		if photo.DuplicateOf != nil {
+			if _, ok := t.photos[*photo.DuplicateOf]; !ok {
+				return foreignKeyError("item_photos", "duplicate_of")
+			}
+		}
+	}
+
+	position := 0
+	for _, photo := range t.photos {
+		if photo.ItemID == itemID && photo.Position >= position {
+			position = photo.Position + 1
+		}
+	}
+
+	now := time.Now()
+	for i, photo := range photos {
+		t.insertPhoto(models.ItemPhoto{
+			ItemID:      itemID,
+			URL:         photo.URL,
+			Position:    position + i,
+			PHash:       copyInt64(photo.PHash),
+			DuplicateOf: copyInt(photo.DuplicateOf),
+		}, now)
+	}
+
+	return nil
+}
+
+// Delete deletes photos of an item by ID, or none when any ID is not a photo of the item
+func (r *ItemPhotoRepository) Delete(ctx context.Context, itemID int, ids []int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if photo, ok := r.db.tables.photos[id]; !ok || photo.ItemID != itemID || seen[id] {
+			return sql.ErrNoRows
+		}
+		seen[id] = true
+	}
+
+	r.db.tables.deletePhotos(ids, time.Now())
+	return nil
+}
+
+// CountByItemID counts photos by item ID
+func (r *ItemPhotoRepository) CountByItemID(ctx context.Context, itemID int) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	count := 0
+	for _, photo := range r.db.tables.photos {
+		if photo.ItemID == itemID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// UpdateVariants stores the resized variants of the photo with the given URL
+func (r *ItemPhotoRepository) UpdateVariants(ctx context.Context, url string, variants models.PhotoVariants) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	updated := 0
+	now := time.Now()
+	for id, photo := range t.photos {
+		if photo.URL == url {
+			photo.Variants = copyVariants(variants)
+			photo.UpdatedAt = now
+			t.photos[id] = photo
+			updated++
+		}
+	}
+	if updated == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateURL replaces the URL of a photo
+func (r *ItemPhotoRepository) UpdateURL(ctx context.Context, id int, url string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	photo, ok := t.photos[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	now := time.Now()
+	photo.URL = url
+	photo.UpdatedAt = now
+	t.photos[id] = photo
+	t.touchItem(photo.ItemID, now)
+
+	return nil
+}
+
+// EnsureCover makes the first photo of an item its cover if it has none
+func (r *ItemPhotoRepository) EnsureCover(ctx context.Context, itemID int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	var first *models.ItemPhoto
+	for _, photo := range t.photos {
+		if photo.ItemID != itemID {
+			continue
+		}
+		if photo.IsCover {
+			return nil
+		}
+		if first == nil || photo.Position < first.Position || (photo.Position == first.Position && photo.ID < first.ID) {
+			p := photo
+			first = &p
+		}
+	}
+	if first == nil {
+		return nil
+	}
+
+	first.IsCover = true
+	t.photos[first.ID] = *first
+	t.touchItem(itemID, time.Now())
+
+	return nil
+}
+
+// LockIDsByItemID returns the IDs of the photos of an item; transactions are
+// serialized, so they cannot change until the transaction ends
+func (r *ItemPhotoRepository) LockIDsByItemID(ctx context.Context, itemID int) ([]int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var ids []int
+	for id, photo := range r.db.tables.photos {
+		if photo.ItemID == itemID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+// UpdatePositions sets the position of each photo to its index in ids
+func (r *ItemPhotoRepository) UpdatePositions(ctx context.Context, itemID int, ids []int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	now := time.Now()
+	for position, id := range ids {
+		photo, ok := t.photos[id]
+		if !ok || photo.ItemID != itemID {
+			continue
+		}
+		photo.Position = position
+		photo.UpdatedAt = now
+		t.photos[id] = photo
+		t.touchItem(itemID, now)
+	}
+
+	return nil
+}
+
+// SetCover makes the photo the cover of its item, clearing the previous cover
+func (r *ItemPhotoRepository) SetCover(ctx context.Context, itemID int, photoID int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	now := time.Now()
+	for id, photo := range t.photos {
+		if photo.ItemID == itemID && photo.IsCover && id != photoID {
+			photo.IsCover = false
+			photo.UpdatedAt = now
+			t.photos[id] = photo
+			t.touchItem(itemID, now)
+		}
+	}
+
+	photo, ok := t.photos[photoID]
+	if !ok || photo.ItemID != itemID {
+		return sql.ErrNoRows
+	}
+	photo.IsCover = true
+	photo.UpdatedAt = now
+	t.photos[photoID] = photo
+	t.touchItem(itemID, now)
+
+	return nil
+}
+
+// FindSimilar returns the stored photo closest to the perceptual hash among photos of
+// items not authored by excludeAuthorID, or nil if none is within maxDistance bits
+func (r *ItemPhotoRepository) FindSimilar(ctx context.Context, hash int64, excludeAuthorID int, maxDistance int) (*models.SimilarPhoto, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	bands, narrowed := imaging.NearBandValues(uint64(hash), maxDistance)
+
+	var best *models.SimilarPhoto
+	for _, photo := range t.photos {
+		if photo.PHash == nil || t.items[photo.ItemID].AuthorID == excludeAuthorID {
+			continue
+		}
+		if narrowed && !matchesBand(uint64(*photo.PHash), bands) {
+			continue
+		}
+		distance := bits.OnesCount64(uint64(*photo.PHash ^ hash))
+		if distance > maxDistance {
+			continue
+		}
+		if best == nil || distance < best.Distance || (distance == best.Distance && photo.ID < best.ID) {
+			best = &models.SimilarPhoto{ID: photo.ID, ItemID: photo.ItemID, Distance: distance}
+		}
+	}
+
+	return best, nil
+}
+
+// matchesBand reports whether a band of the hash is among the band values, like the
+// band indexes of the PostgreSQL repository
+func matchesBand(hash uint64, bands [imaging.HashBands][]uint16) bool {
+	for i, values := range bands {
+		for _, value := range values {
+			if imaging.HashBand(hash, i) == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetFlagged retrieves photos flagged as near-duplicates, newest first
+func (r *ItemPhotoRepository) GetFlagged(ctx context.Context) ([]models.FlaggedPhoto, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	var photos []models.FlaggedPhoto
+	for _, photo := range t.photos {
+		if photo.DuplicateOf == nil {
+			continue
+		}
+		original, ok := t.photos[*photo.DuplicateOf]
+		if !ok {
+			continue
+		}
+		photos = append(photos, models.FlaggedPhoto{
+			ID:                photo.ID,
+			ItemID:            photo.ItemID,
+			URL:               photo.URL,
+			AuthorID:          t.items[photo.ItemID].AuthorID,
+			DuplicateOfID:     original.ID,
+			DuplicateOfItemID: original.ItemID,
+			DuplicateOfURL:    original.URL,
+			CreatedAt:         photo.CreatedAt,
+		})
+	}
+	sort.Slice(photos, func(i, j int) bool {
+		if !photos[i].CreatedAt.Equal(photos[j].CreatedAt) {
+			return photos[i].CreatedAt.After(photos[j].CreatedAt)
+		}
+		return photos[i].ID > photos[j].ID
+	})
+
+	return photos, nil
+}
+
+// UpdateHash stores the perceptual hash of a photo
+func (r *ItemPhotoRepository) UpdateHash(ctx context.Context, id int, hash int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	photo, ok := t.photos[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	photo.PHash = &hash
+	photo.UpdatedAt = time.Now()
+	t.photos[id] = photo
+
+	return nil
+}
+
+// insertPhoto stores a new photo and touches its item
+func (t *tables) insertPhoto(photo models.ItemPhoto, now time.Time) {
+	t.lastPhotoID++
+	photo.ID = t.lastPhotoID
+	photo.Variants = models.PhotoVariants{}
+	photo.CreatedAt = now
+	photo.UpdatedAt = now
+	t.photos[photo.ID] = photo
+	t.touchItem(photo.ItemID, now)
+}
+
+// deletePhotos deletes photos and touches their items. Photos flagged as duplicates
+// of them lose the flag, like ON DELETE SET NULL. It returns how many were deleted.
+func (t *tables) deletePhotos(ids []int, now time.Time) int {
+	deleted := map[int]bool{}
+	for _, id := range ids {
+		photo, ok := t.photos[id]
+		if !ok || deleted[id] {
+			continue
+		}
+		delete(t.photos, id)
+		deleted[id] = true
+		t.touchItem(photo.ItemID, now)
+	}
+
+	for id, photo := range t.photos {
+		if photo.DuplicateOf != nil && deleted[*photo.DuplicateOf] {
+			photo.DuplicateOf = nil
+			t.photos[id] = photo
+		}
+	}
+
+	return len(deleted)
+}
+
+// itemPhotos returns the photos of an item in display order: the cover first, then by position
+func (t *tables) itemPhotos(itemID int) []models.ItemPhoto {
+	photos := []models.ItemPhoto{}
+	for _, photo := range t.photos {
+		if photo.ItemID == itemID {
+			photos = append(photos, copyPhoto(photo))
+		}
+	}
+	sort.Slice(photos, func(i, j int) bool {
+		a, b := photos[i], photos[j]
+		if a.IsCover != b.IsCover {
+			return a.IsCover
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.ID < b.ID
+	})
+	return photos
+}
+
+// copyPhoto copies a photo so callers cannot change the stored one
+func copyPhoto(photo models.ItemPhoto) models.ItemPhoto {
+	photo.Variants = copyVariants(photo.Variants)
+	photo.PHash = copyInt64(photo.PHash)
+	photo.DuplicateOf = copyInt(photo.DuplicateOf)
+	return photo
+}
+
+// copyVariants copies photo variants; nil becomes empty, as stored JSONB is never null
+func copyVariants(variants models.PhotoVariants) models.PhotoVariants {
+	c := make(models.PhotoVariants, len(variants))
+	for name, url := range variants {
+		c[name] = url
+	}
+	return c
+}
+
+// copyInt64 copies an optional 64-bit integer
+func copyInt64(v *int64) *int64 {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"shary_be/internal/models"
+	"shary_be/internal/repository"
+)
+
+// PrivatePhotoRepository stores the owners of private photos in a DB
+type PrivatePhotoRepository struct {
+	db *DB
+}
+
+// NewPrivatePhotoRepository creates a new private photo repository
+func NewPrivatePhotoRepository(db *DB) *PrivatePhotoRepository {
+	return &PrivatePhotoRepository{db: db}
+}
+
+// Create records the owner of a private photo
+func (r *PrivatePhotoRepository) Create(ctx context.Context, photo *models.PrivatePhotoRecord) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	if _, ok := t.users[photo.OwnerID]; !ok {
+		return repository.ErrUnknownOwner
+	}
+	if _, ok := t.privatePhotos[photo.Key]; ok {
+		return uniqueError("private_photos", "key")
+	}
+
+	photo.CreatedAt = time.Now()
+	t.privatePhotos[photo.Key] = *photo
+
+	return nil
+}
+
+// GetByKey retrieves the record of a private photo.
+// It returns sql.ErrNoRows if the photo is not recorded.
+func (r *PrivatePhotoRepository) GetByKey(ctx context.Context, key string) (*models.PrivatePhotoRecord, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	photo, ok := r.db.tables.privatePhotos[key]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &photo, nil
+}
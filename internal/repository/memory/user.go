@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"shary_be/internal/models"
+)
+
+// UserRepository stores users in a DB
+type UserRepository struct {
+	db *DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Create adds a new user
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	for _, existing := range t.users {
+		if existing.Identity == user.Identity {
+			return uniqueError("users", "identity")
+		}
+	}
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	t.lastUserID++
+	user.ID = t.lastUserID
+	t.users[user.ID] = copyUser(*user)
+
+	return nil
+}
+
+// IdentityExists checks whether a user with the given identity number exists
+func (r *UserRepository) IdentityExists(ctx context.Context, identity string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, user := range r.db.tables.users {
+		if user.Identity == identity {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.tables.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	user = copyUser(user)
+	return &user, nil
+}
+
+// LockByID checks that a user exists; transactions are serialized, so the user
+// cannot change until the transaction ends
+func (r *UserRepository) LockByID(ctx context.Context, id int) error {
+	_, err := r.GetByID(ctx, id)
+	return err
+}
+
+// Update saves the editable profile fields of a user
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t := &r.db.tables
+
+	stored, ok := t.users[user.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	user.UpdatedAt = time.Now()
+
+	stored.FirstName = user.FirstName
+	stored.LastName = user.LastName
+	stored.Phone = copyString(user.Phone)
+	stored.AvatarURL = copyString(user.AvatarURL)
+	stored.UpdatedAt = user.UpdatedAt
+	t.users[user.ID] = stored
+
+	return nil
+}
+
+// copyUser copies a user so callers cannot change the stored one
+func copyUser(user models.User) models.User {
+	user.Phone = copyString(user.Phone)
+	user.AvatarURL = copyString(user.AvatarURL)
+	return user
+}
+
+// copyString copies an optional string
+func copyString(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"shary_be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrUnknownOwner is returned by Create when the owner of a private photo is not a user
+var ErrUnknownOwner = errors.New("private photo owner does not exist")
+
+// PrivatePhotoRepository handles database operations for the owners of private photos
+type PrivatePhotoRepository struct {
+	db *sqlx.DB
+}
+
+// NewPrivatePhotoRepository creates a new private photo repository
+func NewPrivatePhotoRepository(db *sqlx.DB) *PrivatePhotoRepository {
+	return &PrivatePhotoRepository{db: db}
+}
+
+// Create records the owner of a private photo
+func (r *PrivatePhotoRepository) Create(ctx context.Context, photo *models.PrivatePhotoRecord) error {
+	query := `INSERT INTO private_photos (key, owner_id, created_at) VALUES ($1, $2, $3)`
+
+	photo.CreatedAt = time.Now()
+
+	_, err := querierFrom(ctx, r.db).ExecContext(ctx, query, photo.Key, photo.OwnerID, photo.CreatedAt)
+	if isForeignKeyViolation(err, "private_photos_owner_id_fkey") {
+		return ErrUnknownOwner
+	}
+	return err
+}
+
+// GetByKey retrieves the record of a private photo.
+// It returns sql.ErrNoRows if the photo is not recorded.
+func (r *PrivatePhotoRepository) GetByKey(ctx context.Context, key string) (*models.PrivatePhotoRecord, error) {
+	var photo models.PrivatePhotoRecord
+	query := `SELECT key, owner_id, created_at FROM private_photos WHERE key = $1`
+
+	err := querierFrom(ctx, r.db).GetContext(ctx, &photo, query, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &photo, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// txKey is the context key of the transaction started by TxManager.WithinTx
+type txKey struct{}
+
+// querier runs queries on the database or in a transaction
+type querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// querierFrom returns the transaction ctx carries, or db when it carries none
+func querierFrom(ctx context.Context, db *sqlx.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// TxManager runs units of work in database transactions
+type TxManager struct {
+	db *sqlx.DB
+}
+
+// NewTxManager creates a new transaction manager
+func NewTxManager(db *sqlx.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// WithinTx runs fn in a transaction, which is committed when fn returns nil and rolled
+// back otherwise. Repository methods called with the context passed to fn run in the
+// transaction; a WithinTx nested in another one joins the outer transaction.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, m.db, fn)
+}
+
+// withinTx implements TxManager.WithinTx for repositories that need a transaction of their own
+func withinTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// withSavepoint runs fn so that, when it fails inside a transaction, only its changes
+// are rolled back and the transaction can go on. PostgreSQL otherwise refuses every
+// further statement of a transaction after an error, e.g. after a unique violation
+// the caller wants to recover from. Outside a transaction fn simply runs.
+func withSavepoint(ctx context.Context, db *sqlx.DB, fn func() error) error {
+	tx, ok := ctx.Value(txKey{}).(*sqlx.Tx)
+	if !ok {
+		return fn()
+	}
+
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT recoverable`); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	if err := fn(); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT recoverable`); rollbackErr != nil {
+			return fmt.Errorf("failed to roll back to savepoint: %w", rollbackErr)
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT recoverable`); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a violation of the named unique constraint
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// isForeignKeyViolation reports whether err is a violation of the named foreign key
+func isForeignKeyViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == constraint
+}
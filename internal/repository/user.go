@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"shary_be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// UserRepository handles database operations for users
+type UserRepository struct {
+	db *sqlx.DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *sqlx.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Create adds a new user
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	query := `
+		INSERT INTO users (first_name, last_name, identity, phone, avatar_url, verified, is_admin, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	return querierFrom(ctx, r.db).QueryRowContext(ctx, query,
+		user.FirstName,
+		user.LastName,
+		user.Identity,
+		user.Phone,
+		user.AvatarURL,
+		user.Verified,
+		user.IsAdmin,
+		user.CreatedAt,
+		user.UpdatedAt,
+	).Scan(&user.ID)
+}
+
+// IdentityExists checks whether a user with the given identity number exists
+func (r *UserRepository) IdentityExists(ctx context.Context, identity string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE identity = $1)`
+
+	err := querierFrom(ctx, r.db).GetContext(ctx, &exists, query, identity)
+	return exists, err
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	var user models.User
+	query := `
+		SELECT id, first_name, last_name, identity, phone, avatar_url,
+			COALESCE(verified, false) AS verified, is_admin, created_at, updated_at
+		FROM users
+		WHERE id = $1`
+
+	if err := querierFrom(ctx, r.db).GetContext(ctx, &user, query, id); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LockByID locks a user's row until the transaction ends
+func (r *UserRepository) LockByID(ctx context.Context, id int) error {
+	var lockedID int
+	query := `SELECT id FROM users WHERE id = $1 FOR UPDATE`
+
+	return querierFrom(ctx, r.db).GetContext(ctx, &lockedID, query, id)
+}
+
+// Update saves the editable profile fields of a user
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users
+		SET first_name = $1, last_name = $2, phone = $3, avatar_url = $4, updated_at = $5
+		WHERE id = $6`
+
+	user.UpdatedAt = time.Now()
+
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, query,
+		user.FirstName,
+		user.LastName,
+		user.Phone,
+		user.AvatarURL,
+		user.UpdatedAt,
+		user.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/caller"
+	"shary_be/internal/handlers"
+	"shary_be/internal/idempotency"
+	"shary_be/internal/models"
+	"shary_be/internal/openapi"
+	"shary_be/internal/service"
+)
+
+// Paths the API description is served at
+const (
+	specPath   = "/api/openapi.json"
+	docsPath   = "/api/docs"
+	assetsPath = "/api/docs/assets"
+)
+
+// Spec describes every route registered by SetupRouter. Request and response schemas
+// are generated from the models, so they follow the structs; routes and status codes
+// are listed here and TestSpec_CoversRoutes fails when a route is missing.
+func Spec() *openapi.Document {
+	d := openapi.NewDocument("Shary API", "1.0.0")
+	d.Info.Description = "Rental marketplace API. Errors are sent as {\"error\": {...}} with a stable code."
+	d.Name(apperror.Response{}, "Error")
+	d.Name(apperror.Body{}, "ErrorBody")
+
+	errorSchema := d.Schema(apperror.Response{})
+	op := func(tag string, summary string) *openapi.Operation {
+		return openapi.NewOperation(tag, summary).Default("Error", errorSchema)
+	}
+
+	str := openapi.Primitive("string")
+	integer := openapi.Primitive("integer")
+	langQuery := func(o *openapi.Operation) *openapi.Operation {
+		return o.Query("lang", "string", "Response language; overrides the Accept-Language header")
+	}
+	ifNoneMatch := func(o *openapi.Operation) *openapi.Operation {
+		return o.Header("If-None-Match", "ETag of a cached representation").
+			Returns(http.StatusNotModified, "The cached representation is current", nil)
+	}
+	idempotent := func(o *openapi.Operation) *openapi.Operation {
+		return o.Header(idempotency.Header, "Unique key; a retry with the same key by the same user gets the first response replayed (for 24 hours by default). Requires "+caller.Header+".").
+			Header(caller.GatewaySecretHeader, "Shared secret of the gateway, sent with "+caller.Header).
+			Returns(http.StatusUnauthorized, "The request has a key but names no user, or the user was not named by the gateway", errorSchema).
+			Returns(http.StatusConflict, "The key was used for a different request or that request is still in progress", errorSchema)
+	}
+	byUser := func(o *openapi.Operation) *openapi.Operation {
+		return o.Header(caller.Header, "ID of the user making the request, set by the gateway").
+			Header(caller.GatewaySecretHeader, "Shared secret of the gateway, sent with "+caller.Header).
+			Returns(http.StatusUnauthorized, "The request names no user, or the user was not named by the gateway", errorSchema)
+	}
+	ifMatch := func(o *openapi.Operation) *openapi.Operation {
+		return o.Header("If-Match", "ETag the change is based on; without it the change is applied unconditionally").
+			Returns(http.StatusPreconditionFailed, "The resource has changed since it was read", errorSchema)
+	}
+
+	// Service
+	d.Add("GET", "/health", op("service", "Health check").
+		Returns(http.StatusOK, "Service is up", openapi.Object(map[string]*openapi.Schema{
+			"status":    str,
+			"timestamp": {Type: "string", Format: "date-time"},
+		})))
+	d.Add("GET", "/uploads/{path}", op("service", "Download a locally stored upload").
+		ReturnsContent(http.StatusOK, "File contents", "application/octet-stream", &openapi.Schema{Type: "string", Format: "binary"}))
+	d.Add("HEAD", "/uploads/{path}", op("service", "Check a locally stored upload").
+		Returns(http.StatusOK, "File exists", nil))
+	d.Add("GET", specPath, op("service", "OpenAPI description of the API").
+		Returns(http.StatusOK, "OpenAPI document", &openapi.Schema{Type: "object"}))
+	d.Add("GET", docsPath, op("service", "Interactive API documentation").
+		ReturnsContent(http.StatusOK, "Documentation page", "text/html", str))
+	d.Add("GET", assetsPath+"/{file}", op("service", "Scripts and styles of the documentation page").
+		ReturnsContent(http.StatusOK, "Asset contents", "application/octet-stream", &openapi.Schema{Type: "string", Format: "binary"}).
+		Returns(http.StatusNotFound, "Asset not found", nil))
+
+	// Items
+	item := d.Schema(models.Item{})
+	itemResponse := d.Schema(models.ItemResponse{})
+	itemList := func(extra string, extraSchema *openapi.Schema) *openapi.Schema {
+		return openapi.Object(map[string]*openapi.Schema{
+			"items": openapi.ArrayOf(itemResponse),
+			"count": integer,
+			extra:   extraSchema,
+		})
+	}
+
+	d.Add("GET", "/api/v1/items", langQuery(op("items", "List items").
+		Query("min_price", "integer", "Minimum price").
+		Query("max_price", "integer", "Maximum price").
+		Query("location", "string", "Location substring").
+		Query("search", "string", "Full-text search in title and description").
+		Query("category_id", "integer", "Category, including its subcategories").
+		Query("limit", "integer", "Page size").
+		Query("offset", "integer", "Number of items to skip")).
+		Returns(http.StatusOK, "Matching items; attributes are filtered with attr.<key>, attr.<key>.min and attr.<key>.max",
+			itemList("filters", d.Schema(models.ItemFilter{}))))
+	d.Add("POST", "/api/v1/items", idempotent(op("items", "Create an item")).
+		Body(d.Schema(models.CreateItemRequest{})).
+		Returns(http.StatusCreated, "Created item", item))
+	d.Add("GET", "/api/v1/items/location/{location}", langQuery(op("items", "List items by location")).
+		Returns(http.StatusOK, "Items at the location", itemList("location", str)))
+	d.Add("GET", "/api/v1/items/category/{category_id}", langQuery(op("items", "List items in a category")).
+		Returns(http.StatusOK, "Items in the category", itemList("category", integer)))
+	d.Add("GET", "/api/v1/items/{id}", ifNoneMatch(langQuery(op("items", "Get an item"))).
+		Returns(http.StatusOK, "Item", itemResponse))
+	d.Add("PUT", "/api/v1/items/{id}", ifMatch(langQuery(op("items", "Replace an item"))).
+		Body(d.Schema(models.ReplaceItemRequest{})).
+		Returns(http.StatusOK, "Updated item", itemResponse))
+	d.Add("PATCH", "/api/v1/items/{id}", ifMatch(langQuery(op("items", "Update an item with a JSON Merge Patch"))).
+		BodyContent(handlers.MergePatchContentType, mergePatch(d.Schema(models.ReplaceItemRequest{}))).
+		Returns(http.StatusOK, "Updated item", itemResponse))
+	d.Add("DELETE", "/api/v1/items/{id}", ifMatch(op("items", "Delete an item")).
+		Returns(http.StatusNoContent, "Item deleted", nil))
+
+	// Item photos
+	itemPhotos := openapi.Object(map[string]*openapi.Schema{
+		"item_id": integer,
+		"photos":  openapi.ArrayOf(d.Schema(models.ItemPhoto{})),
+	})
+	photoURLs := openapi.Object(map[string]*openapi.Schema{
+		"item_id": integer,
+		"photos":  openapi.ArrayOf(&openapi.Schema{Type: "string", Format: "uri"}),
+	})
+
+	d.Add("GET", "/api/v1/item_photos/flagged", op("item photos", "List photos flagged as near-duplicates").
+		Returns(http.StatusOK, "Flagged photos", openapi.Object(map[string]*openapi.Schema{
+			"photos": openapi.ArrayOf(d.Schema(models.FlaggedPhoto{})),
+			"count":  integer,
+		})))
+	d.Add("GET", "/api/v1/item_photos/{item_id}/photos", op("item photos", "List an item's photos").
+		Returns(http.StatusOK, "Photos in display order", openapi.Object(map[string]*openapi.Schema{
+			"photos": openapi.ArrayOf(d.Schema(models.ItemPhoto{})),
+		})))
+	d.Add("POST", "/api/v1/item_photos/{item_id}/photos", idempotent(op("item photos", "Add photos by URL")).
+		Body(d.Schema(models.CreateItemPhotoRequest{})).
+		Returns(http.StatusCreated, "Photos added", photoURLs))
+	d.Add("POST", "/api/v1/item_photos/{item_id}/photos/upload", op("item photos", "Upload photo files").
+		Multipart("photos[]").
+		Returns(http.StatusCreated, "Photos stored", photoURLs))
+	d.Add("DELETE", "/api/v1/item_photos/{item_id}/photos", op("item photos", "Delete photos").
+		Body(d.Schema(models.DeleteItemPhotosRequest{})).
+		Returns(http.StatusNoContent, "Photos deleted", nil))
+	d.Add("PUT", "/api/v1/item_photos/{item_id}/photos/order", op("item photos", "Reorder photos").
+		Body(d.Schema(models.ReorderItemPhotosRequest{})).
+		Returns(http.StatusOK, "Photos in their new order", itemPhotos))
+	d.Add("PUT", "/api/v1/item_photos/{item_id}/photos/{photo_id}/cover", op("item photos", "Make a photo the cover").
+		Returns(http.StatusOK, "Photos with the cover first", itemPhotos))
+	d.Add("GET", "/api/v1/item_photos/{item_id}/photos/count", op("item photos", "Count an item's photos").
+		Returns(http.StatusOK, "Number of photos", openapi.Object(map[string]*openapi.Schema{
+			"count": integer,
+		})))
+
+	// Private photos
+	d.Add("POST", "/api/v1/private_photos", byUser(op("private photos", "Upload a private photo")).
+		Multipart("photo").
+		Returns(http.StatusCreated, "Stored photo with a signed download URL", d.Schema(service.PrivatePhoto{})))
+	d.Add("GET", "/api/v1/private_photos/url", byUser(op("private photos", "Get a new signed URL for a private photo the user stored")).
+		Query("key", "string", "Photo key, as returned when the photo was stored").
+		Returns(http.StatusOK, "Photo with a new signed download URL", d.Schema(service.PrivatePhoto{})))
+	d.Add("GET", "/api/v1/private_photos/{key}", op("private photos", "Download a private photo through a signed URL").
+		Query("expires", "integer", "Expiry as a Unix timestamp, from the signed URL").
+		Query("signature", "string", "Signature, from the signed URL").
+		ReturnsContent(http.StatusOK, "Photo contents", "image/*", &openapi.Schema{Type: "string", Format: "binary"}))
+
+	// Categories
+	category := d.Schema(models.Category{})
+	translation := d.Schema(models.CategoryTranslation{})
+
+	d.Add("GET", "/api/v1/categories", langQuery(op("categories", "List categories")).
+		Returns(http.StatusOK, "Categories", openapi.ArrayOf(category)))
+	d.Add("POST", "/api/v1/categories", idempotent(op("categories", "Create a category")).
+		Body(d.Schema(models.CreateCategoryRequest{})).
+		Returns(http.StatusCreated, "Created category", category))
+	d.Add("GET", "/api/v1/categories/tree", langQuery(op("categories", "Get the category tree")).
+		Returns(http.StatusOK, "Root categories with their subcategories", openapi.ArrayOf(d.Schema(models.CategoryTreeNode{}))))
+	d.Add("GET", "/api/v1/categories/slug/{slug}", ifNoneMatch(langQuery(op("categories", "Get a category by slug"))).
+		Returns(http.StatusOK, "Category", category))
+	d.Add("GET", "/api/v1/categories/{id}", ifNoneMatch(langQuery(op("categories", "Get a category"))).
+		Returns(http.StatusOK, "Category", category))
+	d.Add("PUT", "/api/v1/categories/{id}", ifMatch(op("categories", "Replace a category")).
+		Body(d.Schema(models.ReplaceCategoryRequest{})).
+		Returns(http.StatusOK, "Updated category", category))
+	d.Add("PATCH", "/api/v1/categories/{id}", ifMatch(op("categories", "Update a category with a JSON Merge Patch")).
+		BodyContent(handlers.MergePatchContentType, mergePatch(d.Schema(models.ReplaceCategoryRequest{}))).
+		Returns(http.StatusOK, "Updated category", category))
+	d.Add("DELETE", "/api/v1/categories/{id}", ifMatch(op("categories", "Delete a category")).
+		Query("reassign_to", "integer", "Category to move the deleted category's items to").
+		Returns(http.StatusNoContent, "Category deleted", nil))
+	d.Add("POST", "/api/v1/categories/{id}/merge", op("categories", "Merge a category into another").
+		Body(d.Schema(models.MergeCategoryRequest{})).
+		Returns(http.StatusOK, "Merge result", d.Schema(models.MergeCategoryResult{})))
+	d.Add("GET", "/api/v1/categories/{id}/translations", op("categories", "List a category's translations").
+		Returns(http.StatusOK, "Translations", openapi.Object(map[string]*openapi.Schema{
+			"translations": openapi.ArrayOf(translation),
+		})))
+	d.Add("PUT", "/api/v1/categories/{id}/translations/{lang}", op("categories", "Create or replace a translation").
+		Body(d.Schema(models.UpsertCategoryTranslationRequest{})).
+		Returns(http.StatusOK, "Translation", translation))
+	d.Add("DELETE", "/api/v1/categories/{id}/translations/{lang}", op("categories", "Delete a translation").
+		Returns(http.StatusNoContent, "Translation deleted", nil))
+
+	// Users
+	user := d.Schema(models.User{})
+
+	d.Add("GET", "/api/v1/users/{id}", op("users", "Get a user").
+		Returns(http.StatusOK, "User", user))
+	d.Add("PUT", "/api/v1/users/{id}", op("users", "Replace a user's profile").
+		Body(d.Schema(models.ReplaceUserRequest{})).
+		Returns(http.StatusOK, "Updated user", user))
+	d.Add("PATCH", "/api/v1/users/{id}", op("users", "Update a user's profile with a JSON Merge Patch").
+		BodyContent(handlers.MergePatchContentType, mergePatch(d.Schema(models.ReplaceUserRequest{}))).
+		Returns(http.StatusOK, "Updated user", user))
+
+	// Legacy unversioned paths
+	d.AddDeprecatedAliases("/api/v1", "/api")
+
+	return d
+}
+
+// mergePatch describes a JSON Merge Patch of a resource's editable representation:
+// any subset of its members, with null clearing a member
+func mergePatch(representation *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{
+		Type:        "object",
+		Description: "JSON Merge Patch (RFC 7396) of " + strings.TrimPrefix(representation.Ref, "#/components/schemas/") + "; null clears a field",
+	}
+}
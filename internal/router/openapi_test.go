@@ -0,0 +1,98 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// paramPattern matches path parameters in both chi routes and OpenAPI paths
+var paramPattern = regexp.MustCompile(`\{[^}]*\}|\*$`)
+
+// normalizePath makes chi route patterns and OpenAPI paths comparable
+func normalizePath(path string) string {
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return paramPattern.ReplaceAllString(path, "{}")
+}
+
+func TestSpec_CoversRoutes(t *testing.T) {
+	// Handlers are never called, so nil ones are enough to register every route
+	r := SetupRouter(nil, nil, nil, nil, nil, http.NotFoundHandler(), nil, "", time.Time{}, zap.NewNop())
+
+	spec := Spec()
+	described := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for method := range *item {
+			described[strings.ToUpper(method)+" "+normalizePath(path)] = true
+		}
+	}
+
+	routes := 0
+	err := chi.Walk(r.(chi.Routes), func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes++
+		if key := method + " " + normalizePath(route); !described[key] {
+			t.Errorf("route %s %s is missing from the OpenAPI spec", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking routes: %v", err)
+	}
+	if routes == 0 {
+		t.Fatal("no routes were registered")
+	}
+}
+
+func TestSpec_ServedAsJSON(t *testing.T) {
+	r := SetupRouter(nil, nil, nil, nil, nil, nil, nil, "", time.Time{}, zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", specPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want 200", specPath, rec.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("decoding spec: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", doc.OpenAPI)
+	}
+	for _, name := range []string{"Item", "CreateItemRequest", "Category", "Error"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s is missing from the spec", name)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", docsPath, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), specPath) {
+		t.Errorf("GET %s = %d, want a page loading %s", docsPath, rec.Code, specPath)
+	}
+	if !strings.Contains(rec.Body.String(), assetsPath+"/swagger-ui-bundle.js") || strings.Contains(rec.Body.String(), "https://") {
+		t.Errorf("GET %s loads assets from elsewhere than %s", docsPath, assetsPath)
+	}
+
+	for _, file := range []string{"swagger-ui-bundle.js", "swagger-ui.css"} {
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", assetsPath+"/"+file, nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s/%s status = %d, want the vendored file", assetsPath, file, rec.Code)
+		}
+	}
+}
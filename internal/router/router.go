@@ -1,6 +1,7 @@
 package router
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
@@ -8,24 +9,44 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 
+	"shary_be/internal/apperror"
+	"shary_be/internal/caller"
 	"shary_be/internal/handlers"
+	"shary_be/internal/openapi"
 )
 
+// apiPrefix is the path every API version is served under
+const apiPrefix = "/api"
+
+// legacyDeprecatedAt is when the unversioned /api paths were deprecated in favour of /api/v1
+var legacyDeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
 // SetupRouter creates and configures the Chi router with all routes and middleware
 func SetupRouter(
 	itemHandler *handlers.ItemHandler,
 	itemPhotoHandler *handlers.ItemPhotoHandler,
+	privatePhotoHandler *handlers.PrivatePhotoHandler,
 	categoryHandler *handlers.CategoryHandler,
+	userHandler *handlers.UserHandler,
+	uploadsHandler http.Handler,
+	idempotent func(http.Handler) http.Handler,
+	gatewaySecret string,
+	legacySunset time.Time,
 	logger *zap.Logger,
 ) http.Handler {
 	r := chi.NewRouter()
 
+	// Unknown routes get the same JSON error envelope as handler errors
+	r.NotFound(apperror.NotFoundHandler)
+	r.MethodNotAllowed(apperror.MethodNotAllowedHandler)
+
 	// Add Chi's built-in middleware
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(caller.Middleware(gatewaySecret))
 
 	// Add custom middleware
 	r.Use(loggingMiddleware(logger))
@@ -34,40 +55,92 @@ func SetupRouter(
 	// Health check endpoint
 	r.Get("/health", healthCheckHandler)
 
-	// Item routes
-	r.Route("/api", func(r chi.Router) {
-		r.Route("/items", func(r chi.Router) {
-			r.Get("/", itemHandler.GetAllItems)
-			r.Post("/", itemHandler.CreateItem)
-			r.Get("/location/{location}", itemHandler.GetItemsByLocation)
-			r.Get("/category/{category_id}", itemHandler.GetItemsByCategory)
-			r.Route("/{id}", func(r chi.Router) {
-				r.Get("/", itemHandler.GetItemByID)
-				r.Put("/", itemHandler.UpdateItem)
-				r.Delete("/", itemHandler.DeleteItem)
-			})
+	// Locally stored uploads, when the storage backend serves files itself
+	if uploadsHandler != nil {
+		uploads := http.StripPrefix("/uploads", uploadsHandler)
+		r.Method(http.MethodGet, "/uploads/*", uploads)
+		r.Method(http.MethodHead, "/uploads/*", uploads)
+	}
+
+	// API description and its documentation page
+	r.Method(http.MethodGet, specPath, openapi.Handler(Spec()))
+	r.Method(http.MethodGet, docsPath, openapi.DocsHandler("Shary API", specPath, assetsPath))
+	r.Method(http.MethodGet, assetsPath+"/*", http.StripPrefix(assetsPath, openapi.AssetsHandler()))
+
+	// Retried POST requests with an Idempotency-Key get the first response replayed
+	if idempotent == nil {
+		idempotent = func(next http.Handler) http.Handler { return next }
+	}
+
+	// Resources are served under /api/v1; register a v2 of a resource with
+	// versions.Register("v2", "/items", ...) and the other resources carry over
+	versions := NewVersions()
+
+	versions.Register("v1", "/items", func(r chi.Router) {
+		r.Get("/", itemHandler.GetAllItems)
+		r.With(idempotent).Post("/", itemHandler.CreateItem)
+		r.Get("/location/{location}", itemHandler.GetItemsByLocation)
+		r.Get("/category/{category_id}", itemHandler.GetItemsByCategory)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", itemHandler.GetItemByID)
+			r.Put("/", itemHandler.ReplaceItem)
+			r.Patch("/", itemHandler.PatchItem)
+			r.Delete("/", itemHandler.DeleteItem)
 		})
 	})
 
-	// Item photo routes
-	r.Route("/api/item_photos", func(r chi.Router) {
+	versions.Register("v1", "/item_photos", func(r chi.Router) {
+		r.Get("/flagged", itemPhotoHandler.GetFlaggedPhotos)
 		r.Get("/{item_id}/photos", itemPhotoHandler.GetPhotosByItemID)
-		r.Post("/{item_id}/photos", itemPhotoHandler.AddPhotos)
+		r.With(idempotent).Post("/{item_id}/photos", itemPhotoHandler.AddPhotos)
+		r.Post("/{item_id}/photos/upload", itemPhotoHandler.UploadPhotos)
 		r.Delete("/{item_id}/photos", itemPhotoHandler.DeletePhotos)
+		r.Put("/{item_id}/photos/order", itemPhotoHandler.ReorderPhotos)
+		r.Put("/{item_id}/photos/{photo_id}/cover", itemPhotoHandler.SetCoverPhoto)
 		r.Get("/{item_id}/photos/count", itemPhotoHandler.CountPhotosByItemID)
 	})
 
-	// Category routes
-	r.Route("/api/categories", func(r chi.Router) {
+	// Private photo downloads require a signed URL, which only the owner can renew
+	versions.Register("v1", "/private_photos", func(r chi.Router) {
+		r.Post("/", privatePhotoHandler.UploadPhoto)
+		r.Get("/url", privatePhotoHandler.RenewURL)
+		r.Get("/*", privatePhotoHandler.DownloadPhoto)
+	})
+
+	versions.Register("v1", "/categories", func(r chi.Router) {
 		r.Get("/", categoryHandler.GetAllCategories)
-		r.Post("/", categoryHandler.CreateCategory)
+		r.With(idempotent).Post("/", categoryHandler.CreateCategory)
+		r.Get("/tree", categoryHandler.GetCategoryTree)
+		r.Get("/slug/{slug}", categoryHandler.GetCategoryBySlug)
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", categoryHandler.GetCategoryByID)
-			r.Put("/", categoryHandler.UpdateCategory)
+			r.Put("/", categoryHandler.ReplaceCategory)
+			r.Patch("/", categoryHandler.PatchCategory)
 			r.Delete("/", categoryHandler.DeleteCategory)
+			r.Post("/merge", categoryHandler.MergeCategory)
+			r.Get("/translations", categoryHandler.GetTranslations)
+			r.Put("/translations/{lang}", categoryHandler.UpsertTranslation)
+			r.Delete("/translations/{lang}", categoryHandler.DeleteTranslation)
 		})
 	})
 
+	versions.Register("v1", "/users", func(r chi.Router) {
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", userHandler.GetUser)
+			r.Put("/", userHandler.ReplaceUser)
+			r.Patch("/", userHandler.PatchUser)
+		})
+	})
+
+	versions.Mount(r, apiPrefix)
+
+	// The unversioned paths used by the first mobile releases stay as aliases of v1
+	// until legacySunset
+	r.With(
+		deprecatedMiddleware(apiPrefix, apiPrefix+"/v1", legacyDeprecatedAt, legacySunset),
+		aliasMiddleware(apiPrefix, apiPrefix+"/v1"),
+	).Mount(apiPrefix, versions.Router("v1"))
+
 	return r
 }
 
@@ -105,7 +178,7 @@ func recoveryMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 						zap.String("method", r.Method),
 						zap.String("path", r.URL.Path),
 					)
-					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					apperror.Write(w, r, fmt.Errorf("panic: %v", err))
 				}
 			}()
 			next.ServeHTTP(w, r)
@@ -0,0 +1,97 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"shary_be/internal/idempotency"
+)
+
+// Versions collects the resources served by each API version. A version serves the
+// resources registered for it and falls back to the closest earlier version for the
+// rest, so a v2 can change a single resource while sharing everything else with v1.
+type Versions struct {
+	order     []string
+	resources map[string]map[string]func(chi.Router)
+}
+
+// NewVersions creates an empty set of API versions
+func NewVersions() *Versions {
+	return &Versions{resources: make(map[string]map[string]func(chi.Router))}
+}
+
+// Register adds the routes of a resource, e.g. "/items", to a version. Versions are
+// ordered by their first registration, so register v1 before v2.
+func (v *Versions) Register(version string, resource string, routes func(chi.Router)) {
+	if _, ok := v.resources[version]; !ok {
+		v.order = append(v.order, version)
+		v.resources[version] = make(map[string]func(chi.Router))
+	}
+	v.resources[version][resource] = routes
+}
+
+// List returns the registered versions, oldest first
+func (v *Versions) List() []string {
+	return append([]string(nil), v.order...)
+}
+
+// Router builds a router serving every resource available in the version
+func (v *Versions) Router(version string) chi.Router {
+	resolved := make(map[string]func(chi.Router))
+	for _, name := range v.order {
+		for resource, routes := range v.resources[name] {
+			resolved[resource] = routes
+		}
+		if name == version {
+			break
+		}
+	}
+
+	r := chi.NewRouter()
+	for resource, routes := range resolved {
+		r.Route(resource, routes)
+	}
+	return r
+}
+
+// Mount serves every version under prefix, e.g. /api/v1 and /api/v2
+func (v *Versions) Mount(r chi.Router, prefix string) {
+	for _, version := range v.order {
+		r.Mount(prefix+"/"+version, v.Router(version))
+	}
+}
+
+// deprecatedMiddleware marks responses from legacy paths as deprecated. Deprecation
+// and Sunset follow RFC 9745 and RFC 8594; the Link header points clients at the same
+// resource under its successor prefix.
+func deprecatedMiddleware(legacyPrefix string, successorPrefix string, deprecatedAt time.Time, sunset time.Time) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(deprecatedAt.Unix(), 10))
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			if rest, ok := strings.CutPrefix(r.URL.Path, legacyPrefix); ok {
+				w.Header().Set("Link", "<"+successorPrefix+rest+`>; rel="successor-version"`)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// aliasMiddleware makes requests to legacy paths count as requests to the same path
+// under the successor prefix, so an idempotency key is shared between both
+func aliasMiddleware(legacyPrefix string, successorPrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rest, ok := strings.CutPrefix(r.URL.Path, legacyPrefix); ok {
+				r = r.WithContext(idempotency.WithScopePath(r.Context(), successorPrefix+rest))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func respond(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}
+}
+
+func TestVersions_FallBackToEarlierVersions(t *testing.T) {
+	versions := NewVersions()
+	versions.Register("v1", "/items", func(r chi.Router) { r.Get("/", respond("items v1")) })
+	versions.Register("v1", "/categories", func(r chi.Router) { r.Get("/", respond("categories v1")) })
+	versions.Register("v2", "/items", func(r chi.Router) { r.Get("/", respond("items v2")) })
+
+	r := chi.NewRouter()
+	versions.Mount(r, "/api")
+
+	tests := map[string]string{
+		"/api/v1/items":      "items v1",
+		"/api/v1/categories": "categories v1",
+		"/api/v2/items":      "items v2",
+		"/api/v2/categories": "categories v1",
+	}
+	for path, want := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if got := rec.Body.String(); got != want {
+			t.Errorf("GET %s = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestSetupRouter_LegacyPathsAreDeprecated(t *testing.T) {
+	sunset := time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
+	r := SetupRouter(nil, nil, nil, nil, nil, nil, nil, "", sunset, zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/api/items/1/unknown", nil))
+	if got := rec.Header().Get("Deprecation"); got != "@1792281600" {
+		t.Errorf("Deprecation = %q, want @1792281600", got)
+	}
+	if got := rec.Header().Get("Sunset"); got != "Sun, 18 Apr 2027 00:00:00 GMT" {
+		t.Errorf("Sunset = %q, want Sun, 18 Apr 2027 00:00:00 GMT", got)
+	}
+	if got := rec.Header().Get("Link"); got != `</api/v1/items/1/unknown>; rel="successor-version"` {
+		t.Errorf("Link = %q, want the /api/v1 path", got)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/items/1/unknown", nil))
+	if got := rec.Header().Get("Deprecation"); got != "" {
+		t.Errorf("Deprecation on /api/v1 = %q, want none", got)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET unknown route status = %d, want 404", rec.Code)
+	}
+}
@@ -1,52 +1,127 @@
 package service
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/i18n"
 	"shary_be/internal/models"
 	"shary_be/internal/repository"
 
 	"go.uber.org/zap"
 )
 
+var (
+	// ErrCategoryNotFound is returned when the requested category does not exist
+	ErrCategoryNotFound = apperror.New(http.StatusNotFound, "category_not_found", "category not found")
+	// ErrTranslationNotFound is returned when a category has no name in the requested language
+	ErrTranslationNotFound = apperror.New(http.StatusNotFound, "translation_not_found", "translation not found")
+	// ErrParentCategoryNotFound is returned when the requested parent category does not exist
+	ErrParentCategoryNotFound = apperror.New(http.StatusBadRequest, "parent_category_not_found", "parent category not found")
+	// ErrCategoryCycle is returned when a category would become its own ancestor
+	ErrCategoryCycle = apperror.New(http.StatusBadRequest, "category_cycle", "category cannot be moved under itself or its descendants")
+	// ErrUnsupportedLanguage is returned when a translation is managed for a language
+	// that is not supported or is the default language stored in the category itself
+	ErrUnsupportedLanguage = apperror.New(http.StatusBadRequest, "unsupported_language", "unsupported translation language")
+	// ErrInvalidSlug is returned when a requested slug has no URL-safe characters
+	ErrInvalidSlug = apperror.New(http.StatusBadRequest, "invalid_slug", "invalid category slug")
+	// ErrSlugTaken is returned when a requested slug is already used by another category
+	ErrSlugTaken = apperror.New(http.StatusConflict, "slug_taken", "category slug already exists")
+	// ErrTargetCategoryNotFound is returned when the category to move items into does not exist
+	ErrTargetCategoryNotFound = apperror.New(http.StatusBadRequest, "target_category_not_found", "target category not found")
+	// ErrInvalidTargetCategory is returned when items would be moved into the category being removed
+	ErrInvalidTargetCategory = apperror.New(http.StatusBadRequest, "invalid_target_category", "target category must differ from the source category")
+	// ErrCategoryInUse is returned when a category cannot be deleted because items still
+	// reference it; the items_count detail holds how many
+	ErrCategoryInUse = apperror.New(http.StatusConflict, "category_in_use", "category is used by items")
+	// ErrIncompatibleAttributes is returned when items would be moved into a category whose
+	// attribute schema rejects their attributes; the item_ids detail lists the items
+	ErrIncompatibleAttributes = apperror.New(http.StatusConflict, "incompatible_attributes", "items have attributes the target category does not accept")
+)
+
+// categoryInUseError reports how many items keep a category from being deleted
+func categoryInUseError(itemsCount int) error {
+	return ErrCategoryInUse.
+		WithMessage(fmt.Sprintf("category is used by %d items", itemsCount)).
+		WithDetail("items_count", itemsCount)
+}
+
+// incompatibleAttributesError lists the items that cannot be moved into another category
+// and, as fields named after the item, the attributes its schema rejects
+func incompatibleAttributesError(itemIDs []int, fields []apperror.FieldError) error {
+	return ErrIncompatibleAttributes.
+		WithMessage(fmt.Sprintf("%d items have attributes the target category does not accept", len(itemIDs))).
+		WithDetail("item_ids", itemIDs).
+		WithFields(fields...)
+}
+
+// maxSlugAttempts bounds the numeric suffixes tried when a generated slug is taken
+const maxSlugAttempts = 100
+
 // CategoryService handles business logic for categories
 type CategoryService struct {
-	categoryRepo *repository.CategoryRepository
+	categoryRepo CategoryRepository
 	logger       *zap.Logger
+	txManager    TxManager
 }
 
-func NewCategoryService(categoryRepo *repository.CategoryRepository, logger *zap.Logger) *CategoryService {
+func NewCategoryService(categoryRepo CategoryRepository, logger *zap.Logger, txManager TxManager) *CategoryService {
 	return &CategoryService{
 		categoryRepo: categoryRepo,
 		logger:       logger,
+		txManager:    txManager,
 	}
 }
 
-// GetAll retrieves all categories
-func (s *CategoryService) GetAllCategories() ([]models.Category, error) {
-	categories, err := s.categoryRepo.GetAll()
+// GetAllCategories retrieves all categories with names in the given language
+func (s *CategoryService) GetAllCategories(ctx context.Context, lang string) ([]models.Category, error) {
+	categories, err := s.categoryRepo.GetAll(ctx, lang)
 	if err != nil {
 		s.logger.Error("Failed to get all categories", zap.Error(err))
 		return nil, err
 	}
 
+	models.AggregateItemCounts(categories)
+
 	return categories, nil
 }
 
 // CreateCategory adds a new category
-func (s *CategoryService) CreateCategory(req *models.CreateCategoryRequest) (*models.Category, error) {
+func (s *CategoryService) CreateCategory(ctx context.Context, req *models.CreateCategoryRequest) (*models.Category, error) {
 	// Validate request
 	if err := req.Validate(); err != nil {
 		s.logger.Error("Invalid create category request", zap.Error(err))
-		return nil, err
+		return nil, apperror.Validation(err)
+	}
+
+	if req.ParentID != nil {
+		if err := s.ensureParentExists(ctx, *req.ParentID); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create category
 	category := &models.Category{
-		Name: req.Name,
+		Name:            req.Name,
+		ParentID:        req.ParentID,
+		AttributeSchema: req.AttributeSchema,
 	}
 
-	if err := s.categoryRepo.Create(category); err != nil {
-		s.logger.Error("Failed to create category", zap.Error(err))
+	err := s.saveWithSlug(ctx, category, req.Slug, func(ctx context.Context) error {
+		if err := s.categoryRepo.Create(ctx, category); err != nil {
+			if !errors.Is(err, repository.ErrSlugExists) {
+				s.logger.Error("Failed to create category", zap.Error(err))
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -54,63 +129,513 @@ func (s *CategoryService) CreateCategory(req *models.CreateCategoryRequest) (*mo
 	return category, nil
 }
 
-// UpdateCategory updates an existing category
-func (s *CategoryService) UpdateCategory(id int, req *models.UpdateCategoryRequest) (*models.Category, error) {
-	if err := req.Validate(); err != nil {
-		s.logger.Error("Invalid update category request", zap.Error(err))
+// ReplaceCategory replaces the editable fields of a category. Fields missing from req
+// are cleared and an empty slug is generated from the name.
+func (s *CategoryService) ReplaceCategory(ctx context.Context, id int, req *models.ReplaceCategoryRequest, precondition Precondition) (*models.Category, error) {
+	return s.updateCategory(ctx, id, precondition, func(*models.ReplaceCategoryRequest) (*models.ReplaceCategoryRequest, error) {
+		return req, nil
+	})
+}
+
+// PatchCategory applies a JSON Merge Patch to a category. A null member clears the
+// field, e.g. "parent_id": null makes the category a root. Renaming a category without
+// setting its slug regenerates the slug from the new name.
+func (s *CategoryService) PatchCategory(ctx context.Context, id int, patch []byte, precondition Precondition) (*models.Category, error) {
+	return s.updateCategory(ctx, id, precondition, func(current *models.ReplaceCategoryRequest) (*models.ReplaceCategoryRequest, error) {
+		name, slug := current.Name, current.Slug
+		if err := applyPatch(current, patch); err != nil {
+			return nil, err
+		}
+		if current.Name != name && current.Slug == slug {
+			current.Slug = ""
+		}
+		return current, nil
+	})
+}
+
+// updateCategory saves the representation that change derives from the category's current one.
+// The category is locked meanwhile so concurrent patches are applied one after another, and
+// ErrVersionMismatch is returned if it no longer has a version the precondition accepts.
+func (s *CategoryService) updateCategory(ctx context.Context, id int, precondition Precondition, change func(current *models.ReplaceCategoryRequest) (*models.ReplaceCategoryRequest, error)) (*models.Category, error) {
+	var categoryToUpdate *models.Category
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		locked, err := s.categoryRepo.LockByIDs(ctx, []int{id})
+		if err != nil {
+			s.logger.Error("Failed to lock category for update", zap.Int("category_id", id), zap.Error(err))
+			return err
+		}
+
+		currentCategory := findCategory(locked, id)
+		if currentCategory == nil {
+			return ErrCategoryNotFound
+		}
+		if !precondition.Matches(currentCategory.Version) {
+			return versionMismatchError(currentCategory.Version)
+		}
+
+		req, err := change(currentCategory.ReplaceRequest())
+		if err != nil {
+			return err
+		}
+		if err := req.Validate(); err != nil {
+			s.logger.Error("Invalid category update", zap.Error(err))
+			return apperror.Validation(err)
+		}
+
+		// Existing items keep their attributes; they are checked against the new
+		// schema the next time they are updated
+		categoryToUpdate = &models.Category{
+			ID:              currentCategory.ID,
+			Name:            req.Name,
+			Slug:            currentCategory.Slug,
+			ParentID:        req.ParentID,
+			AttributeSchema: req.AttributeSchema,
+			CreatedAt:       currentCategory.CreatedAt,
+		}
+
+		if req.ParentID != nil {
+			if err := s.ensureValidParent(ctx, id, *req.ParentID); err != nil {
+				return err
+			}
+		}
+
+		update := func(ctx context.Context) error {
+			if err := s.categoryRepo.Update(ctx, categoryToUpdate); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrCategoryNotFound
+				}
+				if !errors.Is(err, repository.ErrSlugExists) {
+					s.logger.Error("Failed to update category", zap.Error(err))
+				}
+				return err
+			}
+			return nil
+		}
+
+		if req.Slug != currentCategory.Slug {
+			return s.saveWithSlug(ctx, categoryToUpdate, req.Slug, update)
+		}
+		return update(ctx)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	currentCategory, err := s.categoryRepo.GetByID(id)
+	s.logger.Info("Category updated successfully", zap.Int("category_id", id))
+	return categoryToUpdate, nil
+}
+
+// GetCategoryTree retrieves all categories arranged as a tree with names in the given language
+func (s *CategoryService) GetCategoryTree(ctx context.Context, lang string) ([]*models.CategoryTreeNode, error) {
+	categories, err := s.categoryRepo.GetAll(ctx, lang)
 	if err != nil {
-		s.logger.Error("Failed to get category for update", zap.Int("category_id", id), zap.Error(err))
+		s.logger.Error("Failed to get categories for tree", zap.Error(err))
 		return nil, err
 	}
-	if currentCategory == nil {
-		return nil, sql.ErrNoRows
+
+	models.AggregateItemCounts(categories)
+
+	return models.BuildCategoryTree(categories), nil
+}
+
+// DeleteCategory deletes a category by ID.
+// Items still assigned to the category are moved to reassignTo; when reassignTo is nil
+// and items exist, ErrCategoryInUse is returned, and ErrIncompatibleAttributes when
+// reassignTo does not accept their attributes. Subcategories are moved up to the
+// parent of the deleted category. ErrVersionMismatch is returned if the category no
+// longer has a version the precondition accepts.
+func (s *CategoryService) DeleteCategory(ctx context.Context, id int, reassignTo *int, precondition Precondition) error {
+	lockIDs := []int{id}
+	if reassignTo != nil {
+		if *reassignTo == id {
+			return ErrInvalidTargetCategory
+		}
+		lockIDs = append(lockIDs, *reassignTo)
 	}
 
-	categoryToUpdate := &models.Category{
-		ID:   currentCategory.ID,
-		Name: currentCategory.Name,
+	var itemsCount int
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		locked, err := s.categoryRepo.LockByIDs(ctx, lockIDs)
+		if err != nil {
+			s.logger.Error("Failed to lock categories for deletion", zap.Int("category_id", id), zap.Error(err))
+			return err
+		}
+
+		category := findCategory(locked, id)
+		if category == nil {
+			return ErrCategoryNotFound
+		}
+		if !precondition.Matches(category.Version) {
+			return versionMismatchError(category.Version)
+		}
+		if reassignTo != nil && findCategory(locked, *reassignTo) == nil {
+			return ErrTargetCategoryNotFound
+		}
+
+		itemsCount, err = s.categoryRepo.CountItems(ctx, id)
+		if err != nil {
+			s.logger.Error("Failed to count category items", zap.Int("category_id", id), zap.Error(err))
+			return err
+		}
+
+		if itemsCount > 0 {
+			if reassignTo == nil {
+				return categoryInUseError(itemsCount)
+			}
+
+			if err := s.checkMovedAttributes(ctx, id, findCategory(locked, *reassignTo)); err != nil {
+				return err
+			}
+			if _, err := s.categoryRepo.ReassignItems(ctx, id, *reassignTo); err != nil {
+				s.logger.Error("Failed to reassign category items", zap.Int("category_id", id), zap.Int("reassign_to", *reassignTo), zap.Error(err))
+				return err
+			}
+		}
+
+		if err := s.categoryRepo.ReparentChildren(ctx, id, category.ParentID); err != nil {
+			s.logger.Error("Failed to move subcategories", zap.Int("category_id", id), zap.Error(err))
+			return err
+		}
+
+		if err := s.categoryRepo.Delete(ctx, id); err != nil {
+			s.logger.Error("Failed to delete category", zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Category deleted successfully", zap.Int("category_id", id), zap.Int("items_reassigned", itemsCount))
+	return nil
+}
+
+// MergeCategory moves all items and subcategories of a category into another one
+// and deletes the source category, all within one transaction. ErrIncompatibleAttributes
+// is returned if the target category does not accept the attributes of the items.
+func (s *CategoryService) MergeCategory(ctx context.Context, sourceID int, req *models.MergeCategoryRequest) (*models.MergeCategoryResult, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid merge category request", zap.Error(err))
+		return nil, apperror.Validation(err)
 	}
 
-	if req.Name != nil {
-		categoryToUpdate.Name = *req.Name
+	targetID := req.TargetID
+	if targetID == sourceID {
+		return nil, ErrInvalidTargetCategory
 	}
 
-	if err := s.categoryRepo.Update(categoryToUpdate); err != nil {
-		s.logger.Error("Failed to update category", zap.Error(err))
+	var itemsMoved int
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		locked, err := s.categoryRepo.LockByIDs(ctx, []int{sourceID, targetID})
+		if err != nil {
+			s.logger.Error("Failed to lock categories for merge", zap.Int("source_id", sourceID), zap.Int("target_id", targetID), zap.Error(err))
+			return err
+		}
+
+		if findCategory(locked, sourceID) == nil {
+			return ErrCategoryNotFound
+		}
+		target := findCategory(locked, targetID)
+		if target == nil {
+			return ErrTargetCategoryNotFound
+		}
+
+		// Moving the source's children under one of its own descendants would create a cycle
+		descendantIDs, err := s.categoryRepo.GetDescendantIDs(ctx, sourceID)
+		if err != nil {
+			s.logger.Error("Failed to get category descendants", zap.Int("category_id", sourceID), zap.Error(err))
+			return err
+		}
+		for _, descendantID := range descendantIDs {
+			if descendantID == targetID {
+				return ErrCategoryCycle
+			}
+		}
+
+		if err := s.checkMovedAttributes(ctx, sourceID, target); err != nil {
+			return err
+		}
+		itemsMoved, err = s.categoryRepo.ReassignItems(ctx, sourceID, targetID)
+		if err != nil {
+			s.logger.Error("Failed to move category items", zap.Int("source_id", sourceID), zap.Int("target_id", targetID), zap.Error(err))
+			return err
+		}
+
+		if err := s.categoryRepo.ReparentChildren(ctx, sourceID, &targetID); err != nil {
+			s.logger.Error("Failed to move subcategories", zap.Int("source_id", sourceID), zap.Int("target_id", targetID), zap.Error(err))
+			return err
+		}
+
+		if err := s.categoryRepo.Delete(ctx, sourceID); err != nil {
+			s.logger.Error("Failed to delete merged category", zap.Int("source_id", sourceID), zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	s.logger.Info("Category updated successfully", zap.Int("category_id", id))
-	return categoryToUpdate, nil
+	s.logger.Info("Category merged successfully", zap.Int("source_id", sourceID), zap.Int("target_id", targetID), zap.Int("items_moved", itemsMoved))
+	return &models.MergeCategoryResult{
+		SourceID:   sourceID,
+		TargetID:   targetID,
+		ItemsMoved: itemsMoved,
+	}, nil
 }
 
-// DeleteCategory deletes a category by ID
-func (s *CategoryService) DeleteCategory(id int) error {
-	// Delete category
-	if err := s.categoryRepo.Delete(id); err != nil {
-		s.logger.Error("Failed to delete category", zap.Error(err))
-		return err
+// GetCategoryByID retrieves a category by ID with its name in the given language
+func (s *CategoryService) GetCategoryByID(ctx context.Context, id int, lang string) (*models.Category, error) {
+	category, err := s.categoryRepo.GetLocalizedByID(ctx, id, lang)
+	if err != nil {
+		s.logger.Error("Failed to get category by ID", zap.Int("category_id", id), zap.Error(err))
+		return nil, err
 	}
 
-	s.logger.Info("Category deleted successfully", zap.Int("category_id", id))
-	return nil
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	return category, nil
 }
 
-// GetCategoryByID retrieves a category by ID
-func (s *CategoryService) GetCategoryByID(id int) (*models.Category, error) {
-	category, err := s.categoryRepo.GetByID(id)
+// GetCategoryBySlug retrieves a category by slug with its name in the given language
+func (s *CategoryService) GetCategoryBySlug(ctx context.Context, slug string, lang string) (*models.Category, error) {
+	category, err := s.categoryRepo.GetLocalizedBySlug(ctx, slug, lang)
 	if err != nil {
-		s.logger.Error("Failed to get category by ID", zap.Int("category_id", id), zap.Error(err))
+		s.logger.Error("Failed to get category by slug", zap.String("slug", slug), zap.Error(err))
 		return nil, err
 	}
 
 	if category == nil {
-		return nil, sql.ErrNoRows
+		return nil, ErrCategoryNotFound
 	}
 
 	return category, nil
 }
+
+// GetTranslations retrieves all translations of a category
+func (s *CategoryService) GetTranslations(ctx context.Context, categoryID int) ([]models.CategoryTranslation, error) {
+	category, err := s.categoryRepo.GetByID(ctx, categoryID)
+	if err != nil {
+		s.logger.Error("Failed to get category for translations", zap.Int("category_id", categoryID), zap.Error(err))
+		return nil, err
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	translations, err := s.categoryRepo.GetTranslations(ctx, categoryID)
+	if err != nil {
+		s.logger.Error("Failed to get category translations", zap.Int("category_id", categoryID), zap.Error(err))
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// UpsertTranslation creates or replaces the name of a category in a non-default language
+func (s *CategoryService) UpsertTranslation(ctx context.Context, categoryID int, lang string, req *models.UpsertCategoryTranslationRequest) (*models.CategoryTranslation, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid category translation request", zap.Error(err))
+		return nil, apperror.Validation(err)
+	}
+
+	if !i18n.IsSupported(lang) || lang == i18n.Default {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	category, err := s.categoryRepo.GetByID(ctx, categoryID)
+	if err != nil {
+		s.logger.Error("Failed to get category for translation", zap.Int("category_id", categoryID), zap.Error(err))
+		return nil, err
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	translation := &models.CategoryTranslation{
+		CategoryID: categoryID,
+		Lang:       lang,
+		Name:       req.Name,
+	}
+
+	if err := s.categoryRepo.UpsertTranslation(ctx, translation); err != nil {
+		s.logger.Error("Failed to save category translation", zap.Int("category_id", categoryID), zap.String("lang", lang), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Category translation saved successfully", zap.Int("category_id", categoryID), zap.String("lang", lang))
+	return translation, nil
+}
+
+// DeleteTranslation deletes the name of a category in a non-default language
+func (s *CategoryService) DeleteTranslation(ctx context.Context, categoryID int, lang string) error {
+	if !i18n.IsSupported(lang) || lang == i18n.Default {
+		return ErrUnsupportedLanguage
+	}
+
+	if err := s.categoryRepo.DeleteTranslation(ctx, categoryID, lang); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTranslationNotFound
+		}
+		s.logger.Error("Failed to delete category translation", zap.Int("category_id", categoryID), zap.String("lang", lang), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Category translation deleted successfully", zap.Int("category_id", categoryID), zap.String("lang", lang))
+	return nil
+}
+
+// ensureParentExists checks that the parent category exists
+func (s *CategoryService) ensureParentExists(ctx context.Context, parentID int) error {
+	parent, err := s.categoryRepo.GetByID(ctx, parentID)
+	if err != nil {
+		s.logger.Error("Failed to get parent category", zap.Int("parent_id", parentID), zap.Error(err))
+		return err
+	}
+	if parent == nil {
+		return ErrParentCategoryNotFound
+	}
+
+	return nil
+}
+
+// ensureValidParent checks that moving the category under parentID does not create a cycle
+func (s *CategoryService) ensureValidParent(ctx context.Context, id, parentID int) error {
+	if id == parentID {
+		return ErrCategoryCycle
+	}
+
+	if err := s.ensureParentExists(ctx, parentID); err != nil {
+		return err
+	}
+
+	descendantIDs, err := s.categoryRepo.GetDescendantIDs(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to get category descendants", zap.Int("category_id", id), zap.Error(err))
+		return err
+	}
+
+	for _, descendantID := range descendantIDs {
+		if descendantID == parentID {
+			return ErrCategoryCycle
+		}
+	}
+
+	return nil
+}
+
+// saveWithSlug gives a category a unique slug and saves it with save.
+// An explicitly requested slug must be free; a slug generated from the name
+// gets a numeric suffix when it collides with another category. Slugs are checked
+// before saving, and when another category takes the slug in the meantime save
+// fails with repository.ErrSlugExists and the next suffix is tried.
+func (s *CategoryService) saveWithSlug(ctx context.Context, category *models.Category, requested string, save func(ctx context.Context) error) error {
+	if requested != "" {
+		slug := models.Slugify(requested)
+		if slug == "" {
+			return ErrInvalidSlug
+		}
+
+		exists, err := s.categoryRepo.SlugExists(ctx, slug, category.ID)
+		if err != nil {
+			s.logger.Error("Failed to check category slug", zap.String("slug", slug), zap.Error(err))
+			return err
+		}
+		if exists {
+			return ErrSlugTaken
+		}
+
+		category.Slug = slug
+		if err := save(ctx); err != nil {
+			if errors.Is(err, repository.ErrSlugExists) {
+				return ErrSlugTaken
+			}
+			return err
+		}
+		return nil
+	}
+
+	base := models.Slugify(category.Name)
+	if base == "" {
+		base = "category"
+	}
+
+	for attempt := 1; attempt <= maxSlugAttempts; attempt++ {
+		slug := base
+		if attempt > 1 {
+			slug = fmt.Sprintf("%s-%d", base, attempt)
+		}
+
+		exists, err := s.categoryRepo.SlugExists(ctx, slug, category.ID)
+		if err != nil {
+			s.logger.Error("Failed to check category slug", zap.String("slug", slug), zap.Error(err))
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		category.Slug = slug
+		err = save(ctx)
+		if errors.Is(err, repository.ErrSlugExists) {
+			continue
+		}
+		return err
+	}
+
+	return ErrSlugTaken
+}
+
+// checkMovedAttributes makes sure the items of category fromID fit the attribute schema
+// of target and locks them, so they cannot change before they are moved. Otherwise
+// ErrIncompatibleAttributes lists the items with their rejected attributes.
+func (s *CategoryService) checkMovedAttributes(ctx context.Context, fromID int, target *models.Category) error {
+	items, err := s.categoryRepo.LockItemAttributes(ctx, fromID)
+	if err != nil {
+		s.logger.Error("Failed to get category item attributes", zap.Int("category_id", fromID), zap.Error(err))
+		return err
+	}
+
+	itemIDs := make([]int, 0, len(items))
+	for itemID := range items {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Ints(itemIDs)
+
+	var conflicting []int
+	var fields []apperror.FieldError
+	for _, itemID := range itemIDs {
+		err := target.AttributeSchema.ValidateAttributes(items[itemID])
+		if err == nil {
+			continue
+		}
+
+		var attrErr *models.AttributeValidationError
+		if !errors.As(err, &attrErr) {
+			return apperror.Validation(err)
+		}
+		conflicting = append(conflicting, itemID)
+		fields = append(fields, attributeFields(fmt.Sprintf("items.%d.attributes.", itemID), attrErr)...)
+	}
+
+	if len(conflicting) > 0 {
+		s.logger.Info("Items do not fit the target category", zap.Int("category_id", fromID), zap.Int("target_id", target.ID), zap.Ints("item_ids", conflicting))
+		return incompatibleAttributesError(conflicting, fields)
+	}
+	return nil
+}
+
+// findCategory returns the category with the given ID from a list, or nil
+func findCategory(categories []models.Category, id int) *models.Category {
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i]
+		}
+	}
+	return nil
+}
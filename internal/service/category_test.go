@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/i18n"
+	"shary_be/internal/models"
+	"shary_be/internal/repository/memory"
+
+	"go.uber.org/zap"
+)
+
+func TestCategoryService_DeleteCategory(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	authorID := s.createAuthor(t)
+
+	vehicles := s.createCategory(t, "Vehicles", nil)
+	bikes := s.createCategory(t, "Bikes", &vehicles.ID)
+	mountain := s.createCategory(t, "Mountain bikes", &bikes.ID)
+	other := s.createCategory(t, "Other", nil)
+	item := s.createItem(t, authorID, &bikes.ID)
+
+	err := s.categories.DeleteCategory(ctx, bikes.ID, nil, nil)
+	wantError(t, err, ErrCategoryInUse)
+
+	if err := s.categories.DeleteCategory(ctx, bikes.ID, &other.ID, nil); err != nil {
+		t.Fatalf("DeleteCategory: %v", err)
+	}
+
+	got, err := s.items.GetItemByID(ctx, item.ID, i18n.Default)
+	if err != nil {
+		t.Fatalf("GetItemByID: %v", err)
+	}
+	if got.Category.ID == nil || *got.Category.ID != other.ID {
+		t.Errorf("item category = %v, want %d", got.Category.ID, other.ID)
+	}
+
+	child, err := s.categories.GetCategoryByID(ctx, mountain.ID, i18n.Default)
+	if err != nil {
+		t.Fatalf("GetCategoryByID: %v", err)
+	}
+	if child.ParentID == nil || *child.ParentID != vehicles.ID {
+		t.Errorf("subcategory parent = %v, want %d", child.ParentID, vehicles.ID)
+	}
+
+	_, err = s.categories.GetCategoryByID(ctx, bikes.ID, i18n.Default)
+	wantError(t, err, ErrCategoryNotFound)
+}
+
+func TestCategoryService_GetCategory_ItemsCount(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	authorID := s.createAuthor(t)
+
+	vehicles := s.createCategory(t, "Vehicles", nil)
+	bikes := s.createCategory(t, "Bikes", &vehicles.ID)
+	s.createItem(t, authorID, &vehicles.ID)
+	s.createItem(t, authorID, &bikes.ID)
+	s.createItem(t, authorID, &bikes.ID)
+
+	all, err := s.categories.GetAllCategories(ctx, i18n.Default)
+	if err != nil {
+		t.Fatalf("GetAllCategories: %v", err)
+	}
+	listed := map[int]int{}
+	for _, category := range all {
+		listed[category.ID] = category.ItemsCount
+	}
+
+	for _, category := range []*models.Category{vehicles, bikes} {
+		byID, err := s.categories.GetCategoryByID(ctx, category.ID, i18n.Default)
+		if err != nil {
+			t.Fatalf("GetCategoryByID: %v", err)
+		}
+		bySlug, err := s.categories.GetCategoryBySlug(ctx, category.Slug, i18n.Default)
+		if err != nil {
+			t.Fatalf("GetCategoryBySlug: %v", err)
+		}
+		if byID.ItemsCount != listed[category.ID] || bySlug.ItemsCount != listed[category.ID] {
+			t.Errorf("%s items count by ID %d, by slug %d; want %d as listed",
+				category.Name, byID.ItemsCount, bySlug.ItemsCount, listed[category.ID])
+		}
+	}
+	if listed[vehicles.ID] != 3 {
+		t.Errorf("Vehicles items count = %d, want 3", listed[vehicles.ID])
+	}
+}
+
+func TestCategoryService_MergeCategory(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	authorID := s.createAuthor(t)
+
+	bikes := s.createCategory(t, "Bikes", nil)
+	mountain := s.createCategory(t, "Mountain bikes", &bikes.ID)
+	cycles := s.createCategory(t, "Cycles", nil)
+	s.createItem(t, authorID, &bikes.ID)
+	s.createItem(t, authorID, &mountain.ID)
+
+	_, err := s.categories.MergeCategory(ctx, bikes.ID, &models.MergeCategoryRequest{TargetID: mountain.ID})
+	wantError(t, err, ErrCategoryCycle)
+
+	result, err := s.categories.MergeCategory(ctx, bikes.ID, &models.MergeCategoryRequest{TargetID: cycles.ID})
+	if err != nil {
+		t.Fatalf("MergeCategory: %v", err)
+	}
+	if result.ItemsMoved != 1 {
+		t.Errorf("items moved = %d, want 1", result.ItemsMoved)
+	}
+
+	items, err := s.items.GetItemsByCategory(ctx, cycles.ID, i18n.Default)
+	if err != nil {
+		t.Fatalf("GetItemsByCategory: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("items under the target = %d, want 2 including its new subcategory", len(items))
+	}
+}
+
+func TestCategoryService_PatchCategory(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	category := s.createCategory(t, "Bikes", nil)
+	if category.Version != 1 {
+		t.Fatalf("created category version = %d, want 1", category.Version)
+	}
+
+	patched, err := s.categories.PatchCategory(ctx, category.ID, []byte(`{"name": "Road bikes", "slug": ""}`), Precondition{category.Version})
+	if err != nil {
+		t.Fatalf("PatchCategory: %v", err)
+	}
+	if patched.Name != "Road bikes" || patched.Slug != "road-bikes" {
+		t.Errorf("patched name %q, slug %q; want Road bikes, road-bikes", patched.Name, patched.Slug)
+	}
+
+	_, err = s.categories.PatchCategory(ctx, category.ID, []byte(`{"name": "Bicycles"}`), Precondition{category.Version})
+	wantError(t, err, ErrVersionMismatch)
+}
+
+// racingCategoryRepository reports every slug as free, as if another category took
+// it between the check and the write
+type racingCategoryRepository struct {
+	*memory.CategoryRepository
+}
+
+func (r racingCategoryRepository) SlugExists(ctx context.Context, slug string, excludeID int) (bool, error) {
+	return false, nil
+}
+
+func TestCategoryService_CreateCategory_SlugRace(t *testing.T) {
+	db := memory.NewDB()
+	repo := memory.NewCategoryRepository(db)
+	categories := NewCategoryService(racingCategoryRepository{repo}, zap.NewNop(), memory.NewTxManager(db))
+	ctx := context.Background()
+
+	if _, err := categories.CreateCategory(ctx, &models.CreateCategoryRequest{Name: "Bikes"}); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+
+	generated, err := categories.CreateCategory(ctx, &models.CreateCategoryRequest{Name: "Bikes"})
+	if err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	if generated.Slug != "bikes-2" {
+		t.Errorf("slug = %q, want %q", generated.Slug, "bikes-2")
+	}
+
+	_, err = categories.CreateCategory(ctx, &models.CreateCategoryRequest{Name: "Cycles", Slug: "bikes"})
+	wantError(t, err, ErrSlugTaken)
+
+	_, err = categories.PatchCategory(ctx, generated.ID, []byte(`{"slug":"bikes"}`), nil)
+	wantError(t, err, ErrSlugTaken)
+}
+
+func TestCategoryService_MergeCategory_IncompatibleAttributes(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	authorID := s.createAuthor(t)
+
+	frame := models.AttributeSchema{{Key: "frame", Type: models.AttributeTypeNumber}}
+	bikes, err := s.categories.CreateCategory(ctx, &models.CreateCategoryRequest{Name: "Bikes", AttributeSchema: frame})
+	if err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	cycles, err := s.categories.CreateCategory(ctx, &models.CreateCategoryRequest{Name: "Cycles", AttributeSchema: frame})
+	if err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	other := s.createCategory(t, "Other", nil)
+
+	item, err := s.items.CreateItem(ctx, &models.CreateItemRequest{
+		Title:       "City bike",
+		Description: "A city bike in good condition",
+		Price:       1000,
+		Location:    "Almaty",
+		AuthorID:    authorID,
+		CategoryID:  &bikes.ID,
+		Attributes:  models.ItemAttributes{"frame": 54.0},
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	_, err = s.categories.MergeCategory(ctx, bikes.ID, &models.MergeCategoryRequest{TargetID: other.ID})
+	wantError(t, err, ErrIncompatibleAttributes)
+	fields := apperror.From(err).Fields
+	wantField := "items." + strconv.Itoa(item.ID) + ".attributes.frame"
+	if len(fields) != 1 || fields[0].Field != wantField {
+		t.Errorf("fields = %+v, want %s", fields, wantField)
+	}
+
+	err = s.categories.DeleteCategory(ctx, bikes.ID, &other.ID, nil)
+	wantError(t, err, ErrIncompatibleAttributes)
+
+	if _, err := s.categories.MergeCategory(ctx, bikes.ID, &models.MergeCategoryRequest{TargetID: cycles.ID}); err != nil {
+		t.Fatalf("MergeCategory: %v", err)
+	}
+}
@@ -1,37 +1,93 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"net/http"
+	"sort"
 
+	"shary_be/internal/apperror"
+	"shary_be/internal/i18n"
 	"shary_be/internal/models"
-	"shary_be/internal/repository"
 
-	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+var (
+	// ErrItemNotFound is returned when the requested item does not exist
+	ErrItemNotFound = apperror.New(http.StatusNotFound, "item_not_found", "item not found")
+	// ErrUnknownCategory is returned when an item refers to a category that does not exist
+	ErrUnknownCategory = apperror.New(http.StatusBadRequest, "unknown_category", "category not found")
+	// ErrInvalidAttributes is returned when item attributes do not match the category schema
+	ErrInvalidAttributes = apperror.New(http.StatusUnprocessableEntity, "invalid_attributes", "invalid attributes")
+	// ErrLocationRequired is returned when items are listed by an empty location
+	ErrLocationRequired = apperror.New(http.StatusBadRequest, "location_required", "location cannot be empty")
+	// ErrInvalidCategoryID is returned when items are listed by a category ID that cannot exist
+	ErrInvalidCategoryID = apperror.New(http.StatusBadRequest, "invalid_category_id", "category_id must be greater than 0")
+)
+
+// attributeError lists the rejected attributes of an item as request fields
+func attributeError(err *models.AttributeValidationError) error {
+	return ErrInvalidAttributes.WithFields(attributeFields("attributes.", err)...).Wrap(err)
+}
+
+// attributeFields lists rejected attributes as request fields named prefix and the key
+func attributeFields(prefix string, err *models.AttributeValidationError) []apperror.FieldError {
+	keys := make([]string, 0, len(err.Fields))
+	for key := range err.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]apperror.FieldError, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, apperror.FieldError{
+			Field: prefix + key,
+			Rule:  err.Fields[key].Rule,
+			Param: err.Fields[key].Param,
+		})
+	}
+	return fields
+}
+
+// PhotoImporter copies remote photos into our own storage and attaches them to an item
+type PhotoImporter interface {
+	ImportPhotos(ctx context.Context, itemID int, urls []string) ([]string, error)
+}
+
 // ItemService handles business logic for items
 type ItemService struct {
-	itemRepo *repository.ItemRepository
-	logger   *zap.Logger
-	db       *sqlx.DB
+	itemRepo         ItemRepository
+	categoryRepo     CategoryRepository
+	photoImporter    PhotoImporter
+	maxPhotosPerItem int
+	logger           *zap.Logger
+	txManager        TxManager
 }
 
 // NewItemService creates a new item service
-func NewItemService(itemRepo *repository.ItemRepository, logger *zap.Logger, db *sqlx.DB) *ItemService {
+func NewItemService(itemRepo ItemRepository, categoryRepo CategoryRepository, photoImporter PhotoImporter, maxPhotosPerItem int, logger *zap.Logger, txManager TxManager) *ItemService {
 	return &ItemService{
-		itemRepo: itemRepo,
-		logger:   logger,
-		db:       db,
+		itemRepo:         itemRepo,
+		categoryRepo:     categoryRepo,
+		photoImporter:    photoImporter,
+		maxPhotosPerItem: maxPhotosPerItem,
+		logger:           logger,
+		txManager:        txManager,
 	}
 }
 
-// CreateItem creates a new item
-func (s *ItemService) CreateItem(req *models.CreateItemRequest) (*models.Item, error) {
+// CreateItem creates a new item. Photos to import are downloaded after the item is
+// created; if that fails the item is removed again.
+func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequest) (*models.Item, error) {
 	// Validate request
 	if err := req.Validate(); err != nil {
 		s.logger.Error("Invalid create item request", zap.Error(err))
+		return nil, apperror.Validation(err)
+	}
+
+	if err := s.validateAttributes(ctx, req.CategoryID, req.Attributes); err != nil {
 		return nil, err
 	}
 
@@ -46,38 +102,68 @@ func (s *ItemService) CreateItem(req *models.CreateItemRequest) (*models.Item, e
 		HasPhotos:   false,
 		AuthorID:    req.AuthorID,
 		CategoryID:  req.CategoryID,
+		Attributes:  req.Attributes,
 	}
 
 	if req.Photos != nil {
 		photos = req.Photos
 	}
 
-	if err := s.itemRepo.Create(item, photos); err != nil {
+	if err := checkPhotoLimit(s.maxPhotosPerItem, 0, len(photos)); err != nil {
+		return nil, err
+	}
+
+	var importURLs []string
+	if req.ImportPhotos {
+		importURLs, photos = photos, nil
+	}
+
+	if err := s.itemRepo.Create(ctx, item, photos); err != nil {
 		s.logger.Error("Failed to create item", zap.Error(err))
 		return nil, err
 	}
 
+	if len(importURLs) > 0 {
+		if _, err := s.photoImporter.ImportPhotos(ctx, item.ID, importURLs); err != nil {
+			// Don't leave a listing behind without the photos it was created with, even
+			// when the import failed because the request was cancelled
+			if delErr := s.itemRepo.Delete(context.WithoutCancel(ctx), item.ID); delErr != nil {
+				s.logger.Error("Failed to remove item after photo import failed", zap.Int("item_id", item.ID), zap.Error(delErr))
+			}
+			return nil, err
+		}
+
+		// The imported photos made new versions of the item
+		imported, err := s.itemRepo.GetByID(ctx, item.ID, i18n.Default)
+		if err != nil {
+			s.logger.Error("Failed to get item after photo import", zap.Int("item_id", item.ID), zap.Error(err))
+			return nil, err
+		}
+		item.HasPhotos = imported.HasPhotos
+		item.Version = imported.Version
+		item.UpdatedAt = imported.UpdatedAt
+	}
+
 	s.logger.Info("Item created successfully", zap.Int("item_id", item.ID))
 	return item, nil
 }
 
-// GetItemByID retrieves an item by ID
-func (s *ItemService) GetItemByID(id int) (*models.ItemResponse, error) {
-	item, err := s.itemRepo.GetByID(id)
+// GetItemByID retrieves an item by ID with the category name in the given language
+func (s *ItemService) GetItemByID(ctx context.Context, id int, lang string) (*models.ItemResponse, error) {
+	item, err := s.itemRepo.GetByID(ctx, id, lang)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
 		s.logger.Error("Failed to get item by ID", zap.Int("item_id", id), zap.Error(err))
 		return nil, err
 	}
 
-	if item == nil {
-		return nil, sql.ErrNoRows
-	}
-
 	return item, nil
 }
 
 // GetAllItems retrieves all items with optional filtering
-func (s *ItemService) GetAllItems(filter *models.ItemFilter) ([]models.ItemResponse, error) {
+func (s *ItemService) GetAllItems(ctx context.Context, filter *models.ItemFilter) ([]models.ItemResponse, error) {
 	// Set default pagination if not provided
 	if filter != nil {
 		if filter.Limit <= 0 {
@@ -88,7 +174,7 @@ func (s *ItemService) GetAllItems(filter *models.ItemFilter) ([]models.ItemRespo
 		}
 	}
 
-	items, err := s.itemRepo.GetAll(filter)
+	items, err := s.itemRepo.GetAll(ctx, filter)
 	if err != nil {
 		s.logger.Error("Failed to get all items", zap.Error(err))
 		return nil, err
@@ -97,91 +183,82 @@ func (s *ItemService) GetAllItems(filter *models.ItemFilter) ([]models.ItemRespo
 	return items, nil
 }
 
-// UpdateItem updates an item
-func (s *ItemService) UpdateItem(id int, req *models.UpdateItemRequest) (*models.ItemResponse, error) {
-	if err := req.Validate(); err != nil {
-		s.logger.Error("Invalid update item request", zap.Error(err))
-		return nil, err
-	}
-
-	// 1. Start Transaction
-	tx, err := s.db.Beginx()
-	if err != nil {
-		s.logger.Error("Failed to begin transaction", zap.Error(err))
-		return nil, err
-	}
-
-	defer tx.Rollback()
-
-	// Get current item data
-	currentItem, err := s.itemRepo.GetByID(id)
-	if err != nil {
-		s.logger.Error("Failed to get item for update", zap.Int("item_id", id), zap.Error(err))
-		return nil, err
-	}
+// ReplaceItem replaces the editable fields of an item and returns it with the category
+// name in the given language. Fields missing from req are cleared.
+func (s *ItemService) ReplaceItem(ctx context.Context, id int, req *models.ReplaceItemRequest, precondition Precondition, lang string) (*models.ItemResponse, error) {
+	return s.updateItem(ctx, id, precondition, lang, func(*models.ReplaceItemRequest) (*models.ReplaceItemRequest, error) {
+		return req, nil
+	})
+}
 
-	// 2. Handle Photo Deletions
-	if len(req.PhotoIDsToDelete) > 0 {
-		if err := s.itemRepo.DeletePhotos(tx, req.PhotoIDsToDelete, id); err != nil {
-			s.logger.Error("Failed to delete photos", zap.Int("item_id", id), zap.Error(err))
+// PatchItem applies a JSON Merge Patch to an item and returns it with the category name
+// in the given language. A null member clears the field, e.g. "category_id": null.
+func (s *ItemService) PatchItem(ctx context.Context, id int, patch []byte, precondition Precondition, lang string) (*models.ItemResponse, error) {
+	return s.updateItem(ctx, id, precondition, lang, func(current *models.ReplaceItemRequest) (*models.ReplaceItemRequest, error) {
+		if err := applyPatch(current, patch); err != nil {
 			return nil, err
 		}
-	}
+		return current, nil
+	})
+}
 
-	// 3. Handle Photo Additions
-	if len(req.PhotosToAdd) > 0 {
-		if err := s.itemRepo.AddPhotos(tx, id, req.PhotosToAdd); err != nil {
-			s.logger.Error("Failed to add photos", zap.Int("item_id", id), zap.Error(err))
-			return nil, err
+// updateItem saves the representation that change derives from the item's current one.
+// The item is locked meanwhile so concurrent patches are applied one after another, and
+// ErrVersionMismatch is returned if it no longer has a version the precondition accepts.
+func (s *ItemService) updateItem(ctx context.Context, id int, precondition Precondition, lang string, change func(current *models.ReplaceItemRequest) (*models.ReplaceItemRequest, error)) (*models.ItemResponse, error) {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.itemRepo.LockByID(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrItemNotFound
+			}
+			s.logger.Error("Failed to lock item for update", zap.Int("item_id", id), zap.Error(err))
+			return err
 		}
-	}
 
-	// 4. Recalculate has_photos flag
-	photoCount, err := s.itemRepo.CountPhotosByItemID(tx, id)
-	if err != nil {
-		s.logger.Error("Failed to count photos", zap.Int("item_id", id), zap.Error(err))
-		return nil, err
-	}
+		currentItem, err := s.itemRepo.GetByID(ctx, id, i18n.Default)
+		if err != nil {
+			s.logger.Error("Failed to get item for update", zap.Int("item_id", id), zap.Error(err))
+			return err
+		}
+		if !precondition.Matches(currentItem.Version) {
+			return versionMismatchError(currentItem.Version)
+		}
 
-	itemToUpdate := &models.ItemToUpdate{
-		ID:          id,
-		Title:       currentItem.Title,
-		Description: currentItem.Description,
-		Price:       currentItem.Price,
-		Location:    currentItem.Location,
-		CategoryID:  currentItem.Category.ID,
-		HasPhotos:   photoCount > 0,
-	}
+		req, err := change(currentItem.ReplaceRequest())
+		if err != nil {
+			return err
+		}
+		if err := req.Validate(); err != nil {
+			s.logger.Error("Invalid item update", zap.Error(err))
+			return apperror.Validation(err)
+		}
+		if err := s.validateAttributes(ctx, req.CategoryID, req.Attributes); err != nil {
+			return err
+		}
 
-	if req.Title != nil {
-		itemToUpdate.Title = *req.Title
-	}
-	if req.Description != nil {
-		itemToUpdate.Description = *req.Description
-	}
-	if req.Price != nil {
-		itemToUpdate.Price = *req.Price
-	}
-	if req.Location != nil {
-		itemToUpdate.Location = *req.Location
-	}
-	if req.CategoryID != nil {
-		itemToUpdate.CategoryID = *req.CategoryID
-	}
+		itemToUpdate := &models.ItemToUpdate{
+			ID:          id,
+			Title:       req.Title,
+			Description: req.Description,
+			Price:       float64(req.Price),
+			Location:    req.Location,
+			HasPhotos:   currentItem.HasPhotos,
+			CategoryID:  req.CategoryID,
+			Attributes:  req.Attributes,
+		}
 
-	// 6. Update the main item record
-	if err := s.itemRepo.Update(tx, itemToUpdate); err != nil {
-		s.logger.Error("Failed to update item", zap.Int("item_id", id), zap.Error(err))
-		return nil, err
-	}
+		if err := s.itemRepo.Update(ctx, itemToUpdate); err != nil {
+			s.logger.Error("Failed to update item", zap.Int("item_id", id), zap.Error(err))
+			return err
+		}
 
-	// 7. Commit the transaction
-	if err := tx.Commit(); err != nil {
-		s.logger.Error("Failed to commit transaction", zap.Int("item_id", id), zap.Error(err))
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	updatedItem, err := s.itemRepo.GetByID(id)
+	updatedItem, err := s.itemRepo.GetByID(ctx, id, lang)
 	if err != nil {
 		s.logger.Error("Failed to get updated item after commit", zap.Int("item_id", id), zap.Error(err))
 		return nil, err
@@ -191,21 +268,37 @@ func (s *ItemService) UpdateItem(id int, req *models.UpdateItemRequest) (*models
 	return updatedItem, nil
 }
 
-// DeleteItem deletes an item
-func (s *ItemService) DeleteItem(id int) error {
+// DeleteItem deletes an item. With a precondition, the item is only deleted while it
+// still has a version the precondition accepts.
+func (s *ItemService) DeleteItem(ctx context.Context, id int, precondition Precondition) error {
 	// Check if item exists
-	item, err := s.itemRepo.GetByID(id)
+	item, err := s.itemRepo.GetByID(ctx, id, i18n.Default)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrItemNotFound
+		}
 		s.logger.Error("Failed to get item for deletion", zap.Int("item_id", id), zap.Error(err))
 		return err
 	}
 
-	if item == nil {
-		return sql.ErrNoRows
+	if !precondition.Matches(item.Version) {
+		return versionMismatchError(item.Version)
 	}
 
-	// Delete item
-	if err := s.itemRepo.Delete(id); err != nil {
+	// Delete item. Under a precondition only the version checked above is deleted,
+	// as the item may have changed since it was read.
+	if precondition != nil {
+		err = s.itemRepo.DeleteVersion(ctx, id, item.Version)
+	} else {
+		err = s.itemRepo.Delete(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if precondition != nil {
+				return ErrVersionMismatch
+			}
+			return ErrItemNotFound
+		}
 		s.logger.Error("Failed to delete item", zap.Int("item_id", id), zap.Error(err))
 		return err
 	}
@@ -214,13 +307,13 @@ func (s *ItemService) DeleteItem(id int) error {
 	return nil
 }
 
-// GetItemsByLocation retrieves items by location
-func (s *ItemService) GetItemsByLocation(location string) ([]models.ItemResponse, error) {
+// GetItemsByLocation retrieves items by location with category names in the given language
+func (s *ItemService) GetItemsByLocation(ctx context.Context, location string, lang string) ([]models.ItemResponse, error) {
 	if location == "" {
-		return nil, errors.New("location cannot be empty")
+		return nil, ErrLocationRequired
 	}
 
-	items, err := s.itemRepo.GetByLocation(location)
+	items, err := s.itemRepo.GetByLocation(ctx, location, lang)
 	if err != nil {
 		s.logger.Error("Failed to get items by location", zap.String("location", location), zap.Error(err))
 		return nil, err
@@ -229,9 +322,9 @@ func (s *ItemService) GetItemsByLocation(location string) ([]models.ItemResponse
 	return items, nil
 }
 
-// GetAvailableItems retrieves only available items
-func (s *ItemService) GetAvailableItems() ([]models.ItemResponse, error) {
-	items, err := s.itemRepo.GetAvailableItems()
+// GetAvailableItems retrieves only available items with category names in the given language
+func (s *ItemService) GetAvailableItems(ctx context.Context, lang string) ([]models.ItemResponse, error) {
+	items, err := s.itemRepo.GetAvailableItems(ctx, lang)
 	if err != nil {
 		s.logger.Error("Failed to get available items", zap.Error(err))
 		return nil, err
@@ -240,13 +333,13 @@ func (s *ItemService) GetAvailableItems() ([]models.ItemResponse, error) {
 	return items, nil
 }
 
-// GetItemsByCategory retrieves items by category
-func (s *ItemService) GetItemsByCategory(categoryID int) ([]models.ItemResponse, error) {
+// GetItemsByCategory retrieves items by category with category names in the given language
+func (s *ItemService) GetItemsByCategory(ctx context.Context, categoryID int, lang string) ([]models.ItemResponse, error) {
 	if categoryID <= 0 {
-		return nil, errors.New("category_id must be greater than 0")
+		return nil, ErrInvalidCategoryID
 	}
 
-	items, err := s.itemRepo.GetByCategory(categoryID)
+	items, err := s.itemRepo.GetByCategory(ctx, categoryID, lang)
 	if err != nil {
 		s.logger.Error("Failed to get items by category", zap.Int("category_id", categoryID), zap.Error(err))
 		return nil, err
@@ -254,3 +347,38 @@ func (s *ItemService) GetItemsByCategory(categoryID int) ([]models.ItemResponse,
 
 	return items, nil
 }
+
+// validateAttributes checks item attributes against the attribute schema of its category.
+// Items without a category cannot have attributes.
+func (s *ItemService) validateAttributes(ctx context.Context, categoryID *int, attributes models.ItemAttributes) error {
+	if categoryID == nil {
+		if len(attributes) > 0 {
+			return ErrInvalidAttributes.WithFields(apperror.FieldError{
+				Field: "attributes",
+				Rule:  "category_required",
+			})
+		}
+		return nil
+	}
+
+	category, err := s.categoryRepo.GetByID(ctx, *categoryID)
+	if err != nil {
+		s.logger.Error("Failed to get category for attribute validation", zap.Int("category_id", *categoryID), zap.Error(err))
+		return err
+	}
+	if category == nil {
+		return ErrUnknownCategory
+	}
+
+	if err := category.AttributeSchema.ValidateAttributes(attributes); err != nil {
+		s.logger.Error("Invalid item attributes", zap.Int("category_id", *categoryID), zap.Error(err))
+
+		var attrErr *models.AttributeValidationError
+		if errors.As(err, &attrErr) {
+			return attributeError(attrErr)
+		}
+		return apperror.Validation(err)
+	}
+
+	return nil
+}
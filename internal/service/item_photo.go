@@ -1,32 +1,161 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/imaging"
 	"shary_be/internal/models"
-	"shary_be/internal/repository"
+	"shary_be/internal/remote"
+	"shary_be/internal/storage"
 
-	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+var (
+	// ErrPhotoNotFound is returned when a photo does not exist or belongs to another item
+	ErrPhotoNotFound = apperror.New(http.StatusNotFound, "photo_not_found", "photo not found")
+	// ErrNoPhotos is returned when an upload contains no files
+	ErrNoPhotos = apperror.New(http.StatusBadRequest, "no_photos", "no photos uploaded")
+	// ErrTooManyPhotos is returned when an upload contains more files than allowed
+	ErrTooManyPhotos = apperror.New(http.StatusBadRequest, "too_many_photos", "too many photos in one upload")
+	// ErrPhotoTooLarge is returned when an uploaded file exceeds the size limit
+	ErrPhotoTooLarge = apperror.New(http.StatusRequestEntityTooLarge, "photo_too_large", "photo exceeds the maximum allowed size")
+	// ErrUnsupportedPhotoType is returned when an uploaded file is not an accepted image type
+	ErrUnsupportedPhotoType = apperror.New(http.StatusUnsupportedMediaType, "unsupported_photo_type", "unsupported photo type")
+	// ErrPhotoImportFailed is returned when a remote photo cannot be downloaded
+	ErrPhotoImportFailed = apperror.New(http.StatusUnprocessableEntity, "photo_import_failed", "failed to import photo")
+	// ErrPhotoOrderMismatch is returned when a new photo order does not list every photo of the item exactly once
+	ErrPhotoOrderMismatch = apperror.New(http.StatusBadRequest, "photo_order_mismatch", "photo order must list every photo of the item exactly once")
+	// ErrPhotoLimitExceeded is returned when adding photos would take an item over its photo
+	// limit; the limit and remaining details tell how many more can be added
+	ErrPhotoLimitExceeded = apperror.New(http.StatusUnprocessableEntity, "photo_limit_exceeded", "too many photos for one item")
+	// ErrDuplicatePhoto is returned when a photo is nearly identical to a photo on another
+	// author's item; the item_id detail names that item
+	ErrDuplicatePhoto = apperror.New(http.StatusConflict, "duplicate_photo", "photo is nearly identical to a photo of another item")
+)
+
+// Policies for photos nearly identical to a photo on another author's item
+const (
+	// DuplicatePolicyOff skips duplicate detection
+	DuplicatePolicyOff = "off"
+	// DuplicatePolicyFlag accepts the photo and flags it for moderators
+	DuplicatePolicyFlag = "flag"
+	// DuplicatePolicyReject refuses the photo
+	DuplicatePolicyReject = "reject"
+)
+
+// PhotoDuplicateOptions configures detection of photos copied from other listings
+type PhotoDuplicateOptions struct {
+	// Policy is one of DuplicatePolicyOff, DuplicatePolicyFlag or DuplicatePolicyReject
+	Policy string
+	// MaxDistance is the largest perceptual hash distance, in bits, still treated as a duplicate
+	MaxDistance int
+}
+
+// photoError attaches the name of the offending file to a photo error
+func photoError(err *apperror.Error, filename string) *apperror.Error {
+	return err.WithMessage(filename+": "+err.Message).WithDetail("filename", filename)
+}
+
+// checkPhotoLimit returns ErrPhotoLimitExceeded when an item with current photos cannot take adding more
+func checkPhotoLimit(limit int, current int, adding int) error {
+	if current+adding <= limit {
+		return nil
+	}
+
+	remaining := limit - current
+	if remaining < 0 {
+		remaining = 0
+	}
+	return ErrPhotoLimitExceeded.
+		WithMessage(fmt.Sprintf("an item can have at most %d photos, %d more can be added", limit, remaining)).
+		WithDetail("limit", limit).
+		WithDetail("remaining", remaining)
+}
+
+// allowedPhotoTypes lists the MIME types accepted for upload
+var allowedPhotoTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// sanitizedPhotoTypes maps the formats produced by imaging.Sanitize to their MIME type and extension
+var sanitizedPhotoTypes = map[string]struct {
+	contentType string
+	ext         string
+}{
+	imaging.FormatJPEG: {contentType: "image/jpeg", ext: ".jpg"},
+	imaging.FormatPNG:  {contentType: "image/png", ext: ".png"},
+}
+
+// PhotoReprocessResult summarizes a run over already stored photos
+type PhotoReprocessResult struct {
+	Processed int `json:"processed"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+}
+
+// PhotoUploadOptions limits direct photo uploads
+type PhotoUploadOptions struct {
+	// MaxBytes is the maximum size of a single photo
+	MaxBytes int64
+	// MaxFiles is the maximum number of photos in one upload
+	MaxFiles int
+}
+
+// PhotoUpload is a single file received for upload
+type PhotoUpload struct {
+	Filename string
+	Body     io.Reader
+}
+
 type ItemPhotoService struct {
-	itemPhotoRepo *repository.ItemPhotoRepository
-	itemRepo      *repository.ItemRepository
-	logger        *zap.Logger
-	db            *sqlx.DB
+	itemPhotoRepo    ItemPhotoRepository
+	itemRepo         ItemRepository
+	blobStore        storage.BlobStore
+	variantPool      *PhotoVariantPool
+	fetcher          *remote.Fetcher
+	uploadOptions    PhotoUploadOptions
+	maxPerItem       int
+	duplicateOptions PhotoDuplicateOptions
+	logger           *zap.Logger
+	txManager        TxManager
 }
 
-func NewItemPhotoService(itemPhotoRepo *repository.ItemPhotoRepository, itemRepo *repository.ItemRepository, logger *zap.Logger, db *sqlx.DB) *ItemPhotoService {
+func NewItemPhotoService(itemPhotoRepo ItemPhotoRepository, itemRepo ItemRepository, blobStore storage.BlobStore, variantPool *PhotoVariantPool, fetcher *remote.Fetcher, uploadOptions PhotoUploadOptions, maxPerItem int, duplicateOptions PhotoDuplicateOptions, logger *zap.Logger, txManager TxManager) *ItemPhotoService {
 	return &ItemPhotoService{
-		itemPhotoRepo: itemPhotoRepo,
-		itemRepo:      itemRepo,
-		logger:        logger,
-		db:            db,
+		itemPhotoRepo:    itemPhotoRepo,
+		itemRepo:         itemRepo,
+		blobStore:        blobStore,
+		variantPool:      variantPool,
+		fetcher:          fetcher,
+		uploadOptions:    uploadOptions,
+		maxPerItem:       maxPerItem,
+		duplicateOptions: duplicateOptions,
+		logger:           logger,
+		txManager:        txManager,
 	}
 }
 
+// UploadOptions returns the limits applied to direct photo uploads
+func (s *ItemPhotoService) UploadOptions() PhotoUploadOptions {
+	return s.uploadOptions
+}
+
 // GetPhotosByItemID retrieves all photos for an item
-func (s *ItemPhotoService) GetPhotosByItemID(itemID int) ([]models.ItemPhoto, error) {
-	photos, err := s.itemPhotoRepo.GetPhotosByItemID(itemID)
+func (s *ItemPhotoService) GetPhotosByItemID(ctx context.Context, itemID int) ([]models.ItemPhoto, error) {
+	photos, err := s.itemPhotoRepo.GetPhotosByItemID(ctx, itemID)
 	if err != nil {
 		s.logger.Error("Failed to get photos by item ID", zap.Int("item_id", itemID), zap.Error(err))
 		return nil, err
@@ -35,90 +164,524 @@ func (s *ItemPhotoService) GetPhotosByItemID(itemID int) ([]models.ItemPhoto, er
 	return photos, nil
 }
 
-func (s *ItemPhotoService) AddPhotos(itemID int, photoURLs []string) error {
-	tx, err := s.db.Beginx()
+// AddPhotos appends photos to an item. The item is locked while its photo count is
+// checked, so concurrent additions cannot exceed the per-item limit.
+func (s *ItemPhotoService) AddPhotos(ctx context.Context, itemID int, photoURLs []string) error {
+	photos := make([]models.NewItemPhoto, len(photoURLs))
+	for i, url := range photoURLs {
+		photos[i] = models.NewItemPhoto{URL: url}
+	}
+
+	return s.addPhotos(ctx, itemID, photos)
+}
+
+// addPhotos appends photos, with their hashes and duplicate flags, to an item
+func (s *ItemPhotoService) addPhotos(ctx context.Context, itemID int, photos []models.NewItemPhoto) error {
+	if len(photos) == 0 {
+		return nil
+	}
+
+	var photoCount int
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.itemRepo.LockByID(ctx, itemID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrItemNotFound
+			}
+			s.logger.Error("Failed to lock item", zap.Int("item_id", itemID), zap.Error(err))
+			return err
+		}
+
+		currentCount, err := s.itemPhotoRepo.CountByItemID(ctx, itemID)
+		if err != nil {
+			s.logger.Error("Failed to count photos", zap.Int("item_id", itemID), zap.Error(err))
+			return err
+		}
+
+		if err := checkPhotoLimit(s.maxPerItem, currentCount, len(photos)); err != nil {
+			return err
+		}
+
+		if err := s.itemPhotoRepo.Add(ctx, itemID, photos); err != nil {
+			s.logger.Error("Failed to add photo", zap.Int("item_id", itemID), zap.Error(err))
+			return err
+		}
+
+		photoCount, err = s.syncPhotoState(ctx, itemID)
+		return err
+	})
 	if err != nil {
-		s.logger.Error("Failed to begin transaction", zap.Error(err))
 		return err
 	}
 
-	defer tx.Rollback()
+	s.logger.Info("Successfully added photos", zap.Int("item_id", itemID), zap.Int("photo_count", photoCount))
+
+	return nil
+}
 
-	if len(photoURLs) == 0 {
+func (s *ItemPhotoService) DeletePhotos(ctx context.Context, itemID int, photoIDs []int) error {
+	if len(photoIDs) == 0 {
 		return nil
 	}
 
-	if err := s.itemPhotoRepo.Add(tx, itemID, photoURLs); err != nil {
-		s.logger.Error("Failed to add photo", zap.Int("item_id", itemID), zap.Error(err))
+	var photoCount int
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.itemPhotoRepo.Delete(ctx, itemID, photoIDs); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrPhotoNotFound
+			}
+			s.logger.Error("Failed to bulk delete photos", zap.Int("item_id", itemID), zap.Error(err))
+			return err
+		}
+
+		var err error
+		photoCount, err = s.syncPhotoState(ctx, itemID)
+		return err
+	})
+	if err != nil {
 		return err
 	}
 
-	photoCount, err := s.itemPhotoRepo.CountByItemID(tx, itemID)
+	s.logger.Info("Successfully deleted photos", zap.Int("item_id", itemID), zap.Int("photo_count", photoCount))
+
+	return nil
+}
+
+// syncPhotoState gives an item a cover photo and updates its has_photos flag after its
+// photos changed, returning how many it has now
+func (s *ItemPhotoService) syncPhotoState(ctx context.Context, itemID int) (int, error) {
+	if err := s.itemPhotoRepo.EnsureCover(ctx, itemID); err != nil {
+		s.logger.Error("Failed to update cover photo", zap.Int("item_id", itemID), zap.Error(err))
+		return 0, err
+	}
+
+	photoCount, err := s.itemPhotoRepo.CountByItemID(ctx, itemID)
 	if err != nil {
 		s.logger.Error("Failed to count photos", zap.Int("item_id", itemID), zap.Error(err))
-		return err
+		return 0, err
 	}
 
-	if err := s.itemRepo.UpdateHasPhotos(tx, itemID, photoCount > 0); err != nil {
+	if err := s.itemRepo.UpdateHasPhotos(ctx, itemID, photoCount > 0); err != nil {
 		s.logger.Error("Failed to update item", zap.Int("item_id", itemID), zap.Error(err))
-		return err
+		return 0, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		s.logger.Error("Failed to commit transaction", zap.Int("item_id", itemID), zap.Error(err))
-		return err
+	return photoCount, nil
+}
+
+// ReorderPhotos sets the display order of an item's photos. The order must list
+// every photo of the item exactly once; the photos are locked while it is applied.
+func (s *ItemPhotoService) ReorderPhotos(ctx context.Context, itemID int, photoIDs []int) ([]models.ItemPhoto, error) {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := s.itemRepo.Exists(ctx, itemID)
+		if err != nil {
+			s.logger.Error("Failed to check item", zap.Int("item_id", itemID), zap.Error(err))
+			return err
+		}
+		if !exists {
+			return ErrItemNotFound
+		}
+
+		currentIDs, err := s.itemPhotoRepo.LockIDsByItemID(ctx, itemID)
+		if err != nil {
+			s.logger.Error("Failed to lock photos", zap.Int("item_id", itemID), zap.Error(err))
+			return err
+		}
+
+		if !sameIDs(currentIDs, photoIDs) {
+			return ErrPhotoOrderMismatch
+		}
+
+		if err := s.itemPhotoRepo.UpdatePositions(ctx, itemID, photoIDs); err != nil {
+			s.logger.Error("Failed to reorder photos", zap.Int("item_id", itemID), zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	s.logger.Info("Successfully added photos", zap.Int("item_id", itemID), zap.Int("photo_count", photoCount))
+	s.logger.Info("Successfully reordered photos", zap.Int("item_id", itemID))
 
-	return nil
+	return s.GetPhotosByItemID(ctx, itemID)
 }
 
-func (s *ItemPhotoService) DeletePhotos(itemID int, photoIDs []int) error {
-	tx, err := s.db.Beginx()
+// SetCoverPhoto makes the photo the cover of its item. It returns ErrPhotoNotFound
+// when the photo does not belong to the item.
+func (s *ItemPhotoService) SetCoverPhoto(ctx context.Context, itemID int, photoID int) ([]models.ItemPhoto, error) {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.itemPhotoRepo.SetCover(ctx, itemID, photoID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrPhotoNotFound
+			}
+			s.logger.Error("Failed to set cover photo", zap.Int("item_id", itemID), zap.Int("photo_id", photoID), zap.Error(err))
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		s.logger.Error("Failed to begin transaction", zap.Error(err))
-		return err
+		return nil, err
 	}
 
-	defer tx.Rollback()
+	s.logger.Info("Successfully set cover photo", zap.Int("item_id", itemID), zap.Int("photo_id", photoID))
 
-	if len(photoIDs) == 0 {
-		return nil
+	return s.GetPhotosByItemID(ctx, itemID)
+}
+
+func (s *ItemPhotoService) CountPhotosByItemID(ctx context.Context, itemID int) (int, error) {
+	count, err := s.itemPhotoRepo.CountByItemID(ctx, itemID)
+	if err != nil {
+		s.logger.Error("Failed to count photos", zap.Int("item_id", itemID), zap.Error(err))
+		return 0, err
 	}
 
-	if err := s.itemPhotoRepo.Delete(tx, photoIDs); err != nil {
-		s.logger.Error("Failed to bulk delete photos", zap.Int("item_id", itemID), zap.Error(err))
-		return err
+	return count, nil
+}
+
+// UploadPhotos stores uploaded files in blob storage and records their URLs as item photos.
+// Stored files are removed again if the photos cannot be recorded.
+func (s *ItemPhotoService) UploadPhotos(ctx context.Context, itemID int, uploads []PhotoUpload) ([]string, error) {
+	if len(uploads) == 0 {
+		return nil, ErrNoPhotos
+	}
+	if len(uploads) > s.uploadOptions.MaxFiles {
+		return nil, ErrTooManyPhotos
 	}
 
-	photoCount, err := s.itemPhotoRepo.CountByItemID(tx, itemID)
+	authorID, err := s.itemRepo.GetAuthorID(ctx, itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		s.logger.Error("Failed to get item author", zap.Int("item_id", itemID), zap.Error(err))
+		return nil, err
+	}
+
+	// Fail early before storing anything; AddPhotos re-checks the limit under a lock
+	currentCount, err := s.itemPhotoRepo.CountByItemID(ctx, itemID)
 	if err != nil {
 		s.logger.Error("Failed to count photos", zap.Int("item_id", itemID), zap.Error(err))
+		return nil, err
+	}
+	if err := checkPhotoLimit(s.maxPerItem, currentCount, len(uploads)); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	var urls []string
+	var photos []models.NewItemPhoto
+	for _, upload := range uploads {
+		key, photo, err := s.storeUpload(ctx, itemID, authorID, upload)
+		if err != nil {
+			s.deleteBlobs(ctx, keys)
+			return nil, err
+		}
+		keys = append(keys, key)
+		urls = append(urls, photo.URL)
+		photos = append(photos, photo)
+	}
+
+	if err := s.addPhotos(ctx, itemID, photos); err != nil {
+		s.deleteBlobs(ctx, keys)
+		return nil, err
+	}
+
+	// Resized variants are generated in the background so the upload returns immediately
+	if s.variantPool != nil {
+		for i, key := range keys {
+			s.variantPool.Enqueue(key, urls[i])
+		}
+	}
+
+	s.logger.Info("Successfully uploaded photos", zap.Int("item_id", itemID), zap.Int("uploaded", len(urls)))
+	return urls, nil
+}
+
+// ImportPhotos downloads remote photos and stores them like uploads, so listings keep
+// working when the original host disappears
+func (s *ItemPhotoService) ImportPhotos(ctx context.Context, itemID int, urls []string) ([]string, error) {
+	if len(urls) == 0 {
+		return nil, ErrNoPhotos
+	}
+	if len(urls) > s.uploadOptions.MaxFiles {
+		return nil, ErrTooManyPhotos
+	}
+
+	exists, err := s.itemRepo.Exists(ctx, itemID)
+	if err != nil {
+		s.logger.Error("Failed to check item", zap.Int("item_id", itemID), zap.Error(err))
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrItemNotFound
+	}
+
+	uploads := make([]PhotoUpload, 0, len(urls))
+	for _, url := range urls {
+		data, err := s.fetcher.Fetch(ctx, url)
+		if err != nil {
+			s.logger.Warn("Failed to fetch remote photo", zap.Int("item_id", itemID), zap.String("url", url), zap.Error(err))
+			if errors.Is(err, remote.ErrTooLarge) {
+				return nil, photoError(ErrPhotoTooLarge, url)
+			}
+			return nil, photoError(ErrPhotoImportFailed, url)
+		}
+
+		uploads = append(uploads, PhotoUpload{
+			Filename: url,
+			Body:     bytes.NewReader(data),
+		})
+	}
+
+	return s.UploadPhotos(ctx, itemID, uploads)
+}
+
+// storeUpload validates a single upload and writes it to blob storage, returning its key
+// and the photo to record
+func (s *ItemPhotoService) storeUpload(ctx context.Context, itemID int, authorID int, upload PhotoUpload) (string, models.NewItemPhoto, error) {
+	sanitized, err := readPhoto(upload, s.uploadOptions.MaxBytes)
+	if err != nil {
+		return "", models.NewItemPhoto{}, err
+	}
+
+	duplicateOf, err := s.checkDuplicate(ctx, itemID, authorID, upload.Filename, sanitized.phash)
+	if err != nil {
+		return "", models.NewItemPhoto{}, err
+	}
+
+	key, err := storage.NewKey("items/"+strconv.Itoa(itemID), sanitized.ext)
+	if err != nil {
+		return "", models.NewItemPhoto{}, err
+	}
+
+	if err := s.blobStore.Put(ctx, key, bytes.NewReader(sanitized.data), sanitized.contentType); err != nil {
+		s.logger.Error("Failed to store photo", zap.Int("item_id", itemID), zap.String("key", key), zap.Error(err))
+		return "", models.NewItemPhoto{}, err
+	}
+
+	return key, models.NewItemPhoto{
+		URL:         s.blobStore.URL(key),
+		PHash:       &sanitized.phash,
+		DuplicateOf: duplicateOf,
+	}, nil
+}
+
+// checkDuplicate looks for a near-identical photo on another author's item. Depending on
+// the duplicate policy it returns ErrDuplicatePhoto or the ID of the matching photo
+// to flag the new one with.
+func (s *ItemPhotoService) checkDuplicate(ctx context.Context, itemID int, authorID int, filename string, hash int64) (*int, error) {
+	if s.duplicateOptions.Policy == DuplicatePolicyOff {
+		return nil, nil
+	}
+
+	match, err := s.itemPhotoRepo.FindSimilar(ctx, hash, authorID, s.duplicateOptions.MaxDistance)
+	if err != nil {
+		s.logger.Error("Failed to look up similar photos", zap.Int("item_id", itemID), zap.Error(err))
+		return nil, err
+	}
+	if match == nil {
+		return nil, nil
+	}
+
+	s.logger.Warn("Photo matches a photo of another author's item",
+		zap.Int("item_id", itemID),
+		zap.Int("author_id", authorID),
+		zap.Int("matched_photo_id", match.ID),
+		zap.Int("matched_item_id", match.ItemID),
+		zap.Int("distance", match.Distance),
+	)
+
+	if s.duplicateOptions.Policy == DuplicatePolicyReject {
+		return nil, ErrDuplicatePhoto.
+			WithMessage(fmt.Sprintf("%s is nearly identical to a photo of item %d", filename, match.ItemID)).
+			WithDetail("filename", filename).
+			WithDetail("item_id", match.ItemID)
+	}
+	return &match.ID, nil
+}
+
+// GetFlaggedPhotos lists photos flagged as near-duplicates of other authors' photos
+func (s *ItemPhotoService) GetFlaggedPhotos(ctx context.Context) ([]models.FlaggedPhoto, error) {
+	photos, err := s.itemPhotoRepo.GetFlagged(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get flagged photos", zap.Error(err))
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// ReprocessStoredPhotos strips metadata from and applies orientation to every photo
+// already held in blob storage, then regenerates its variants. Photos whose URL does
+// not point into the blob store are skipped. A photo whose format changes is stored
+// under a new key and its URL is updated.
+func (s *ItemPhotoService) ReprocessStoredPhotos(ctx context.Context) (PhotoReprocessResult, error) {
+	var result PhotoReprocessResult
+
+	photos, err := s.itemPhotoRepo.GetAll(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get photos", zap.Error(err))
+		return result, err
+	}
+
+	for _, photo := range photos {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		key, ok := s.blobStore.Key(photo.URL)
+		if !ok {
+			result.Skipped++
+			continue
+		}
+
+		if err := s.reprocessPhoto(ctx, photo, key); err != nil {
+			s.logger.Error("Failed to reprocess photo", zap.Int("photo_id", photo.ID), zap.String("key", key), zap.Error(err))
+			result.Failed++
+			continue
+		}
+		result.Processed++
+	}
+
+	s.logger.Info("Reprocessed stored photos",
+		zap.Int("processed", result.Processed),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// reprocessPhoto sanitizes a single stored photo in place
+func (s *ItemPhotoService) reprocessPhoto(ctx context.Context, photo models.ItemPhoto, key string) error {
+	rc, err := s.blobStore.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
 		return err
 	}
 
-	if err := s.itemRepo.UpdateHasPhotos(tx, itemID, photoCount > 0); err != nil {
-		s.logger.Error("Failed to update item", zap.Int("item_id", itemID), zap.Error(err))
+	sanitized, err := sanitizePhoto(data)
+	if err != nil {
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		s.logger.Error("Failed to commit transaction", zap.Int("item_id", itemID), zap.Error(err))
+	newKey := key
+	if path.Ext(key) != sanitized.ext {
+		newKey = strings.TrimSuffix(key, path.Ext(key)) + sanitized.ext
+	}
+
+	if err := s.blobStore.Put(ctx, newKey, bytes.NewReader(sanitized.data), sanitized.contentType); err != nil {
 		return err
 	}
 
-	s.logger.Info("Successfully deleted photos", zap.Int("item_id", itemID), zap.Int("photo_count", photoCount))
+	if err := s.itemPhotoRepo.UpdateHash(ctx, photo.ID, sanitized.phash); err != nil {
+		return err
+	}
+
+	url := photo.URL
+	if newKey != key {
+		url = s.blobStore.URL(newKey)
+		if err := s.itemPhotoRepo.UpdateURL(ctx, photo.ID, url); err != nil {
+			s.deleteBlobs(ctx, []string{newKey})
+			return err
+		}
+		s.deleteBlobs(ctx, []string{key})
+	}
+
+	// Variants were rendered from the unrotated original
+	if s.variantPool != nil {
+		if err := s.variantPool.Generate(ctx, newKey, url); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-func (s *ItemPhotoService) CountPhotosByItemID(itemID int) (int, error) {
-	count, err := s.itemPhotoRepo.CountByItemID(s.db, itemID)
+// sanitizedPhoto is a photo re-encoded without metadata, ready for storage
+type sanitizedPhoto struct {
+	data        []byte
+	contentType string
+	ext         string
+	phash       int64
+}
+
+// readPhoto reads an uploaded photo, checks its size and type and sanitizes it
+func readPhoto(upload PhotoUpload, maxBytes int64) (*sanitizedPhoto, error) {
+	data, err := io.ReadAll(io.LimitReader(upload.Body, maxBytes+1))
 	if err != nil {
-		s.logger.Error("Failed to count photos", zap.Int("item_id", itemID), zap.Error(err))
-		return 0, err
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, photoError(ErrPhotoTooLarge, upload.Filename)
 	}
 
-	return count, nil
+	contentType := http.DetectContentType(data)
+	if !allowedPhotoTypes[contentType] {
+		return nil, photoError(ErrUnsupportedPhotoType, upload.Filename).WithDetail("content_type", contentType)
+	}
+
+	// Re-encode so metadata such as GPS coordinates never reaches storage
+	sanitized, err := sanitizePhoto(data)
+	if err != nil {
+		if errors.Is(err, imaging.ErrImageTooLarge) {
+			return nil, photoError(ErrPhotoTooLarge, upload.Filename)
+		}
+		return nil, photoError(ErrUnsupportedPhotoType, upload.Filename)
+	}
+
+	return sanitized, nil
+}
+
+// sanitizePhoto re-encodes a photo without metadata and computes its perceptual hash
+func sanitizePhoto(data []byte) (*sanitizedPhoto, error) {
+	sanitized, err := imaging.Sanitize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	photoType := sanitizedPhotoTypes[sanitized.Format]
+	return &sanitizedPhoto{
+		data:        sanitized.Data,
+		contentType: photoType.contentType,
+		ext:         photoType.ext,
+		// Stored as BIGINT; the bits are what matter, not the sign
+		phash: int64(imaging.DHash(sanitized.Image)),
+	}, nil
+}
+
+// deleteBlobs removes stored objects, logging failures instead of returning them.
+// It cleans up after failed requests, so it carries on when ctx is cancelled.
+func (s *ItemPhotoService) deleteBlobs(ctx context.Context, keys []string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range keys {
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			s.logger.Warn("Failed to delete stored photo", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// sameIDs reports whether both slices hold the same IDs, ignoring order
+func sameIDs(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	seen := make(map[int]int, len(a))
+	for _, id := range a {
+		seen[id]++
+	}
+	for _, id := range b {
+		if seen[id] == 0 {
+			return false
+		}
+		seen[id]--
+	}
+
+	return true
 }
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"shary_be/internal/i18n"
+)
+
+func TestItemPhotoService_AddPhotos_LimitRollsBack(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	item := s.createItem(t, s.createAuthor(t), nil, "https://example.com/1.jpg", "https://example.com/2.jpg")
+
+	err := s.photos.AddPhotos(ctx, item.ID, []string{"https://example.com/3.jpg", "https://example.com/4.jpg"})
+	wantError(t, err, ErrPhotoLimitExceeded)
+
+	count, err := s.photos.CountPhotosByItemID(ctx, item.ID)
+	if err != nil {
+		t.Fatalf("CountPhotosByItemID: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("photo count = %d, want 2", count)
+	}
+
+	err = s.photos.AddPhotos(ctx, item.ID+1, []string{"https://example.com/3.jpg"})
+	wantError(t, err, ErrItemNotFound)
+}
+
+func TestItemPhotoService_DeletePhotos(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	item := s.createItem(t, s.createAuthor(t), nil, "https://example.com/1.jpg", "https://example.com/2.jpg")
+
+	photos, err := s.photos.GetPhotosByItemID(ctx, item.ID)
+	if err != nil {
+		t.Fatalf("GetPhotosByItemID: %v", err)
+	}
+
+	// Deleting the cover makes the remaining photo the cover
+	if err := s.photos.DeletePhotos(ctx, item.ID, []int{photos[0].ID}); err != nil {
+		t.Fatalf("DeletePhotos: %v", err)
+	}
+	remaining, err := s.photos.GetPhotosByItemID(ctx, item.ID)
+	if err != nil {
+		t.Fatalf("GetPhotosByItemID: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != photos[1].ID || !remaining[0].IsCover {
+		t.Fatalf("remaining photos = %+v, want photo %d as the cover", remaining, photos[1].ID)
+	}
+
+	if err := s.photos.DeletePhotos(ctx, item.ID, []int{photos[1].ID}); err != nil {
+		t.Fatalf("DeletePhotos: %v", err)
+	}
+	got, err := s.items.GetItemByID(ctx, item.ID, i18n.Default)
+	if err != nil {
+		t.Fatalf("GetItemByID: %v", err)
+	}
+	if got.HasPhotos {
+		t.Error("item without photos has has_photos set")
+	}
+
+	err = s.photos.DeletePhotos(ctx, item.ID, []int{photos[1].ID})
+	wantError(t, err, ErrPhotoNotFound)
+}
+
+func TestItemPhotoService_DeletePhotos_OtherItem(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	authorID := s.createAuthor(t)
+	item := s.createItem(t, authorID, nil, "https://example.com/1.jpg")
+	other := s.createItem(t, authorID, nil, "https://example.com/2.jpg")
+
+	photos, err := s.photos.GetPhotosByItemID(ctx, item.ID)
+	if err != nil {
+		t.Fatalf("GetPhotosByItemID: %v", err)
+	}
+	otherPhotos, err := s.photos.GetPhotosByItemID(ctx, other.ID)
+	if err != nil {
+		t.Fatalf("GetPhotosByItemID: %v", err)
+	}
+
+	// A photo of another item fails the whole request
+	err = s.photos.DeletePhotos(ctx, item.ID, []int{photos[0].ID, otherPhotos[0].ID})
+	wantError(t, err, ErrPhotoNotFound)
+
+	for _, itemID := range []int{item.ID, other.ID} {
+		count, err := s.photos.CountPhotosByItemID(ctx, itemID)
+		if err != nil {
+			t.Fatalf("CountPhotosByItemID: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("item %d has %d photos, want 1", itemID, count)
+		}
+	}
+}
+
+func TestItemPhotoService_ReorderPhotos(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	item := s.createItem(t, s.createAuthor(t), nil, "https://example.com/1.jpg", "https://example.com/2.jpg")
+
+	photos, err := s.photos.GetPhotosByItemID(ctx, item.ID)
+	if err != nil {
+		t.Fatalf("GetPhotosByItemID: %v", err)
+	}
+
+	_, err = s.photos.ReorderPhotos(ctx, item.ID, []int{photos[1].ID})
+	wantError(t, err, ErrPhotoOrderMismatch)
+
+	reordered, err := s.photos.ReorderPhotos(ctx, item.ID, []int{photos[1].ID, photos[0].ID})
+	if err != nil {
+		t.Fatalf("ReorderPhotos: %v", err)
+	}
+	positions := map[int]int{}
+	for _, photo := range reordered {
+		positions[photo.ID] = photo.Position
+	}
+	if len(reordered) != 2 || positions[photos[1].ID] >= positions[photos[0].ID] {
+		t.Errorf("reordered photos = %+v, want %d before %d", reordered, photos[1].ID, photos[0].ID)
+	}
+}
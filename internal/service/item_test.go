@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"shary_be/internal/i18n"
+	"shary_be/internal/models"
+)
+
+func TestItemService_CreateItem(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	authorID := s.createAuthor(t)
+
+	category := s.createCategory(t, "Велосипеды", nil)
+	if _, err := s.categories.UpsertTranslation(ctx, category.ID, i18n.English, &models.UpsertCategoryTranslationRequest{Name: "Bikes"}); err != nil {
+		t.Fatalf("UpsertTranslation: %v", err)
+	}
+
+	item := s.createItem(t, authorID, &category.ID, "https://example.com/1.jpg", "https://example.com/2.jpg")
+	if !item.HasPhotos {
+		t.Error("created item has no photos")
+	}
+
+	got, err := s.items.GetItemByID(ctx, item.ID, i18n.English)
+	if err != nil {
+		t.Fatalf("GetItemByID: %v", err)
+	}
+	if len(got.Photos) != 2 || got.Photos[0] != "https://example.com/1.jpg" {
+		t.Errorf("photos = %v, want both in the given order", got.Photos)
+	}
+	if got.Category.Name == nil || *got.Category.Name != "Bikes" {
+		t.Errorf("category name = %v, want Bikes", got.Category.Name)
+	}
+	if item.Version != got.Version {
+		t.Errorf("created item version = %d, want %d as read back", item.Version, got.Version)
+	}
+
+	_, err = s.items.GetItemByID(ctx, item.ID+1, i18n.Default)
+	wantError(t, err, ErrItemNotFound)
+}
+
+func TestItemService_CreateItem_TooManyPhotos(t *testing.T) {
+	s := newTestServices(t)
+	authorID := s.createAuthor(t)
+
+	_, err := s.items.CreateItem(context.Background(), &models.CreateItemRequest{
+		Title:       "City bike",
+		Description: "A city bike in good condition",
+		Price:       1000,
+		Location:    "Almaty",
+		AuthorID:    authorID,
+		Photos: []string{
+			"https://example.com/1.jpg", "https://example.com/2.jpg",
+			"https://example.com/3.jpg", "https://example.com/4.jpg",
+		},
+	})
+	wantError(t, err, ErrPhotoLimitExceeded)
+}
+
+func TestItemService_PatchItem(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	item := s.createItem(t, s.createAuthor(t), nil)
+
+	current, err := s.items.GetItemByID(ctx, item.ID, i18n.Default)
+	if err != nil {
+		t.Fatalf("GetItemByID: %v", err)
+	}
+
+	patched, err := s.items.PatchItem(ctx, item.ID, []byte(`{"price": 1500}`), Precondition{current.Version}, i18n.Default)
+	if err != nil {
+		t.Fatalf("PatchItem: %v", err)
+	}
+	if patched.Price != 1500 || patched.Title != current.Title {
+		t.Errorf("patched price %v, title %q; want 1500, %q", patched.Price, patched.Title, current.Title)
+	}
+	if patched.Version <= current.Version {
+		t.Errorf("version = %d, want more than %d", patched.Version, current.Version)
+	}
+
+	// The precondition names the version the first patch replaced
+	_, err = s.items.PatchItem(ctx, item.ID, []byte(`{"price": 2000}`), Precondition{current.Version}, i18n.Default)
+	wantError(t, err, ErrVersionMismatch)
+
+	got, err := s.items.GetItemByID(ctx, item.ID, i18n.Default)
+	if err != nil {
+		t.Fatalf("GetItemByID: %v", err)
+	}
+	if got.Price != 1500 || got.Version != patched.Version {
+		t.Errorf("after rejected patch: price %v, version %d; want 1500, %d", got.Price, got.Version, patched.Version)
+	}
+
+	_, err = s.items.PatchItem(ctx, item.ID, []byte(`{"title": ""}`), nil, i18n.Default)
+	if err == nil {
+		t.Error("PatchItem with an empty title succeeded")
+	}
+}
+
+func TestItemService_DeleteItem(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	item := s.createItem(t, s.createAuthor(t), nil, "https://example.com/1.jpg")
+
+	current, err := s.items.GetItemByID(ctx, item.ID, i18n.Default)
+	if err != nil {
+		t.Fatalf("GetItemByID: %v", err)
+	}
+
+	err = s.items.DeleteItem(ctx, item.ID, Precondition{current.Version + 1})
+	wantError(t, err, ErrVersionMismatch)
+
+	if err := s.items.DeleteItem(ctx, item.ID, Precondition{current.Version}); err != nil {
+		t.Fatalf("DeleteItem: %v", err)
+	}
+
+	err = s.items.DeleteItem(ctx, item.ID, nil)
+	wantError(t, err, ErrItemNotFound)
+
+	count, err := s.photos.CountPhotosByItemID(ctx, item.ID)
+	if err != nil {
+		t.Fatalf("CountPhotosByItemID: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("photos left after delete = %d, want 0", count)
+	}
+}
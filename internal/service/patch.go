@@ -0,0 +1,20 @@
+package service
+
+import (
+	"net/http"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/mergepatch"
+)
+
+// ErrInvalidPatch is returned when a merge patch is malformed or names unknown fields
+var ErrInvalidPatch = apperror.New(http.StatusBadRequest, "invalid_patch", "invalid merge patch")
+
+// applyPatch merges a JSON Merge Patch into the editable representation of a resource.
+// The result still has to be validated like a full replacement.
+func applyPatch(target interface{}, patch []byte) error {
+	if err := mergepatch.ApplyTo(target, patch); err != nil {
+		return ErrInvalidPatch.WithMessage(err.Error()).Wrap(err)
+	}
+	return nil
+}
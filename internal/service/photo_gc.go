@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"shary_be/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// photoKeyPrefix is the blob storage prefix that item photos and their variants live under
+const photoKeyPrefix = "items"
+
+// PhotoGCOptions configures orphaned photo collection
+type PhotoGCOptions struct {
+	// GracePeriod protects recently stored files, e.g. uploads whose rows are not committed yet
+	GracePeriod time.Duration
+	// DryRun reports what would be removed without deleting anything
+	DryRun bool
+}
+
+// PhotoGCResult reports a collection run
+type PhotoGCResult struct {
+	Scanned      int      `json:"scanned"`
+	Removed      []string `json:"removed"`
+	RemovedBytes int64    `json:"removed_bytes"`
+	Failed       int      `json:"failed"`
+	DryRun       bool     `json:"dry_run"`
+}
+
+// PhotoGarbageCollector removes stored photo files that no item photo references any
+// more, such as files of deleted photos and items or uploads that were never recorded
+type PhotoGarbageCollector struct {
+	blobStore     storage.BlobStore
+	itemPhotoRepo ItemPhotoRepository
+	options       PhotoGCOptions
+	logger        *zap.Logger
+}
+
+// NewPhotoGarbageCollector creates a photo garbage collector
+func NewPhotoGarbageCollector(blobStore storage.BlobStore, itemPhotoRepo ItemPhotoRepository, options PhotoGCOptions, logger *zap.Logger) *PhotoGarbageCollector {
+	return &PhotoGarbageCollector{
+		blobStore:     blobStore,
+		itemPhotoRepo: itemPhotoRepo,
+		options:       options,
+		logger:        logger,
+	}
+}
+
+// Collect reconciles blob storage against item_photos once. Referenced URLs are loaded
+// before storage is listed, so files stored during the run are protected by the grace period.
+func (g *PhotoGarbageCollector) Collect(ctx context.Context) (PhotoGCResult, error) {
+	result := PhotoGCResult{DryRun: g.options.DryRun}
+
+	urls, err := g.itemPhotoRepo.GetReferencedURLs(ctx)
+	if err != nil {
+		g.logger.Error("Failed to get referenced photo URLs", zap.Error(err))
+		return result, err
+	}
+
+	referenced := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		if key, ok := g.blobStore.Key(url); ok {
+			referenced[key] = true
+		}
+	}
+
+	cutoff := time.Now().Add(-g.options.GracePeriod)
+
+	var orphans []storage.BlobInfo
+	err = g.blobStore.List(ctx, photoKeyPrefix, func(info storage.BlobInfo) error {
+		result.Scanned++
+		if !referenced[info.Key] && info.ModTime.Before(cutoff) {
+			orphans = append(orphans, info)
+		}
+		return nil
+	})
+	if err != nil {
+		g.logger.Error("Failed to list stored photos", zap.Error(err))
+		return result, err
+	}
+
+	for _, orphan := range orphans {
+		if !g.options.DryRun {
+			if err := g.blobStore.Delete(ctx, orphan.Key); err != nil {
+				g.logger.Error("Failed to delete orphaned photo", zap.String("key", orphan.Key), zap.Error(err))
+				result.Failed++
+				continue
+			}
+		}
+
+		g.logger.Info("Removed orphaned photo",
+			zap.String("key", orphan.Key),
+			zap.Int64("size", orphan.Size),
+			zap.Bool("dry_run", g.options.DryRun),
+		)
+		result.Removed = append(result.Removed, orphan.Key)
+		result.RemovedBytes += orphan.Size
+	}
+
+	g.logger.Info("Photo garbage collection finished",
+		zap.Int("scanned", result.Scanned),
+		zap.Int("removed", len(result.Removed)),
+		zap.Int64("removed_bytes", result.RemovedBytes),
+		zap.Int("failed", result.Failed),
+		zap.Bool("dry_run", result.DryRun),
+	)
+
+	return result, nil
+}
+
+// Run collects garbage every interval until the context is cancelled
+func (g *PhotoGarbageCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Errors are logged by Collect; the next run tries again
+			g.Collect(ctx)
+		}
+	}
+}
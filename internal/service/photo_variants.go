@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"shary_be/internal/imaging"
+	"shary_be/internal/models"
+	"shary_be/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// variantJob asks for the variants of one stored photo to be generated
+type variantJob struct {
+	key string
+	url string
+}
+
+// PhotoVariantPool generates resized photo variants in a fixed number of background
+// workers. Jobs are queued without blocking; when the queue is full the job is dropped
+// and the photo is served without variants.
+type PhotoVariantPool struct {
+	blobStore     storage.BlobStore
+	itemPhotoRepo ItemPhotoRepository
+	variants      []imaging.Variant
+	logger        *zap.Logger
+	jobs          chan variantJob
+	wg            sync.WaitGroup
+	closeOnce     sync.Once
+}
+
+// NewPhotoVariantPool creates the pool and starts its workers
+func NewPhotoVariantPool(blobStore storage.BlobStore, itemPhotoRepo ItemPhotoRepository, logger *zap.Logger, workers int, queueSize int) *PhotoVariantPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &PhotoVariantPool{
+		blobStore:     blobStore,
+		itemPhotoRepo: itemPhotoRepo,
+		variants:      imaging.DefaultVariants,
+		logger:        logger,
+		jobs:          make(chan variantJob, queueSize),
+	}
+
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	return p
+}
+
+// Enqueue schedules variant generation for a stored photo.
+// It reports false when the queue is full and the job was dropped.
+func (p *PhotoVariantPool) Enqueue(key string, url string) bool {
+	select {
+	case p.jobs <- variantJob{key: key, url: url}:
+		return true
+	default:
+		p.logger.Warn("Photo variant queue is full, skipping", zap.String("key", key))
+		return false
+	}
+}
+
+// Close stops accepting jobs and waits for queued jobs to finish
+func (p *PhotoVariantPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.jobs)
+	})
+	p.wg.Wait()
+}
+
+func (p *PhotoVariantPool) work() {
+	defer p.wg.Done()
+
+	for job := range p.jobs {
+		if err := p.process(context.Background(), job); err != nil {
+			p.logger.Error("Failed to generate photo variants", zap.String("key", job.key), zap.Error(err))
+		}
+	}
+}
+
+// Generate renders and records the variants of a stored photo synchronously
+func (p *PhotoVariantPool) Generate(ctx context.Context, key string, url string) error {
+	return p.process(ctx, variantJob{key: key, url: url})
+}
+
+// process renders every variant of the photo, stores them and records their URLs
+func (p *PhotoVariantPool) process(ctx context.Context, job variantJob) error {
+	rc, err := p.blobStore.Get(ctx, job.key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+
+	img, _, err := imaging.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	variants := make(models.PhotoVariants, len(p.variants))
+	for _, v := range p.variants {
+		rendered, err := v.Render(img)
+		if err != nil {
+			return err
+		}
+
+		key := imaging.VariantKey(job.key, v)
+		if err := p.blobStore.Put(ctx, key, bytes.NewReader(rendered), v.ContentType()); err != nil {
+			return err
+		}
+		variants[v.Name] = p.blobStore.URL(key)
+	}
+
+	if err := p.itemPhotoRepo.UpdateVariants(ctx, job.url, variants); err != nil {
+		return err
+	}
+
+	p.logger.Debug("Generated photo variants", zap.String("key", job.key), zap.Int("variants", len(variants)))
+	return nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"net/http"
+
+	"shary_be/internal/apperror"
+)
+
+// ErrVersionMismatch is returned when a conditional request names a version other than
+// the current one, i.e. the resource was changed since the client read it
+var ErrVersionMismatch = apperror.New(http.StatusPreconditionFailed, "version_mismatch", "resource has been modified")
+
+// Precondition holds the versions a conditional request may be applied to, taken from
+// its If-Match header. A nil Precondition matches any version, so requests without
+// If-Match are applied unconditionally; an empty one matches none.
+type Precondition []int
+
+// Matches reports whether a resource at version may be changed
+func (p Precondition) Matches(version int) bool {
+	if p == nil {
+		return true
+	}
+	for _, v := range p {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// versionMismatchError reports the current version of a resource that failed a precondition
+func versionMismatchError(version int) error {
+	return ErrVersionMismatch.WithDetail("version", version)
+}
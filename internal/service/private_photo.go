@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/models"
+	"shary_be/internal/repository"
+	"shary_be/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotPrivatePhoto is returned when a signed URL is requested for a public object
+	ErrNotPrivatePhoto = apperror.New(http.StatusBadRequest, "not_private_photo", "not a private photo")
+	// ErrInvalidSignedURL is returned when a signed URL was not issued by us or was altered
+	ErrInvalidSignedURL = apperror.New(http.StatusForbidden, "invalid_signature", "invalid signature")
+	// ErrSignedURLExpired is returned when a signed URL is used after it expired
+	ErrSignedURLExpired = apperror.New(http.StatusForbidden, "url_expired", "signed URL has expired")
+	// ErrUnknownOwner is returned when a private photo is stored for a user that does not exist
+	ErrUnknownOwner = apperror.New(http.StatusUnauthorized, "unknown_user", "user not found")
+)
+
+// PrivatePhoto is a stored private photo with a signed URL granting temporary access
+type PrivatePhoto struct {
+	Key       string    `json:"key"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PrivatePhotoService stores photos that must not be publicly reachable, such as ID
+// documents or damage evidence, and hands out expiring signed URLs for them to the
+// users who stored them
+type PrivatePhotoService struct {
+	privatePhotoRepo PrivatePhotoRepository
+	blobStore        storage.BlobStore
+	signer           *storage.URLSigner
+	urlTTL           time.Duration
+	maxBytes         int64
+	logger           *zap.Logger
+}
+
+// NewPrivatePhotoService creates a private photo service. Signed URLs stay valid for urlTTL.
+func NewPrivatePhotoService(privatePhotoRepo PrivatePhotoRepository, blobStore storage.BlobStore, signer *storage.URLSigner, urlTTL time.Duration, maxBytes int64, logger *zap.Logger) *PrivatePhotoService {
+	return &PrivatePhotoService{
+		privatePhotoRepo: privatePhotoRepo,
+		blobStore:        blobStore,
+		signer:           signer,
+		urlTTL:           urlTTL,
+		maxBytes:         maxBytes,
+		logger:           logger,
+	}
+}
+
+// Store sanitizes and stores a private photo owned by ownerID, returning its key and
+// a signed URL
+func (s *PrivatePhotoService) Store(ctx context.Context, ownerID int, upload PhotoUpload) (*PrivatePhoto, error) {
+	sanitized, err := readPhoto(upload, s.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := storage.NewKey(storage.PrivatePrefix+"photos", sanitized.ext)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.blobStore.Put(ctx, key, bytes.NewReader(sanitized.data), sanitized.contentType); err != nil {
+		s.logger.Error("Failed to store private photo", zap.String("key", key), zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.privatePhotoRepo.Create(ctx, &models.PrivatePhotoRecord{Key: key, OwnerID: ownerID}); err != nil {
+		// Without an owner nobody could get a URL for the file again
+		if deleteErr := s.blobStore.Delete(ctx, key); deleteErr != nil {
+			s.logger.Error("Failed to delete unrecorded private photo", zap.String("key", key), zap.Error(deleteErr))
+		}
+		if errors.Is(err, repository.ErrUnknownOwner) {
+			return nil, ErrUnknownOwner
+		}
+		s.logger.Error("Failed to record private photo", zap.String("key", key), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Successfully stored private photo", zap.String("key", key), zap.Int("owner_id", ownerID))
+	return s.signedURL(key), nil
+}
+
+// RenewURL issues a new signed URL for a private photo stored by userID.
+// Photos stored by other users are reported as ErrPhotoNotFound, like missing ones.
+func (s *PrivatePhotoService) RenewURL(ctx context.Context, userID int, key string) (*PrivatePhoto, error) {
+	if !storage.IsPrivateKey(key) {
+		return nil, ErrNotPrivatePhoto
+	}
+
+	photo, err := s.privatePhotoRepo.GetByKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPhotoNotFound
+		}
+		s.logger.Error("Failed to get private photo", zap.String("key", key), zap.Error(err))
+		return nil, err
+	}
+	if photo.OwnerID != userID {
+		s.logger.Warn("Refused signed URL for another user's private photo", zap.String("key", key), zap.Int("user_id", userID))
+		return nil, ErrPhotoNotFound
+	}
+
+	return s.signedURL(key), nil
+}
+
+// signedURL issues a new signed URL for a private photo
+func (s *PrivatePhotoService) signedURL(key string) *PrivatePhoto {
+	expiresAt := time.Now().Add(s.urlTTL).Truncate(time.Second)
+	return &PrivatePhoto{
+		Key:       key,
+		URL:       s.signer.SignedURL(key, expiresAt),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Open verifies a signed URL and opens the private photo it grants access to.
+// It returns ErrInvalidSignedURL, ErrSignedURLExpired or ErrPhotoNotFound.
+func (s *PrivatePhotoService) Open(ctx context.Context, key string, expires string, signature string) (io.ReadCloser, error) {
+	if !storage.IsPrivateKey(key) {
+		return nil, ErrPhotoNotFound
+	}
+
+	if err := s.signer.Verify(key, expires, signature, time.Now()); err != nil {
+		s.logger.Warn("Rejected private photo request", zap.String("key", key), zap.Error(err))
+		if errors.Is(err, storage.ErrURLExpired) {
+			return nil, ErrSignedURLExpired
+		}
+		return nil, ErrInvalidSignedURL
+	}
+
+	rc, err := s.blobStore.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrPhotoNotFound
+		}
+		s.logger.Error("Failed to open private photo", zap.String("key", key), zap.Error(err))
+		return nil, err
+	}
+
+	return rc, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"shary_be/internal/models"
+	"shary_be/internal/repository/memory"
+	"shary_be/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+func TestPrivatePhotoService_RenewURL(t *testing.T) {
+	db := memory.NewDB()
+	users := NewUserService(memory.NewUserRepository(db), zap.NewNop(), memory.NewTxManager(db))
+	blobStore, err := storage.NewLocalStore(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	signer := storage.NewURLSigner([]byte("secret"), "/api/v1/private_photos")
+	photos := NewPrivatePhotoService(memory.NewPrivatePhotoRepository(db), blobStore, signer, time.Hour, 1<<20, zap.NewNop())
+	ctx := context.Background()
+
+	var owners []int
+	for _, identity := range []string{"900101300123", "900202400234"} {
+		user, err := users.CreateUser(ctx, &models.CreateUserRequest{FirstName: "Aigerim", LastName: "Sadykova", Identity: identity})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		owners = append(owners, user.ID)
+	}
+
+	upload := func() PhotoUpload {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+			t.Fatalf("encoding photo: %v", err)
+		}
+		return PhotoUpload{Filename: "passport.png", Body: &buf}
+	}
+
+	stored, err := photos.Store(ctx, owners[0], upload())
+	if err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	renewed, err := photos.RenewURL(ctx, owners[0], stored.Key)
+	if err != nil {
+		t.Fatalf("RenewURL: %v", err)
+	}
+	if renewed.Key != stored.Key || renewed.URL == "" {
+		t.Errorf("renewed photo = %+v, want a URL for %s", renewed, stored.Key)
+	}
+
+	_, err = photos.RenewURL(ctx, owners[1], stored.Key)
+	wantError(t, err, ErrPhotoNotFound)
+	_, err = photos.RenewURL(ctx, owners[0], storage.PrivatePrefix+"photos/missing.png")
+	wantError(t, err, ErrPhotoNotFound)
+
+	// A photo without an owner is not kept
+	_, err = photos.Store(ctx, owners[1]+1, upload())
+	wantError(t, err, ErrUnknownOwner)
+	count := 0
+	err = blobStore.List(ctx, storage.PrivatePrefix, func(storage.BlobInfo) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("stored %d private photos, want 1", count)
+	}
+}
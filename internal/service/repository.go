@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+
+	"shary_be/internal/models"
+	"shary_be/internal/repository"
+)
+
+// The services depend on the storage they need through the interfaces below, so they
+// can run on the PostgreSQL repositories in package repository as well as on the
+// in-memory ones in package memory. Methods that find nothing return sql.ErrNoRows,
+// except the category getters, which return a nil category.
+
+// TxManager runs units of work in transactions. Repository methods called with the
+// context passed to fn run in the transaction, which is rolled back if fn returns an error.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// ItemRepository stores items
+type ItemRepository interface {
+	Create(ctx context.Context, item *models.Item, photos []string) error
+	GetByID(ctx context.Context, id int, lang string) (*models.ItemResponse, error)
+	GetAll(ctx context.Context, filter *models.ItemFilter) ([]models.ItemResponse, error)
+	Exists(ctx context.Context, id int) (bool, error)
+	GetAuthorID(ctx context.Context, id int) (int, error)
+	LockByID(ctx context.Context, id int) error
+	Update(ctx context.Context, item *models.ItemToUpdate) error
+	Delete(ctx context.Context, id int) error
+	DeleteVersion(ctx context.Context, id, version int) error
+	GetByLocation(ctx context.Context, location string, lang string) ([]models.ItemResponse, error)
+	GetAvailableItems(ctx context.Context, lang string) ([]models.ItemResponse, error)
+	GetByCategory(ctx context.Context, categoryID int, lang string) ([]models.ItemResponse, error)
+	UpdateHasPhotos(ctx context.Context, itemID int, hasPhotos bool) error
+}
+
+// ItemPhotoRepository stores the photos of items
+type ItemPhotoRepository interface {
+	GetPhotosByItemID(ctx context.Context, itemID int) ([]models.ItemPhoto, error)
+	GetAll(ctx context.Context) ([]models.ItemPhoto, error)
+	GetReferencedURLs(ctx context.Context) ([]string, error)
+	Add(ctx context.Context, itemID int, photos []models.NewItemPhoto) error
+	Delete(ctx context.Context, itemID int, ids []int) error
+	CountByItemID(ctx context.Context, itemID int) (int, error)
+	UpdateVariants(ctx context.Context, url string, variants models.PhotoVariants) error
+	UpdateURL(ctx context.Context, id int, url string) error
+	EnsureCover(ctx context.Context, itemID int) error
+	LockIDsByItemID(ctx context.Context, itemID int) ([]int, error)
+	UpdatePositions(ctx context.Context, itemID int, ids []int) error
+	SetCover(ctx context.Context, itemID int, photoID int) error
+	FindSimilar(ctx context.Context, hash int64, excludeAuthorID int, maxDistance int) (*models.SimilarPhoto, error)
+	GetFlagged(ctx context.Context) ([]models.FlaggedPhoto, error)
+	UpdateHash(ctx context.Context, id int, hash int64) error
+}
+
+// CategoryRepository stores categories and their translations
+type CategoryRepository interface {
+	GetAll(ctx context.Context, lang string) ([]models.Category, error)
+	Create(ctx context.Context, category *models.Category) error
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*models.Category, error)
+	GetLocalizedByID(ctx context.Context, id int, lang string) (*models.Category, error)
+	GetLocalizedBySlug(ctx context.Context, slug string, lang string) (*models.Category, error)
+	SlugExists(ctx context.Context, slug string, excludeID int) (bool, error)
+	GetTranslations(ctx context.Context, categoryID int) ([]models.CategoryTranslation, error)
+	UpsertTranslation(ctx context.Context, translation *models.CategoryTranslation) error
+	DeleteTranslation(ctx context.Context, categoryID int, lang string) error
+	GetDescendantIDs(ctx context.Context, id int) ([]int, error)
+	LockByIDs(ctx context.Context, ids []int) ([]models.Category, error)
+	CountItems(ctx context.Context, categoryID int) (int, error)
+	LockItemAttributes(ctx context.Context, categoryID int) (map[int]models.ItemAttributes, error)
+	ReassignItems(ctx context.Context, fromID, toID int) (int, error)
+	ReparentChildren(ctx context.Context, fromID int, toParentID *int) error
+}
+
+// UserRepository stores users
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	IdentityExists(ctx context.Context, identity string) (bool, error)
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	LockByID(ctx context.Context, id int) error
+	Update(ctx context.Context, user *models.User) error
+}
+
+// PrivatePhotoRepository stores the owners of private photos
+type PrivatePhotoRepository interface {
+	Create(ctx context.Context, photo *models.PrivatePhotoRecord) error
+	GetByKey(ctx context.Context, key string) (*models.PrivatePhotoRecord, error)
+}
+
+var (
+	_ TxManager              = (*repository.TxManager)(nil)
+	_ ItemRepository         = (*repository.ItemRepository)(nil)
+	_ ItemPhotoRepository    = (*repository.ItemPhotoRepository)(nil)
+	_ CategoryRepository     = (*repository.CategoryRepository)(nil)
+	_ UserRepository         = (*repository.UserRepository)(nil)
+	_ PrivatePhotoRepository = (*repository.PrivatePhotoRepository)(nil)
+)
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"shary_be/internal/models"
+	"shary_be/internal/repository/memory"
+
+	"go.uber.org/zap"
+)
+
+var (
+	_ TxManager              = (*memory.TxManager)(nil)
+	_ ItemRepository         = (*memory.ItemRepository)(nil)
+	_ ItemPhotoRepository    = (*memory.ItemPhotoRepository)(nil)
+	_ CategoryRepository     = (*memory.CategoryRepository)(nil)
+	_ UserRepository         = (*memory.UserRepository)(nil)
+	_ PrivatePhotoRepository = (*memory.PrivatePhotoRepository)(nil)
+)
+
+// testMaxPhotos is the per-item photo limit of the services built by newTestServices
+const testMaxPhotos = 3
+
+// testServices holds services backed by in-memory repositories sharing one DB
+type testServices struct {
+	users      *UserService
+	items      *ItemService
+	photos     *ItemPhotoService
+	categories *CategoryService
+}
+
+func newTestServices(t *testing.T) *testServices {
+	t.Helper()
+
+	db := memory.NewDB()
+	txManager := memory.NewTxManager(db)
+	logger := zap.NewNop()
+
+	itemRepo := memory.NewItemRepository(db)
+	itemPhotoRepo := memory.NewItemPhotoRepository(db)
+	categoryRepo := memory.NewCategoryRepository(db)
+
+	photos := NewItemPhotoService(itemPhotoRepo, itemRepo, nil, nil, nil, PhotoUploadOptions{}, testMaxPhotos, PhotoDuplicateOptions{}, logger, txManager)
+
+	return &testServices{
+		users:      NewUserService(memory.NewUserRepository(db), logger, txManager),
+		items:      NewItemService(itemRepo, categoryRepo, photos, testMaxPhotos, logger, txManager),
+		photos:     photos,
+		categories: NewCategoryService(categoryRepo, logger, txManager),
+	}
+}
+
+// createAuthor stores a user to author items
+func (s *testServices) createAuthor(t *testing.T) int {
+	t.Helper()
+
+	user, err := s.users.CreateUser(context.Background(), &models.CreateUserRequest{
+		FirstName: "Aigerim",
+		LastName:  "Sadykova",
+		Identity:  "900101300123",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user.ID
+}
+
+// createItem stores an item with the given photos and category
+func (s *testServices) createItem(t *testing.T, authorID int, categoryID *int, photos ...string) *models.Item {
+	t.Helper()
+
+	req := &models.CreateItemRequest{
+		Title:       "City bike",
+		Description: "A city bike in good condition",
+		Price:       1000,
+		Location:    "Almaty",
+		AuthorID:    authorID,
+		CategoryID:  categoryID,
+	}
+	if len(photos) > 0 {
+		req.Photos = photos
+	}
+
+	item, err := s.items.CreateItem(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	return item
+}
+
+// createCategory stores a category under parentID
+func (s *testServices) createCategory(t *testing.T, name string, parentID *int) *models.Category {
+	t.Helper()
+
+	category, err := s.categories.CreateCategory(context.Background(), &models.CreateCategoryRequest{Name: name, ParentID: parentID})
+	if err != nil {
+		t.Fatalf("CreateCategory(%q): %v", name, err)
+	}
+	return category
+}
+
+// wantError fails the test unless err is target
+func wantError(t *testing.T, err error, target error) {
+	t.Helper()
+
+	if !errors.Is(err, target) {
+		t.Fatalf("error = %v, want %v", err, target)
+	}
+}
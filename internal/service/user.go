@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"shary_be/internal/apperror"
+	"shary_be/internal/models"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUserNotFound is returned when the requested user does not exist
+	ErrUserNotFound = apperror.New(http.StatusNotFound, "user_not_found", "user not found")
+	// ErrIdentityTaken is returned when a user with the identity number already exists
+	ErrIdentityTaken = apperror.New(http.StatusConflict, "identity_taken", "a user with this identity number already exists")
+)
+
+// UserService handles business logic for users
+type UserService struct {
+	userRepo  UserRepository
+	logger    *zap.Logger
+	txManager TxManager
+}
+
+// NewUserService creates a new user service
+func NewUserService(userRepo UserRepository, logger *zap.Logger, txManager TxManager) *UserService {
+	return &UserService{
+		userRepo:  userRepo,
+		logger:    logger,
+		txManager: txManager,
+	}
+}
+
+// CreateUser creates a user. Users sign up elsewhere; this is for operators creating
+// accounts such as administrators.
+func (s *UserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid create user request", zap.Error(err))
+		return nil, apperror.Validation(err)
+	}
+
+	user := &models.User{
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Identity:  req.Identity,
+		Phone:     req.Phone,
+		IsAdmin:   req.IsAdmin,
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := s.userRepo.IdentityExists(ctx, req.Identity)
+		if err != nil {
+			s.logger.Error("Failed to check user identity", zap.Error(err))
+			return err
+		}
+		if exists {
+			return ErrIdentityTaken
+		}
+
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			s.logger.Error("Failed to create user", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User created successfully", zap.Int("user_id", user.ID), zap.Bool("is_admin", user.IsAdmin))
+	return user, nil
+}
+
+// GetUser retrieves a user by ID
+func (s *UserService) GetUser(ctx context.Context, id int) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		s.logger.Error("Failed to get user", zap.Int("user_id", id), zap.Error(err))
+		return nil, err
+	}
+	return user, nil
+}
+
+// ReplaceUser replaces a user's profile. Fields missing from req are cleared.
+func (s *UserService) ReplaceUser(ctx context.Context, id int, req *models.ReplaceUserRequest) (*models.User, error) {
+	return s.updateUser(ctx, id, func(*models.ReplaceUserRequest) (*models.ReplaceUserRequest, error) {
+		return req, nil
+	})
+}
+
+// PatchUser applies a JSON Merge Patch to a user's profile. A null member clears the
+// field, e.g. "phone": null.
+func (s *UserService) PatchUser(ctx context.Context, id int, patch []byte) (*models.User, error) {
+	return s.updateUser(ctx, id, func(current *models.ReplaceUserRequest) (*models.ReplaceUserRequest, error) {
+		if err := applyPatch(current, patch); err != nil {
+			return nil, err
+		}
+		return current, nil
+	})
+}
+
+// updateUser saves the profile that change derives from the user's current one
+func (s *UserService) updateUser(ctx context.Context, id int, change func(current *models.ReplaceUserRequest) (*models.ReplaceUserRequest, error)) (*models.User, error) {
+	var user *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Lock the user so concurrent patches are applied one after another
+		if err := s.userRepo.LockByID(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			s.logger.Error("Failed to lock user for update", zap.Int("user_id", id), zap.Error(err))
+			return err
+		}
+
+		var err error
+		user, err = s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			s.logger.Error("Failed to get user for update", zap.Int("user_id", id), zap.Error(err))
+			return err
+		}
+
+		req, err := change(user.ReplaceRequest())
+		if err != nil {
+			return err
+		}
+		if err := req.Validate(); err != nil {
+			s.logger.Error("Invalid user update", zap.Error(err))
+			return apperror.Validation(err)
+		}
+
+		user.FirstName = req.FirstName
+		user.LastName = req.LastName
+		user.Phone = req.Phone
+		user.AvatarURL = req.AvatarURL
+
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			s.logger.Error("Failed to update user", zap.Int("user_id", id), zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User updated successfully", zap.Int("user_id", id))
+	return user, nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"shary_be/internal/models"
+)
+
+func TestUserService_CreateUser(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	req := &models.CreateUserRequest{FirstName: "Aigerim", LastName: "Sadykova", Identity: "900101300123", IsAdmin: true}
+	user, err := s.users.CreateUser(ctx, req)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	got, err := s.users.GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if !got.IsAdmin || got.Identity != req.Identity {
+		t.Errorf("stored user is_admin %v, identity %q; want true, %q", got.IsAdmin, got.Identity, req.Identity)
+	}
+
+	_, err = s.users.CreateUser(ctx, req)
+	wantError(t, err, ErrIdentityTaken)
+
+	_, err = s.users.CreateUser(ctx, &models.CreateUserRequest{FirstName: "Aigerim", LastName: "Sadykova"})
+	if err == nil {
+		t.Error("CreateUser without an identity succeeded")
+	}
+}
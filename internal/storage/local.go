@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps blobs as files in a directory on the local filesystem
+type LocalStore struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalStore creates a local blob store rooted at dir.
+// Object URLs are built by joining baseURL and the object key.
+func NewLocalStore(dir string, baseURL string) (*LocalStore, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalStore{
+		root:    root,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// Put writes the content to a file, going through a temporary file so readers
+// never observe a partially written object
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the file stored under the key
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete removes the file stored under the key
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// URL returns the public URL of the object
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// Key extracts the object key from a URL produced by URL
+func (s *LocalStore) Key(url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, s.baseURL+"/")
+	if !ok || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+// List walks the files under the prefix. Temporary files of writes in progress are skipped.
+func (s *LocalStore) List(ctx context.Context, prefix string, fn func(BlobInfo) error) error {
+	dir := s.root
+	if prefix != "" {
+		path, err := s.path(prefix)
+		if err != nil {
+			return err
+		}
+		dir = path
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+
+		return fn(BlobInfo{
+			Key:     filepath.ToSlash(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Handler serves stored files over HTTP without directory listings.
+// The request path, after any prefix has been stripped, is the object key.
+// Private objects are never served.
+func (s *LocalStore) Handler() http.Handler {
+	fileServer := http.FileServer(http.Dir(s.root))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") || IsPrivateKey(strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")) {
+			http.NotFound(w, r)
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// path maps a key to a file path, rejecting keys that would escape the root directory
+func (s *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
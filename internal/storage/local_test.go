@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore_PutGetDelete(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "http://localhost:4000/uploads/")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	ctx := context.Background()
+	key := "items/1/photo.jpg"
+
+	if err := store.Put(ctx, key, strings.NewReader("jpeg bytes"), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	rc, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	content, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(content) != "jpeg bytes" {
+		t.Errorf("Get() content = %q, want %q", content, "jpeg bytes")
+	}
+
+	if got, want := store.URL(key), "http://localhost:4000/uploads/items/1/photo.jpg"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+
+	if got, ok := store.Key(store.URL(key)); !ok || got != key {
+		t.Errorf("Key(URL()) = %q, %v, want %q, true", got, ok, key)
+	}
+	if _, ok := store.Key("https://example.com/photo.jpg"); ok {
+		t.Errorf("Key() of foreign URL ok = true, want false")
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() missing error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStore_RejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+
+	for _, key := range []string{"", "../secret", "items/../../etc/passwd"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), "text/plain"); err == nil {
+			t.Errorf("Put(%q) error = nil, want error", key)
+		}
+	}
+}
+
+func TestLocalStore_Handler(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	if err := store.Put(context.Background(), "items/1/a.jpg", strings.NewReader("data"), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	store.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/items/1/a.jpg", nil))
+	if rec.Code != 200 || rec.Body.String() != "data" {
+		t.Errorf("Handler() file = %d %q, want 200 %q", rec.Code, rec.Body.String(), "data")
+	}
+
+	rec = httptest.NewRecorder()
+	store.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/items/1/", nil))
+	if rec.Code != 404 {
+		t.Errorf("Handler() directory status = %d, want 404", rec.Code)
+	}
+
+	if err := store.Put(context.Background(), PrivatePrefix+"photos/id.jpg", strings.NewReader("secret"), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	for _, target := range []string{"/private/photos/id.jpg", "/items/../private/photos/id.jpg", "//private/photos/id.jpg"} {
+		rec = httptest.NewRecorder()
+		store.Handler().ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != 404 {
+			t.Errorf("Handler() private file %q status = %d, want 404", target, rec.Code)
+		}
+	}
+}
+
+func TestLocalStore_List(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"items/1/a.jpg", "items/2/b.jpg", "other/c.jpg"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), "image/jpeg"); err != nil {
+			t.Fatalf("Put(%q) error = %v", key, err)
+		}
+	}
+
+	var keys []string
+	err = store.List(ctx, "items", func(info BlobInfo) error {
+		keys = append(keys, info.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "items/1/a.jpg" || keys[1] != "items/2/b.jpg" {
+		t.Errorf("List() keys = %v, want [items/1/a.jpg items/2/b.jpg]", keys)
+	}
+
+	if err := store.List(ctx, "missing", func(BlobInfo) error { return nil }); err != nil {
+		t.Errorf("List() of missing prefix error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner([]byte("test-secret"), "/api/private_photos/")
+	key := PrivatePrefix + "photos/abc.jpg"
+	now := time.Unix(1_700_000_000, 0)
+
+	signed := signer.SignedURL(key, now.Add(15*time.Minute))
+	if !strings.HasPrefix(signed, "/api/private_photos/photos/abc.jpg?") {
+		t.Fatalf("SignedURL() = %q, want path /api/private_photos/photos/abc.jpg", signed)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	expires := u.Query().Get("expires")
+	signature := u.Query().Get("signature")
+
+	tests := []struct {
+		name      string
+		key       string
+		expires   string
+		signature string
+		now       time.Time
+		wantErr   error
+	}{
+		{name: "valid", key: key, expires: expires, signature: signature, now: now},
+		{name: "expired", key: key, expires: expires, signature: signature, now: now.Add(time.Hour), wantErr: ErrURLExpired},
+		{name: "other key", key: PrivatePrefix + "photos/other.jpg", expires: expires, signature: signature, now: now, wantErr: ErrInvalidSignature},
+		{name: "extended expiry", key: key, expires: "9999999999", signature: signature, now: now, wantErr: ErrInvalidSignature},
+		{name: "bad expiry", key: key, expires: "soon", signature: signature, now: now, wantErr: ErrInvalidSignature},
+		{name: "missing signature", key: key, expires: expires, signature: "", now: now, wantErr: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := signer.Verify(tt.key, tt.expires, tt.signature, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	other := NewURLSigner([]byte("other-secret"), "/api/private_photos")
+	if err := other.Verify(key, expires, signature, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with another secret error = %v, want ErrInvalidSignature", err)
+	}
+}
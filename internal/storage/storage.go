@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when a blob does not exist
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores binary objects such as uploaded photos under string keys
+type BlobStore interface {
+	// Put stores the content under the key, replacing any existing object
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Get opens the object stored under the key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under the key
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of the object stored under the key
+	URL(key string) string
+	// Key returns the key of the object with the given URL, reporting false when
+	// the URL does not point into this store
+	Key(url string) (string, bool)
+	// List calls fn for every object whose key starts with the prefix
+	List(ctx context.Context, prefix string, fn func(BlobInfo) error) error
+}
+
+// BlobInfo describes a stored object
+type BlobInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// NewKey builds a random object key under the prefix with the given file extension
+func NewKey(prefix string, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s%s", prefix, hex.EncodeToString(b), ext), nil
+}
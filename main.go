@@ -1,14 +1,25 @@
+// Command shary_be runs the rental platform API and its administrative tasks:
+//
+//	shary_be [serve]                  run the API server (the default)
+//	shary_be migrate up               apply pending migrations
+//	shary_be migrate down [-steps N]  roll back the last N migrations (default 1)
+//	shary_be migrate status           show the schema version
+//	shary_be migrate force VERSION    mark VERSION as applied after a failed migration was repaired
+//	shary_be seed [-replace]          load sample data into an empty database, or replace it
+//	shary_be user create-admin ...    create an administrator
+//	shary_be reindex                  rebuild the database indexes
+//	shary_be photo-gc [-dry-run]      remove stored photo files nothing references
+//	shary_be reprocess-photos         strip metadata and regenerate variants of stored photos
+//
+// Every command reads the same environment configuration as the API server.
 package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -16,108 +27,112 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"shary_be/internal/config"
-	"shary_be/internal/handlers"
-	"shary_be/internal/repository"
-	"shary_be/internal/router"
-	"shary_be/internal/service"
+	"shary_be/internal/database"
 )
 
+// errUsage is returned by commands given invalid arguments, after printing how to use them
+var errUsage = errors.New("invalid arguments")
+
+// commands maps command names to their implementations
+var commands = map[string]func(cfg *config.Config, logger *zap.Logger, args []string) error{
+	"serve":            serve,
+	"migrate":          migrateCommand,
+	"seed":             seed,
+	"user":             userCommand,
+	"reindex":          reindex,
+	"photo-gc":         photoGC,
+	"reprocess-photos": reprocessPhotos,
+}
+
 func main() {
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
 	// Load configuration
 	cfg := config.Load()
 
-	// Initialize logger based on configuration
-	var logger *zap.Logger
-	var err error
+	logger, err := newLogger(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	if err := command(cfg, logger, args); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		logger.Error("Command failed", zap.String("command", name), zap.Error(err))
+		logger.Sync()
+		os.Exit(1)
+	}
+}
+
+// usage prints the commands to stderr
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: shary_be <command> [arguments]
+
+Commands:
+  serve                  run the API server (the default)
+  migrate up             apply pending migrations
+  migrate down           roll back migrations (-steps N, default 1)
+  migrate status         show the schema version
+  migrate force VERSION  mark VERSION as applied after a failed migration was repaired
+  seed                   load sample data into an empty database (-replace to reload it)
+  user create-admin      create an administrator
+  reindex                rebuild the database indexes
+  photo-gc               remove stored photo files nothing references (-dry-run, -grace)
+  reprocess-photos       strip metadata and regenerate variants of stored photos
+`)
+}
 
+// newLogger creates a logger for the configured log level
+func newLogger(cfg *config.Config) (*zap.Logger, error) {
 	switch cfg.LogLevel {
 	case "debug":
-		logger, err = zap.NewDevelopment()
+		return zap.NewDevelopment()
 	case "production":
-		logger, err = zap.NewProduction()
+		return zap.NewProduction()
 	default:
 		// Default to info level
 		config := zap.NewProductionConfig()
 		config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-		logger, err = config.Build()
+		return config.Build()
 	}
+}
 
-	if err != nil {
-		log.Fatalf("Failed to initialize logger: %v", err)
-	}
-	defer logger.Sync()
-
-	logger.Info("Starting application",
-		zap.String("environment", cfg.Environment),
-		zap.String("log_level", cfg.LogLevel),
-		zap.Int("port", cfg.Port),
-	)
-
-	// Initialize database connection
+// connect opens the configured database
+func connect(cfg *config.Config) (*sqlx.DB, error) {
 	db, err := sqlx.Connect("postgres", cfg.DatabaseURL)
 	if err != nil {
-		logger.Error("Failed to connect to database", zap.Error(err))
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	defer db.Close()
+	return db, nil
+}
 
-	// Test database connection
-	if err := db.Ping(); err != nil {
-		logger.Error("Failed to ping database", zap.Error(err))
-		os.Exit(1)
-	}
-	logger.Info("Successfully connected to database")
-
-	// Initialize repositories
-	itemRepo := repository.NewItemRepository(db)
-	itemPhotoRepo := repository.NewItemPhotoRepository(db)
-	categoryRepo := repository.NewCategoryRepository(db)
-
-	// Initialize services
-	itemService := service.NewItemService(itemRepo, logger, db)
-	itemPhotoService := service.NewItemPhotoService(itemPhotoRepo, itemRepo, logger, db)
-	categoryService := service.NewCategoryService(categoryRepo, logger)
-
-	// Initialize handlers
-	itemHandler := handlers.NewItemHandler(itemService, logger)
-	itemPhotoHandler := handlers.NewItemPhotoHandler(itemPhotoService, logger)
-	categoryHandler := handlers.NewCategoryHandler(categoryService, logger)
-
-	// Setup Chi router
-	handler := router.SetupRouter(itemHandler, itemPhotoHandler, categoryHandler, logger)
-
-	// Create server
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      handler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+// connectMigrated opens the configured database and checks its schema is up to date,
+// for commands that read or write application data
+func connectMigrated(cfg *config.Config) (*sqlx.DB, error) {
+	db, err := connect(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	// Start server in a goroutine
-	go func() {
-		logger.Info("Starting server", zap.Int("port", cfg.Port))
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatal("Server failed to start", zap.Error(err))
-		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down server...")
-
-	// Create a deadline for server shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Attempt graceful shutdown
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+	if _, err := database.CheckSchema(context.Background(), db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w; run `shary_be migrate up` first", err)
 	}
-
-	logger.Info("Server exited")
+	return db, nil
 }
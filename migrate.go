@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"shary_be/internal/config"
+	"shary_be/internal/database"
+)
+
+const migrateUsage = `Usage:
+  shary_be migrate up               apply pending migrations
+  shary_be migrate down [-steps N]  roll back the last N migrations (default 1)
+  shary_be migrate status           show the schema version
+  shary_be migrate force VERSION    mark VERSION as applied after a failed migration was repaired
+`
+
+// migrateCommand applies, rolls back or inspects the embedded migrations
+func migrateCommand(cfg *config.Config, logger *zap.Logger, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return errUsage
+	}
+	action, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	steps := 1
+	if action == "down" {
+		flags.IntVar(&steps, "steps", 1, "number of migrations to roll back")
+	}
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	var run func(m *database.Migrator) error
+	switch {
+	case action == "up" && flags.NArg() == 0:
+		run = func(m *database.Migrator) error { return m.Up() }
+	case action == "down" && flags.NArg() == 0 && steps > 0:
+		run = func(m *database.Migrator) error { return m.Down(steps) }
+	case action == "status" && flags.NArg() == 0:
+		run = func(m *database.Migrator) error { return nil }
+	case action == "force" && flags.NArg() == 1:
+		version, err := strconv.Atoi(flags.Arg(0))
+		if err != nil || version < -1 {
+			fmt.Fprintf(os.Stderr, "invalid version %q\n", flags.Arg(0))
+			return errUsage
+		}
+		run = func(m *database.Migrator) error { return m.Force(version) }
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return errUsage
+	}
+
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err := run(migrator); err != nil {
+		return err
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	printMigrationStatus(status)
+	return nil
+}
+
+// printMigrationStatus prints the schema version and whether migrations are pending
+func printMigrationStatus(status database.MigrationStatus) {
+	state := "up to date"
+	switch {
+	case status.Dirty:
+		state = "dirty, repair the failed migration and run `shary_be migrate force VERSION`"
+	case status.Behind():
+		state = "migrations pending"
+	case status.Version > status.Latest:
+		state = "ahead of this binary"
+	}
+	fmt.Printf("Schema version %d, latest migration %d: %s\n", status.Version, status.Latest, state)
+}
@@ -0,0 +1,2 @@
+-- Drop category_translations table
+DROP TABLE IF EXISTS category_translations CASCADE;
@@ -0,0 +1,38 @@
+-- Create category_translations table for localized category names.
+-- The name in categories is the default (Russian) name and is used as a fallback.
+CREATE TABLE IF NOT EXISTS category_translations (
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    lang VARCHAR(8) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (category_id, lang)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_translations_lang ON category_translations(lang);
+
+-- Insert Kazakh and English names for the sample categories
+INSERT INTO category_translations (category_id, lang, name)
+SELECT c.id, t.lang, t.name
+FROM (VALUES
+    ('Электроника', 'kk', 'Электроника'),
+    ('Электроника', 'en', 'Electronics'),
+    ('Одежда и обувь', 'kk', 'Киім және аяқ киім'),
+    ('Одежда и обувь', 'en', 'Clothing and shoes'),
+    ('Спорт и отдых', 'kk', 'Спорт және демалыс'),
+    ('Спорт и отдых', 'en', 'Sports and leisure'),
+    ('Книги и образование', 'kk', 'Кітаптар және білім'),
+    ('Книги и образование', 'en', 'Books and education'),
+    ('Дом и сад', 'kk', 'Үй және бақ'),
+    ('Дом и сад', 'en', 'Home and garden'),
+    ('Транспорт', 'kk', 'Көлік'),
+    ('Транспорт', 'en', 'Transport'),
+    ('Красота и здоровье', 'kk', 'Сұлулық және денсаулық'),
+    ('Красота и здоровье', 'en', 'Beauty and health'),
+    ('Игрушки и хобби', 'kk', 'Ойыншықтар және хобби'),
+    ('Игрушки и хобби', 'en', 'Toys and hobbies'),
+    ('Другое', 'kk', 'Басқа'),
+    ('Другое', 'en', 'Other')
+) AS t(category_name, lang, name)
+INNER JOIN categories c ON c.name = t.category_name
+ON CONFLICT (category_id, lang) DO NOTHING;
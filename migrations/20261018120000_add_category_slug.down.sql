@@ -0,0 +1,4 @@
+-- Remove URL slug from categories
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_slug_key;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS slug;
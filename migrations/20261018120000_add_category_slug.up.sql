@@ -0,0 +1,24 @@
+-- Add URL slug to categories
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS slug VARCHAR(120);
+
+-- Backfill slugs for the sample categories (transliterated names)
+UPDATE categories c
+SET slug = s.slug
+FROM (VALUES
+    ('Электроника', 'elektronika'),
+    ('Одежда и обувь', 'odezhda-i-obuv'),
+    ('Спорт и отдых', 'sport-i-otdyh'),
+    ('Книги и образование', 'knigi-i-obrazovanie'),
+    ('Дом и сад', 'dom-i-sad'),
+    ('Транспорт', 'transport'),
+    ('Красота и здоровье', 'krasota-i-zdorove'),
+    ('Игрушки и хобби', 'igrushki-i-hobbi'),
+    ('Другое', 'drugoe')
+) AS s(name, slug)
+WHERE c.name = s.name AND c.slug IS NULL;
+
+-- Any other existing categories get an ID-based slug that can be renamed later
+UPDATE categories SET slug = 'category-' || id WHERE slug IS NULL;
+
+ALTER TABLE categories ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE categories ADD CONSTRAINT categories_slug_key UNIQUE (slug);
@@ -0,0 +1,6 @@
+-- Remove attribute schema from categories and attribute values from items
+DROP INDEX IF EXISTS idx_items_attributes;
+
+ALTER TABLE items DROP COLUMN IF EXISTS attributes;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS attribute_schema;
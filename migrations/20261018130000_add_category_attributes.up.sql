@@ -0,0 +1,24 @@
+-- Add typed attribute schema to categories and attribute values to items
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS attribute_schema JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_items_attributes ON items USING gin(attributes);
+
+-- Define attributes for the sample categories
+UPDATE categories
+SET attribute_schema = '[
+    {"key": "year", "label": "Год выпуска", "type": "number", "required": true},
+    {"key": "transmission", "label": "Коробка передач", "type": "enum", "required": false, "options": ["manual", "automatic"]}
+]'
+WHERE name = 'Транспорт';
+
+UPDATE categories
+SET attribute_schema = '[
+    {"key": "size", "label": "Размер", "type": "enum", "required": true, "options": ["XS", "S", "M", "L", "XL", "XXL"]}
+]'
+WHERE name = 'Одежда и обувь';
+
+UPDATE items
+SET attributes = '{"size": "M"}'
+WHERE title = 'Платье вечернее' AND attributes = '{}';
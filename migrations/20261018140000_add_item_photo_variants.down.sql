@@ -0,0 +1,4 @@
+-- Remove resized variant URLs from item photos
+DROP INDEX IF EXISTS idx_item_photos_url;
+
+ALTER TABLE item_photos DROP COLUMN IF EXISTS variants;
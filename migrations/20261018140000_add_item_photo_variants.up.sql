@@ -0,0 +1,4 @@
+-- Add resized variant URLs to item photos
+ALTER TABLE item_photos ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_item_photos_url ON item_photos(url);
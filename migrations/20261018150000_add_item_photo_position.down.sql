@@ -0,0 +1,6 @@
+-- Remove display order and the cover flag from item photos
+DROP INDEX IF EXISTS idx_item_photos_cover;
+DROP INDEX IF EXISTS idx_item_photos_item_position;
+
+ALTER TABLE item_photos DROP COLUMN IF EXISTS is_cover;
+ALTER TABLE item_photos DROP COLUMN IF EXISTS position;
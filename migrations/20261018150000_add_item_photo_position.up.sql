@@ -0,0 +1,20 @@
+-- Add display order and an explicit cover flag to item photos
+ALTER TABLE item_photos ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE item_photos ADD COLUMN IF NOT EXISTS is_cover BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Keep the existing order: photos were shown in insertion order
+UPDATE item_photos p
+SET position = ordered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY item_id ORDER BY created_at, id) - 1 AS position
+    FROM item_photos
+) ordered
+WHERE p.id = ordered.id;
+
+-- The first photo was the de facto main photo
+UPDATE item_photos SET is_cover = TRUE WHERE position = 0;
+
+CREATE INDEX IF NOT EXISTS idx_item_photos_item_position ON item_photos(item_id, position);
+
+-- At most one cover photo per item
+CREATE UNIQUE INDEX IF NOT EXISTS idx_item_photos_cover ON item_photos(item_id) WHERE is_cover;
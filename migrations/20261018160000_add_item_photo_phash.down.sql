@@ -0,0 +1,5 @@
+-- Remove perceptual hashes and duplicate flags from item photos
+DROP INDEX IF EXISTS idx_item_photos_duplicate_of;
+
+ALTER TABLE item_photos DROP COLUMN IF EXISTS duplicate_of;
+ALTER TABLE item_photos DROP COLUMN IF EXISTS phash;
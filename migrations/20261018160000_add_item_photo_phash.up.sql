@@ -0,0 +1,5 @@
+-- Add perceptual hashes to item photos and flag near-duplicates of other authors' photos
+ALTER TABLE item_photos ADD COLUMN IF NOT EXISTS phash BIGINT;
+ALTER TABLE item_photos ADD COLUMN IF NOT EXISTS duplicate_of INTEGER REFERENCES item_photos(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_item_photos_duplicate_of ON item_photos(duplicate_of) WHERE duplicate_of IS NOT NULL;
@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS category_translations_touch_category ON category_translations;
+DROP FUNCTION IF EXISTS touch_translation_category();
+
+DROP TRIGGER IF EXISTS item_photos_touch_item ON item_photos;
+DROP FUNCTION IF EXISTS touch_photo_item();
+
+DROP TRIGGER IF EXISTS categories_bump_version ON categories;
+DROP TRIGGER IF EXISTS items_bump_version ON items;
+DROP FUNCTION IF EXISTS bump_version();
+
+ALTER TABLE categories DROP COLUMN IF EXISTS version;
+ALTER TABLE items DROP COLUMN IF EXISTS version;
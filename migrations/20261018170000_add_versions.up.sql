@@ -0,0 +1,62 @@
+-- Versions for optimistic concurrency: clients send the version they read as an ETag in
+-- If-Match, and an update is refused when the row has changed since
+ALTER TABLE items ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Every update makes a new version, whichever code path made it
+CREATE OR REPLACE FUNCTION bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS items_bump_version ON items;
+CREATE TRIGGER items_bump_version
+    BEFORE UPDATE ON items
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+DROP TRIGGER IF EXISTS categories_bump_version ON categories;
+CREATE TRIGGER categories_bump_version
+    BEFORE UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+-- Photos are part of an item's representation, so changing them updates the item.
+-- Variants and perceptual hashes are filled in later and are left out.
+CREATE OR REPLACE FUNCTION touch_photo_item() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE items SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.item_id;
+    ELSIF TG_OP = 'DELETE' THEN
+        UPDATE items SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.item_id;
+    ELSE
+        UPDATE items SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.item_id;
+        IF NEW.item_id <> OLD.item_id THEN
+            UPDATE items SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.item_id;
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS item_photos_touch_item ON item_photos;
+CREATE TRIGGER item_photos_touch_item
+    AFTER INSERT OR DELETE OR UPDATE OF item_id, url, position, is_cover ON item_photos
+    FOR EACH ROW EXECUTE FUNCTION touch_photo_item();
+
+-- Likewise, translations are part of a category's representation
+CREATE OR REPLACE FUNCTION touch_translation_category() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE categories SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.category_id;
+    ELSE
+        UPDATE categories SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.category_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS category_translations_touch_category ON category_translations;
+CREATE TRIGGER category_translations_touch_category
+    AFTER INSERT OR UPDATE OR DELETE ON category_translations
+    FOR EACH ROW EXECUTE FUNCTION touch_translation_category();
@@ -0,0 +1,2 @@
+-- Administrators are created with `shary_be user create-admin`
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
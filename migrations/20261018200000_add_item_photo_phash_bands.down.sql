@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_item_photos_phash_band3;
+DROP INDEX IF EXISTS idx_item_photos_phash_band2;
+DROP INDEX IF EXISTS idx_item_photos_phash_band1;
+DROP INDEX IF EXISTS idx_item_photos_phash_band0;
@@ -0,0 +1,6 @@
+-- Index the four 16-bit bands of perceptual hashes so near-duplicates are looked up
+-- by band instead of comparing every stored hash
+CREATE INDEX IF NOT EXISTS idx_item_photos_phash_band0 ON item_photos (((phash >> 48) & 65535)) WHERE phash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_item_photos_phash_band1 ON item_photos (((phash >> 32) & 65535)) WHERE phash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_item_photos_phash_band2 ON item_photos (((phash >> 16) & 65535)) WHERE phash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_item_photos_phash_band3 ON item_photos ((phash & 65535)) WHERE phash IS NOT NULL;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS private_photos;
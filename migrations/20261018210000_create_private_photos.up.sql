@@ -0,0 +1,8 @@
+-- Owners of private photos; only the owner can get a new signed URL for a photo
+CREATE TABLE IF NOT EXISTS private_photos (
+    key TEXT PRIMARY KEY,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_private_photos_owner_id ON private_photos(owner_id);
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations so the binary can apply them without
+// the migrations directory being shipped next to it
+package migrations
+
+import "embed"
+
+// FS holds the up and down migrations, named <version>_<name>.(up|down).sql
+//
+//go:embed *.sql
+var FS embed.FS
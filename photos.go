@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"shary_be/internal/config"
+	"shary_be/internal/repository"
+	"shary_be/internal/service"
+	"shary_be/internal/storage"
+)
+
+// photoGC removes stored photo files that no item photo references, once. The API
+// server runs the same collection periodically; this is for running it by hand.
+func photoGC(cfg *config.Config, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("photo-gc", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", cfg.PhotoGCDryRun, "report orphaned files without deleting them")
+	grace := flags.Duration("grace", cfg.PhotoGCGracePeriod, "keep files stored more recently than this")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: shary_be photo-gc [-dry-run] [-grace DURATION]")
+		return errUsage
+	}
+
+	localStore, err := openLocalStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := connectMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	collector := service.NewPhotoGarbageCollector(localStore, repository.NewItemPhotoRepository(db), service.PhotoGCOptions{
+		GracePeriod: *grace,
+		DryRun:      *dryRun,
+	}, logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	result, err := collector.Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to collect orphaned photos: %w", err)
+	}
+
+	verb := "Removed"
+	if result.DryRun {
+		verb = "Would remove"
+	}
+	for _, key := range result.Removed {
+		fmt.Printf("%s %s\n", verb, key)
+	}
+	fmt.Printf("Scanned %d files, %s %d (%d bytes), %d failed\n",
+		result.Scanned, strings.ToLower(verb), len(result.Removed), result.RemovedBytes, result.Failed)
+	return nil
+}
+
+// reprocessPhotos strips metadata from and applies EXIF orientation to photos already
+// held in local storage, regenerating their resized variants and perceptual hashes.
+// It is meant to be run once against existing data.
+func reprocessPhotos(cfg *config.Config, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("reprocess-photos", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: shary_be reprocess-photos")
+		return errUsage
+	}
+
+	localStore, err := openLocalStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := connectMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	itemPhotoRepo := repository.NewItemPhotoRepository(db)
+
+	// Variants are generated synchronously, so a single idle worker is enough
+	variantPool := service.NewPhotoVariantPool(localStore, itemPhotoRepo, logger, 1, 0)
+	defer variantPool.Close()
+
+	itemPhotoService := service.NewItemPhotoService(itemPhotoRepo, repository.NewItemRepository(db), localStore, variantPool, nil, service.PhotoUploadOptions{
+		MaxBytes: cfg.UploadMaxBytes,
+		MaxFiles: cfg.UploadMaxFiles,
+	}, cfg.MaxPhotosPerItem, service.PhotoDuplicateOptions{
+		Policy:      cfg.PhotoDuplicatePolicy,
+		MaxDistance: cfg.PhotoDuplicateMaxDistance,
+	}, logger, repository.NewTxManager(db))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	result, err := itemPhotoService.ReprocessStoredPhotos(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reprocess photos: %w", err)
+	}
+
+	fmt.Printf("Photos reprocessed: %d, skipped: %d, failed: %d\n", result.Processed, result.Skipped, result.Failed)
+	if result.Failed > 0 {
+		return fmt.Errorf("failed to reprocess %d photos", result.Failed)
+	}
+	return nil
+}
+
+// openLocalStore opens the configured photo storage, which the photo maintenance
+// commands can only work on when it is local
+func openLocalStore(cfg *config.Config) (*storage.LocalStore, error) {
+	if cfg.StorageBackend != "local" {
+		return nil, fmt.Errorf("unsupported storage backend %q, only local storage is supported", cfg.StorageBackend)
+	}
+
+	localStore, err := storage.NewLocalStore(cfg.StorageLocalDir, cfg.StoragePublicURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize local storage: %w", err)
+	}
+	return localStore, nil
+}
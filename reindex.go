@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"shary_be/internal/config"
+	"shary_be/internal/database"
+)
+
+// reindex rebuilds the indexes of the application tables
+func reindex(cfg *config.Config, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	concurrently := flags.Bool("concurrently", false, "rebuild without blocking writes (PostgreSQL 12 or later)")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: shary_be reindex [-concurrently]")
+		return errUsage
+	}
+
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return database.Reindex(ctx, db, *concurrently, func(table string) {
+		fmt.Printf("Reindexed %s\n", table)
+	})
+}
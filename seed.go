@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"shary_be/internal/config"
+	"shary_be/internal/database"
+)
+
+// seed loads the sample data into an empty database, or replaces the sample data there
+func seed(cfg *config.Config, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	replace := flags.Bool("replace", false, "remove the sample users and categories already there first")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: shary_be seed [-replace]")
+		return errUsage
+	}
+
+	db, err := connectMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := database.Seed(context.Background(), db, *replace); err != nil {
+		return err
+	}
+
+	fmt.Println("Sample data loaded")
+	return nil
+}
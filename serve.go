@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"shary_be/internal/config"
+	"shary_be/internal/database"
+	"shary_be/internal/handlers"
+	"shary_be/internal/idempotency"
+	"shary_be/internal/remote"
+	"shary_be/internal/repository"
+	"shary_be/internal/router"
+	"shary_be/internal/service"
+	"shary_be/internal/storage"
+)
+
+// serve runs the API server until it receives SIGINT or SIGTERM
+func serve(cfg *config.Config, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: shary_be serve")
+		return errUsage
+	}
+
+	logger.Info("Starting application",
+		zap.String("environment", cfg.Environment),
+		zap.String("log_level", cfg.LogLevel),
+		zap.Int("port", cfg.Port),
+	)
+
+	// Initialize database connection
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Test database connection
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	logger.Info("Successfully connected to database")
+
+	// Apply or check migrations before serving, so the server never runs against a
+	// schema it was not built for
+	if cfg.MigrateOnStart {
+		if err := database.RunMigrations(context.Background(), db); err != nil {
+			return fmt.Errorf("failed to run database migrations: %w", err)
+		}
+	}
+	schema, err := database.CheckSchema(context.Background(), db)
+	if err != nil {
+		return fmt.Errorf("%w; run `shary_be migrate up` or set MIGRATE_ON_START=true", err)
+	}
+	logger.Info("Database schema is up to date", zap.Uint("version", schema.Version))
+
+	// Initialize repositories
+	itemRepo := repository.NewItemRepository(db)
+	itemPhotoRepo := repository.NewItemPhotoRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	privatePhotoRepo := repository.NewPrivatePhotoRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	txManager := repository.NewTxManager(db)
+
+	// Initialize photo storage
+	var blobStore storage.BlobStore
+	var uploadsHandler http.Handler
+	switch cfg.StorageBackend {
+	case "local":
+		localStore, err := storage.NewLocalStore(cfg.StorageLocalDir, cfg.StoragePublicURL)
+		if err != nil {
+			return fmt.Errorf("failed to initialize local storage: %w", err)
+		}
+		blobStore = localStore
+		uploadsHandler = localStore.Handler()
+	default:
+		return fmt.Errorf("unsupported storage backend %q", cfg.StorageBackend)
+	}
+
+	switch cfg.PhotoDuplicatePolicy {
+	case service.DuplicatePolicyOff, service.DuplicatePolicyFlag, service.DuplicatePolicyReject:
+	default:
+		return fmt.Errorf("unsupported photo duplicate policy %q", cfg.PhotoDuplicatePolicy)
+	}
+
+	// Start background photo variant generation
+	variantPool := service.NewPhotoVariantPool(blobStore, itemPhotoRepo, logger, cfg.PhotoWorkers, cfg.PhotoQueueSize)
+	// Let queued photo variants finish before exiting
+	defer variantPool.Close()
+
+	// Private photos are served through signed URLs
+	signedURLSecret := []byte(cfg.SignedURLSecret)
+	if len(signedURLSecret) == 0 {
+		if cfg.Environment == "production" {
+			return errors.New("SIGNED_URL_SECRET must be set in production")
+		}
+		signedURLSecret = make([]byte, 32)
+		if _, err := rand.Read(signedURLSecret); err != nil {
+			return fmt.Errorf("failed to generate signed URL secret: %w", err)
+		}
+		logger.Warn("SIGNED_URL_SECRET is not set, signed URLs will stop working after a restart")
+	}
+	urlSigner := storage.NewURLSigner(signedURLSecret, "/api/v1/private_photos")
+
+	if cfg.GatewaySecret == "" {
+		logger.Warn("GATEWAY_SECRET is not set, requests naming a user in X-User-ID will be rejected")
+	}
+
+	// Periodically remove stored photo files nothing references
+	gcCtx, stopGC := context.WithCancel(context.Background())
+	defer stopGC()
+	if cfg.PhotoGCInterval > 0 {
+		photoGC := service.NewPhotoGarbageCollector(blobStore, itemPhotoRepo, service.PhotoGCOptions{
+			GracePeriod: cfg.PhotoGCGracePeriod,
+			DryRun:      cfg.PhotoGCDryRun,
+		}, logger)
+		go photoGC.Run(gcCtx, cfg.PhotoGCInterval)
+	}
+
+	// Hourly purge of idempotency keys past their retention
+	go idempotency.Purge(gcCtx, idempotencyRepo, cfg.IdempotencyKeyTTL, time.Hour, logger)
+
+	// Remote photo imports must not reach internal addresses
+	photoFetcher := remote.NewFetcher(remote.FetcherOptions{
+		Timeout:      cfg.PhotoImportTimeout,
+		MaxBytes:     cfg.UploadMaxBytes,
+		MaxRedirects: cfg.PhotoImportMaxRedirects,
+	})
+
+	// Initialize services
+	itemPhotoService := service.NewItemPhotoService(itemPhotoRepo, itemRepo, blobStore, variantPool, photoFetcher, service.PhotoUploadOptions{
+		MaxBytes: cfg.UploadMaxBytes,
+		MaxFiles: cfg.UploadMaxFiles,
+	}, cfg.MaxPhotosPerItem, service.PhotoDuplicateOptions{
+		Policy:      cfg.PhotoDuplicatePolicy,
+		MaxDistance: cfg.PhotoDuplicateMaxDistance,
+	}, logger, txManager)
+	itemService := service.NewItemService(itemRepo, categoryRepo, itemPhotoService, cfg.MaxPhotosPerItem, logger, txManager)
+	categoryService := service.NewCategoryService(categoryRepo, logger, txManager)
+	userService := service.NewUserService(userRepo, logger, txManager)
+	privatePhotoService := service.NewPrivatePhotoService(privatePhotoRepo, blobStore, urlSigner, cfg.SignedURLTTL, cfg.UploadMaxBytes, logger)
+
+	// Initialize handlers
+	itemHandler := handlers.NewItemHandler(itemService, logger)
+	itemPhotoHandler := handlers.NewItemPhotoHandler(itemPhotoService, logger)
+	privatePhotoHandler := handlers.NewPrivatePhotoHandler(privatePhotoService, cfg.UploadMaxBytes, logger)
+	categoryHandler := handlers.NewCategoryHandler(categoryService, logger)
+	userHandler := handlers.NewUserHandler(userService, logger)
+
+	// Setup Chi router
+	idempotent := idempotency.Middleware(idempotencyRepo, cfg.IdempotencyKeyTTL, logger)
+	handler := router.SetupRouter(itemHandler, itemPhotoHandler, privatePhotoHandler, categoryHandler, userHandler, uploadsHandler, idempotent, cfg.GatewaySecret, cfg.LegacyAPISunset, logger)
+
+	// Create server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Handler:      handler,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Start server in a goroutine
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Starting server", zap.Int("port", cfg.Port))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	// Wait for interrupt signal to gracefully shutdown the server
+	quit, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("server failed: %w", err)
+	case <-quit.Done():
+	}
+
+	logger.Info("Shutting down server...")
+
+	// Create a deadline for server shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Attempt graceful shutdown
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("server forced to shutdown: %w", err)
+	}
+
+	logger.Info("Server exited")
+	return nil
+}
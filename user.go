@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"shary_be/internal/config"
+	"shary_be/internal/models"
+	"shary_be/internal/repository"
+	"shary_be/internal/service"
+)
+
+const userUsage = `Usage:
+  shary_be user create-admin -first-name NAME -last-name NAME -identity IIN [-phone PHONE]
+`
+
+// userCommand manages users
+func userCommand(cfg *config.Config, logger *zap.Logger, args []string) error {
+	if len(args) == 0 || args[0] != "create-admin" {
+		fmt.Fprint(os.Stderr, userUsage)
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("user create-admin", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, userUsage)
+		flags.PrintDefaults()
+	}
+	firstName := flags.String("first-name", "", "first name")
+	lastName := flags.String("last-name", "", "last name")
+	identity := flags.String("identity", "", "12-digit individual identification number")
+	phone := flags.String("phone", "", "phone number")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	req := &models.CreateUserRequest{
+		FirstName: *firstName,
+		LastName:  *lastName,
+		Identity:  *identity,
+		IsAdmin:   true,
+	}
+	if *phone != "" {
+		req.Phone = phone
+	}
+
+	db, err := connectMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	userService := service.NewUserService(repository.NewUserRepository(db), logger, repository.NewTxManager(db))
+	user, err := userService.CreateUser(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created administrator %s %s with ID %d\n", user.FirstName, user.LastName, user.ID)
+	return nil
+}